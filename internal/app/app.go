@@ -9,11 +9,15 @@ import (
 	"time"
 
 	"github.com/bethropolis/dir-dumper/internal/config"
+	"github.com/bethropolis/dir-dumper/internal/git"
 	"github.com/bethropolis/dir-dumper/internal/ignore"
 	"github.com/bethropolis/dir-dumper/internal/logger"
 	"github.com/bethropolis/dir-dumper/internal/printer"
+	"github.com/bethropolis/dir-dumper/internal/redact"
 	"github.com/bethropolis/dir-dumper/internal/setup"
+	"github.com/bethropolis/dir-dumper/internal/strip"
 	"github.com/bethropolis/dir-dumper/internal/summary"
+	"github.com/bethropolis/dir-dumper/internal/tokens"
 	"github.com/bethropolis/dir-dumper/internal/walker"
 	"github.com/fatih/color"
 )
@@ -31,8 +35,9 @@ func New(cfg *config.Config) *App {
 	color.NoColor = !cfg.UseColors
 
 	// Set up output destination
+	// Chunked output creates its own files when the dump is written
 	var output io.Writer = os.Stdout
-	if cfg.OutputFile != "" {
+	if cfg.OutputFile != "" && (!cfg.Chunked() || cfg.TreeOnly) {
 		file, err := os.Create(cfg.OutputFile)
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "ERROR: Failed to create output file: %v\n", err)
@@ -103,14 +108,17 @@ func (a *App) Run() {
 		a.log.Debug("Directory: %s", a.cfg.RootDir)
 		a.log.Debug("Concurrent mode: %v (workers: %d)", a.cfg.Concurrent, a.cfg.MaxWorkers)
 		a.log.Debug("Max file size: %d MB", a.cfg.MaxFileSizeMB)
-		a.log.Debug("Ignore settings: hidden=%v, git=%v",
-			a.cfg.IgnoreHidden, a.cfg.IgnoreGit)
+		a.log.Debug("Ignore settings: hidden=%v, git=%v, dumperignore=%v",
+			a.cfg.IgnoreHidden, a.cfg.IgnoreGit, a.cfg.DumperIgnore)
 		if a.cfg.CustomIgnore != "" {
 			a.log.Debug("Custom ignore patterns: %s", a.cfg.CustomIgnore)
 		}
 		if a.cfg.Extensions != "" {
 			a.log.Debug("Extensions filter: %s", a.cfg.Extensions)
 		}
+		if a.cfg.Include != "" {
+			a.log.Debug("Include patterns: %s", a.cfg.Include)
+		}
 	}
 
 	// --- Directory validation ---
@@ -135,20 +143,71 @@ func (a *App) Run() {
 		os.Exit(1)
 	}
 
+	// --- Resolve changed files from git if requested ---
+	var changes *setup.GitChanges
+	var changedPaths []string
+	if a.cfg.ChangedSince != "" || a.cfg.Staged || a.cfg.Modified {
+		// Changed files replace the walk, so there is no index left to filter
+		if a.cfg.GitTracked || a.cfg.GitUntracked {
+			a.log.Error("-git-tracked and -git-untracked cannot be combined with -changed-since, -staged or -modified.")
+			os.Exit(1)
+		}
+		changes, err = setup.LoadGitChanges(absRootDir, git.ChangeOptions{
+			Since:    a.cfg.ChangedSince,
+			Staged:   a.cfg.Staged,
+			Modified: a.cfg.Modified,
+		})
+		if err != nil {
+			a.log.Error("Failed to resolve git changes: %v", err)
+			os.Exit(1)
+		}
+		changedPaths = changes.Paths()
+		infoLog("Found %d changed files.", len(changedPaths))
+	}
+
+	// A tree lists only paths, so no file is read past its binary check
+	if a.cfg.TreeOnly {
+		a.ignoreForTreeOnly()
+	}
+
+	// Streamed content never sits in memory, so nothing can rewrite it
+	if a.cfg.Stream {
+		if err := a.checkStream(); err != nil {
+			a.log.Error("%v", err)
+			os.Exit(1)
+		}
+		infoLog("Streaming file contents to the output.")
+	}
+
 	// Configure the walker using the setup package
 	walkerConfig := setup.WalkerConfig{
-		RootDir:       absRootDir,
-		Concurrent:    a.cfg.Concurrent,
-		MaxWorkers:    a.cfg.MaxWorkers,
-		MaxFileSizeMB: a.cfg.MaxFileSizeMB,
-		Extensions:    a.cfg.Extensions,
-		IgnoreHidden:  a.cfg.IgnoreHidden,
-		IgnoreGit:     a.cfg.IgnoreGit,
-		CustomIgnore:  a.cfg.CustomIgnore,
-		ShowProgress:  a.cfg.ShowProgress,
-		Timeout:       ctx,
-		Quiet:         a.cfg.Quiet,
-		Logger:        a.log,
+		RootDir:        absRootDir,
+		Concurrent:     a.cfg.Concurrent,
+		MaxWorkers:     a.cfg.MaxWorkers,
+		MaxFileSizeMB:  a.cfg.MaxFileSizeMB,
+		MaxInFlightMB:  a.cfg.MaxInFlightMB,
+		MaxDepth:       a.cfg.MaxDepth,
+		MaxFilesDir:    a.cfg.MaxFilesDir,
+		BinaryPolicy:   a.cfg.BinaryPolicy,
+		Extensions:     a.cfg.Extensions,
+		Include:        a.cfg.Include,
+		Outline:        a.cfg.Outline,
+		Head:           a.cfg.Head,
+		Tail:           a.cfg.Tail,
+		Truncate:       a.cfg.Truncate,
+		FollowSymlinks: a.cfg.FollowSymlinks,
+		SymlinkTargets: a.cfg.SymlinkTargets,
+		IgnoreHidden:   a.cfg.IgnoreHidden,
+		IgnoreGit:      a.cfg.IgnoreGit,
+		DumperIgnore:   a.cfg.DumperIgnore,
+		CustomIgnore:   a.cfg.CustomIgnore,
+		GitTracked:     a.cfg.GitTracked,
+		GitUntracked:   a.cfg.GitUntracked,
+		Paths:          changedPaths,
+		ShowProgress:   a.cfg.ShowProgress,
+		Timeout:        ctx,
+		Quiet:          a.cfg.Quiet,
+		Logger:         a.log,
 	}
 
 	matcher, walkOptions, err := setup.ConfigureWalker(walkerConfig, infoLog)
@@ -157,26 +216,105 @@ func (a *App) Run() {
 		os.Exit(1)
 	}
 
+	// --- Check for secrets before writing anything if requested ---
+	if a.cfg.FailOnSecrets {
+		infoLog("Scanning for secrets before dumping.")
+		found, err := a.scanSecrets(absRootDir, matcher, walkOptions, changes)
+		if err != nil {
+			a.log.Error("Critical error during secret scan: %v", err)
+			os.Exit(1)
+		}
+		if len(found) > 0 {
+			summary.DisplayRedactions(a.log, found, os.Stderr, a.cfg.Quiet)
+			a.log.Error("Found secrets in %d files; nothing was dumped (-fail-on-secrets).", len(found))
+			a.discardOutput()
+			os.Exit(1)
+		}
+	}
+	var redactor *redact.Redactor
+	var redactions []summary.FileRedactions
+	if a.cfg.Redact {
+		redactor = redact.New()
+	}
+	var stripped summary.Stripped
+	if a.cfg.KeepLicense && !a.cfg.StripComments {
+		a.log.Warn("-keep-license has no effect without -strip-comments.")
+	}
+
 	// --- Create the printer ---
+	// Colors only make sense for the plain text format on a terminal
+	formatOptions := printer.FormatOptions{
+		UseColors:   a.cfg.UseColors,
+		Markdown:    printer.MarkdownOptions{TOC: a.cfg.TOC},
+		XML:         printer.XMLOptions{Root: a.cfg.XMLRoot, CDATA: a.cfg.XMLCDATA},
+		LineNumbers: a.cfg.LineNumbers,
+	}
+	if err := formatOptions.XML.SetTags(a.cfg.XMLTags); err != nil {
+		a.log.Error("%v", err)
+		os.Exit(1)
+	}
+	if formatOptions.Meta, err = printer.ParseMetaFields(a.cfg.Meta); err != nil {
+		a.log.Error("%v", err)
+		os.Exit(1)
+	}
+	if a.cfg.Template != "" {
+		if a.cfg.Format != "text" && a.cfg.Format != "template" {
+			a.log.Warn("-template overrides -format %s", a.cfg.Format)
+		}
+		a.cfg.Format = "template"
+		formatOptions.Template, err = printer.LoadTemplate(a.cfg.Template)
+		if err != nil {
+			a.log.Error("%v", err)
+			os.Exit(1)
+		}
+	}
+	formatter, err := printer.NewFormatter(a.cfg.Format, formatOptions)
+	if err != nil {
+		a.log.Error("%v", err)
+		os.Exit(1)
+	}
+	a.log.Debug("Output format: %s", a.cfg.Format)
+	if a.cfg.Stream && !a.cfg.TreeOnly {
+		// Streamed files are only read ahead if their lines or hash are shown
+		scan := a.cfg.Format == "jsonl" || formatOptions.Meta.Has(printer.MetaLines) || formatOptions.Meta.Has(printer.MetaSHA256)
+		walkOptions = append(walkOptions, walker.WithStreamScan(scan))
+	}
+
 	p := printer.New()
 	p.WithOutput(a.Output)
-	p.WithColors(a.cfg.UseColors)
+	p.WithFormatter(formatter)
+	p.WithTree(printer.TreeOptions{
+		Enabled: a.cfg.Tree,
+		Skipped: a.cfg.TreeSkipped,
+		Only:    a.cfg.TreeOnly,
+	})
+	if a.cfg.Chunked() {
+		if err := a.configureChunks(p, formatOptions); err != nil {
+			a.log.Error("%v", err)
+			os.Exit(1)
+		}
+	}
+	p.Begin(printer.Document{Root: absRootDir})
 
-	// Enable JSON output if requested
-	if a.cfg.JSONOutput {
-		a.log.Debug("JSON output mode enabled")
-		p.WithJSON(true)
-		// Disable colors in JSON mode regardless of other settings
-		p.WithColors(false)
-	} else if a.cfg.MarkdownOutput {
-		a.log.Debug("Markdown output mode enabled")
-		p.WithMarkdown(true)
-		// Disable colors in Markdown mode regardless of other settings
-		p.WithColors(false)
+	// --- Set up token counting if requested ---
+	var counter tokens.Counter
+	var tokenCounts []summary.FileTokens
+	totalTokens := 0
+	if a.cfg.ShowTokens || a.cfg.MaxTokens > 0 || a.cfg.StripComments {
+		// Stripping comments reports the tokens it saves
+		counter, err = tokens.New(a.cfg.Tokenizer)
+		if err != nil {
+			a.log.Error("%v", err)
+			os.Exit(1)
+		}
+		if a.cfg.MaxTokens > 0 {
+			infoLog("Token budget: %d tokens (%s estimate).", a.cfg.MaxTokens, a.cfg.Tokenizer)
+		}
 	}
 
 	// --- Define walk function ---
-	printFunc := func(relativePath string, content []byte, err error) error {
+	printFunc := func(file walker.File, err error) error {
+		relativePath, content := file.RelativePath, file.Content
 		if err != nil {
 			a.log.Warn("Skipping file '%s' due to error: %v", relativePath, err)
 			return nil // Error handled by logging
@@ -187,9 +325,42 @@ func (a *App) Run() {
 			relativePath, content == nil)
 
 		if content != nil { // Ensure content was actually read
+			diff := ""
+			if changes != nil && a.cfg.ShowDiff {
+				// Diff the file as stored, then redact both sides alike
+				diff = diffFile(changes, absRootDir, file)
+			}
+			n := -1 // Tokens in content, once counted
+			if a.cfg.StripComments {
+				content, n = a.stripComments(&file, counter, &stripped)
+			}
+			if redactor != nil {
+				if found := redactContent(redactor, &file.Meta, &content, &diff); len(found) > 0 {
+					a.log.Debug("Redacted %d secrets in %s", len(found), relativePath)
+					redactions = append(redactions, summary.FileRedactions{Path: relativePath, Findings: found})
+					n = -1
+				}
+			}
+
 			// Debug info before printing
 			a.log.Debug("About to print file: %s (%d bytes)", relativePath, len(content))
-			p.PrintFile(relativePath, content)
+			if counter != nil {
+				// Files are delivered in walk order, so the budget is filled
+				// greedily: a file that does not fit is skipped, but smaller
+				// files after it may still be included
+				if n < 0 {
+					n = counter.Count(content)
+				}
+				if a.cfg.MaxTokens > 0 && totalTokens+n > a.cfg.MaxTokens {
+					a.log.Debug("Skipping file %s: %d tokens exceed the remaining budget of %d",
+						relativePath, n, a.cfg.MaxTokens-totalTokens)
+					return walker.SkipFile(walker.ReasonSkippedTokenLimit)
+				}
+				totalTokens += n
+				tokenCounts = append(tokenCounts, summary.FileTokens{Path: relativePath, Tokens: n})
+			}
+			entry := printer.Entry{Path: relativePath, Content: content, Diff: diff, Meta: &file.Meta}
+			p.PrintEntry(entry)
 			// Debug info after printing
 			a.log.Debug("After printing file: %s (printer count: %d)", relativePath, p.GetCount())
 		} else {
@@ -199,13 +370,30 @@ func (a *App) Run() {
 		return nil // Indicate success to walker
 	}
 
+	// --- Define stream walk function ---
+	streamFunc := func(file walker.StreamFile, err error) error {
+		if err != nil {
+			a.log.Warn("Skipping file '%s' due to error: %v", file.RelativePath, err)
+			return nil // Error handled by logging
+		}
+		a.log.Debug("About to stream file: %s (%d bytes)", file.RelativePath, file.Size)
+		p.PrintEntry(printer.Entry{Path: file.RelativePath, Reader: file.Content, Meta: &file.Meta})
+		a.log.Debug("After printing file: %s (printer count: %d)", file.RelativePath, p.GetCount())
+		return nil
+	}
+
 	// --- Start the directory walk ---
 	infoLog("Scanning directory: %s", absRootDir)
 	if a.cfg.Concurrent {
 		infoLog("Using concurrent processing with %d workers.", a.cfg.MaxWorkers)
 	}
 
-	skippedItems, err := a.walkDirectory(absRootDir, matcher, printFunc, walkOptions)
+	var skippedItems []walker.SkippedItem
+	if a.cfg.Stream || a.cfg.TreeOnly {
+		skippedItems, err = walker.WalkStreams(absRootDir, matcher, streamFunc, walkOptions...)
+	} else {
+		skippedItems, err = a.walkDirectory(absRootDir, matcher, printFunc, walkOptions)
+	}
 
 	// --- Handle walk errors ---
 	if err != nil {
@@ -217,20 +405,253 @@ func (a *App) Run() {
 	summary.DisplayResults(a.log, p.GetCount(), time.Since(startTime), a.cfg.Quiet)
 
 	// Finalize the printer (important for JSON output to close the array)
+	p.PrintSkipped(skippedItems)
 	p.Finalize()
 
+	if counter != nil {
+		infoLog("Estimated tokens: %d.", totalTokens)
+	}
+
+	if a.cfg.StripComments {
+		summary.DisplayStripped(a.log, stripped, a.cfg.Quiet)
+	}
+
+	// --- Show redacted secrets ---
+	if len(redactions) > 0 {
+		a.log.Warn("Redacted secrets in %d files.", len(redactions))
+		summary.DisplayRedactions(a.log, redactions, os.Stderr, a.cfg.Quiet)
+	}
+
+	// --- Show Token Counts (if requested) ---
+	if a.cfg.ShowTokens {
+		summary.DisplayTokenCounts(a.log, tokenCounts, os.Stderr, a.cfg.Quiet)
+	}
+
 	// --- Show Skipped Items (if requested) ---
 	if a.cfg.ShowSkipped {
 		summary.DisplaySkippedItems(a.log, skippedItems, os.Stderr, a.cfg.Quiet)
 	}
 }
 
+// checkStream rejects options that need each file's whole content in
+// memory, which -stream does not keep
+func (a *App) checkStream() error {
+	conflicts := []struct {
+		set  bool
+		flag string
+	}{
+		{a.cfg.Redact, "-redact"},
+		{a.cfg.FailOnSecrets, "-fail-on-secrets"},
+		{a.cfg.StripComments, "-strip-comments"},
+		{a.cfg.ShowTokens, "-tokens"},
+		{a.cfg.MaxTokens > 0, "-max-tokens"},
+		{a.cfg.ShowDiff, "-diff"},
+		{a.cfg.Chunked(), "-chunk-bytes, -chunk-lines and -chunk-tokens"},
+		{a.cfg.Template != "" || a.cfg.Format == "template", "-template"},
+	}
+	for _, c := range conflicts {
+		if c.set {
+			return fmt.Errorf("-stream cannot be combined with %s", c.flag)
+		}
+	}
+	return nil
+}
+
+// ignoreForTreeOnly turns off, with a warning, the options that read or
+// rewrite file contents, which a tree of paths never shows
+func (a *App) ignoreForTreeOnly() {
+	ignored := []struct {
+		set   bool
+		flag  string
+		clear func()
+	}{
+		{a.cfg.Redact, "-redact", func() { a.cfg.Redact = false }},
+		{a.cfg.FailOnSecrets, "-fail-on-secrets", func() { a.cfg.FailOnSecrets = false }},
+		{a.cfg.StripComments, "-strip-comments", func() { a.cfg.StripComments, a.cfg.KeepLicense = false, false }},
+		{a.cfg.ShowTokens, "-tokens", func() { a.cfg.ShowTokens = false }},
+		{a.cfg.MaxTokens > 0, "-max-tokens", func() { a.cfg.MaxTokens = 0 }},
+		{a.cfg.ShowDiff, "-diff", func() { a.cfg.ShowDiff = false }},
+		{a.cfg.Outline != "", "-outline", func() { a.cfg.Outline = "" }},
+		{a.cfg.Head > 0 || a.cfg.Tail > 0 || a.cfg.Truncate != "", "-head, -tail and -truncate", func() {
+			a.cfg.Head, a.cfg.Tail, a.cfg.Truncate = 0, 0, ""
+		}},
+	}
+	for _, o := range ignored {
+		if o.set {
+			a.log.Warn("%s is ignored with -tree-only.", o.flag)
+			o.clear()
+		}
+	}
+}
+
+// configureChunks sets up the printer to split the dump into numbered files
+func (a *App) configureChunks(p *printer.Printer, formatOptions printer.FormatOptions) error {
+	if a.cfg.OutputFile == "" {
+		return fmt.Errorf("-chunk-bytes, -chunk-lines and -chunk-tokens require -output")
+	}
+	if a.cfg.TreeOnly {
+		a.log.Warn("Chunking is ignored with -tree-only.")
+		return nil
+	}
+	if formatOptions.Markdown.TOC {
+		// A table of contents holds the whole document until the end, so it
+		// cannot be measured entry by entry
+		a.log.Warn("-toc is not supported with chunked output and is ignored.")
+		formatOptions.Markdown.TOC = false
+	}
+
+	opts := printer.ChunkOptions{
+		Path:      a.cfg.OutputFile,
+		MaxBytes:  a.cfg.ChunkBytes,
+		MaxLines:  a.cfg.ChunkLines,
+		MaxTokens: a.cfg.ChunkTokens,
+	}
+	if opts.MaxTokens > 0 {
+		counter, err := tokens.New(a.cfg.Tokenizer)
+		if err != nil {
+			return err
+		}
+		opts.Counter = counter
+	}
+
+	a.log.Debug("Splitting output into chunks next to %s", a.cfg.OutputFile)
+	p.WithChunks(opts, func() (printer.Formatter, error) {
+		return printer.NewFormatter(a.cfg.Format, formatOptions)
+	})
+	return nil
+}
+
+// scanSecrets walks the directory without printing and returns the files
+// containing secrets, including secrets in their diffs
+func (a *App) scanSecrets(
+	rootDir string,
+	matcher *ignore.IgnoreMatcher,
+	options []walker.Option,
+	changes *setup.GitChanges,
+) ([]summary.FileRedactions, error) {
+	redactor := redact.New()
+	var found []summary.FileRedactions
+	_, err := a.walkDirectory(rootDir, matcher, func(file walker.File, err error) error {
+		if err != nil || file.Content == nil {
+			return nil // Reported by the dump itself
+		}
+		content, diff := file.Content, ""
+		if changes != nil && a.cfg.ShowDiff {
+			diff = diffFile(changes, rootDir, file)
+		}
+		if a.cfg.StripComments && file.Meta.Truncation.Omitted == 0 {
+			// Secrets in stripped comments are not dumped
+			var lines []int
+			content, lines = strip.CommentsWithLines(content, file.Meta.Language, a.stripOptions())
+			mapSourceLines(&file.Meta, lines)
+		}
+		if findings := redactContent(redactor, &file.Meta, &content, &diff); len(findings) > 0 {
+			found = append(found, summary.FileRedactions{Path: file.RelativePath, Findings: findings})
+		}
+		return nil
+	}, options)
+	return found, err
+}
+
+// diffFile returns the diff of the file as stored against its base.
+// Truncated files are not whole, so they have no diff, binary files only
+// have a line saying they differ, and content rewritten by a transform is
+// read again from disk, so that the diff shows the changes made to the file
+// rather than what the transform left out.
+func diffFile(changes *setup.GitChanges, rootDir string, file walker.File) string {
+	if file.Meta.Truncation.Omitted > 0 {
+		return ""
+	}
+	if file.Meta.Binary {
+		return changes.BinaryDiff(file.RelativePath)
+	}
+	content := file.Content
+	if file.Meta.Transformed {
+		stored, err := os.ReadFile(filepath.Join(rootDir, file.RelativePath))
+		if err != nil {
+			return ""
+		}
+		content = stored
+	}
+	return changes.Diff(file.RelativePath, content)
+}
+
+// stripComments returns the file's content without comments and its token
+// count (-1 if it was not counted), adding what stripping saved to stripped.
+// The file's source lines are updated to number what is left as in the file.
+// Truncated files are left as they are: a cut can fall inside a comment or a
+// string, and stripping could then remove the elision marker.
+func (a *App) stripComments(file *walker.File, counter tokens.Counter, stripped *summary.Stripped) ([]byte, int) {
+	if file.Meta.Truncation.Omitted > 0 {
+		a.log.Debug("Not stripping comments from truncated file %s", file.RelativePath)
+		return file.Content, -1
+	}
+	content, lines := strip.CommentsWithLines(file.Content, file.Meta.Language, a.stripOptions())
+	if len(content) == len(file.Content) {
+		return content, -1
+	}
+	mapSourceLines(&file.Meta, lines)
+	a.log.Debug("Stripped %d bytes of comments and blank lines from %s",
+		len(file.Content)-len(content), file.RelativePath)
+	n := counter.Count(content)
+	stripped.Add(len(file.Content), len(content), counter.Count(file.Content), n)
+	return content, n
+}
+
+// stripOptions returns the options for stripping comments
+func (a *App) stripOptions() strip.Options {
+	return strip.Options{KeepLicense: a.cfg.KeepLicense}
+}
+
+// mapSourceLines records that the file's content was rewritten, lines being
+// the line in the previous content of each new line. The previous content
+// may itself have been rewritten, so lines are mapped through its own.
+func mapSourceLines(meta *walker.FileMeta, lines []int) {
+	if lines == nil {
+		return
+	}
+	for i, line := range lines {
+		lines[i] = meta.SourceLine(line)
+	}
+	meta.SourceLines = lines
+}
+
+// redactContent replaces secrets in a file's content and diff in place and
+// returns what was found, updating the file's source lines if secrets
+// spanning lines were collapsed. Findings in the diff have no line.
+func redactContent(redactor *redact.Redactor, meta *walker.FileMeta, content *[]byte, diff *string) []redact.Finding {
+	redacted, findings, lines := redactor.RedactWithLines(*content, meta.Language)
+	for i := range findings {
+		findings[i].Line = meta.SourceLine(findings[i].Line)
+	}
+	*content = redacted
+	mapSourceLines(meta, lines)
+	if *diff != "" {
+		redacted, inDiff := redactor.Redact([]byte(*diff), meta.Language)
+		for _, f := range inDiff {
+			f.Line = 0
+			findings = append(findings, f)
+		}
+		*diff = string(redacted)
+	}
+	return findings
+}
+
+// discardOutput closes and removes the output file, if any, so a failed run
+// leaves nothing behind
+func (a *App) discardOutput() {
+	if file, ok := a.Output.(*os.File); ok && file != os.Stdout {
+		file.Close()
+		os.Remove(file.Name())
+	}
+}
+
 // walkDirectory is a helper method that performs the actual directory walk
 func (a *App) walkDirectory(
 	rootDir string,
 	matcher *ignore.IgnoreMatcher,
-	walkFn walker.WalkFunc,
+	walkFn walker.FileFunc,
 	options []walker.Option,
 ) ([]walker.SkippedItem, error) {
-	return walker.Walk(rootDir, matcher, walkFn, options...)
+	return walker.WalkFiles(rootDir, matcher, walkFn, options...)
 }
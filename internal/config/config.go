@@ -4,8 +4,11 @@ import (
 	"flag"
 	"os"
 	"runtime" // Add runtime for CPU core count
+	"strings"
 	"time"
 
+	"github.com/bethropolis/dir-dumper/internal/outline"
+	"github.com/bethropolis/dir-dumper/internal/printer"
 	"github.com/mattn/go-isatty"
 )
 
@@ -27,18 +30,68 @@ type Config struct {
 	Concurrent    bool
 	MaxWorkers    int
 	MaxFileSizeMB int64
+	MaxDepth      int
+	MaxFilesDir   int
 	ShowProgress  bool
 	Timeout       time.Duration
+	BinaryPolicy  string
+	Stream        bool
+	MaxInFlightMB int64
+
+	// Token settings
+	ShowTokens bool
+	Tokenizer  string
+	MaxTokens  int
+
+	// Symlink settings
+	FollowSymlinks bool
+	SymlinkTargets bool
 
 	// Filtering settings
 	IgnoreHidden bool
 	IgnoreGit    bool
+	DumperIgnore bool
 	CustomIgnore string
 	Extensions   string
+	Include      string
+	GitTracked   bool
+	GitUntracked bool
+	ChangedSince string
+	Staged       bool
+	Modified     bool
+	ShowDiff     bool
 
 	// Output format
-	JSONOutput     bool
-	MarkdownOutput bool
+	Format      string
+	XMLRoot     string
+	XMLTags     string
+	XMLCDATA    bool
+	TOC         bool
+	Template    string
+	Meta        string
+	LineNumbers bool
+
+	// Secrets
+	Redact        bool
+	FailOnSecrets bool
+
+	// Content transforms
+	StripComments bool
+	KeepLicense   bool
+	Outline       string
+	Head          int
+	Tail          int
+	Truncate      string
+
+	// Output chunking
+	ChunkBytes  int64
+	ChunkLines  int64
+	ChunkTokens int
+
+	// Directory tree
+	Tree        bool
+	TreeSkipped bool
+	TreeOnly    bool
 
 	// Version info
 	ShowVersion bool
@@ -58,19 +111,57 @@ func New() *Config {
 	flag.StringVar(&c.LogLevel, "log-level", "INFO", "Set the logging level (DEBUG, INFO, WARN, ERROR)")
 	flag.BoolVar(&c.Concurrent, "concurrent", false, "Enable concurrent file processing")
 	flag.IntVar(&c.MaxWorkers, "workers", runtime.NumCPU(), "Max number of concurrent workers (defaults to number of CPU cores)")
-	flag.Int64Var(&c.MaxFileSizeMB, "max-size", 0, "Max file size to process in MB (0 = no limit)")
+	flag.Int64Var(&c.MaxFileSizeMB, "max-size", 0, "Max file size to process in MB; text files with a line limit are truncated instead (0 = no limit)")
+	flag.BoolVar(&c.Stream, "stream", false, "Stream file contents from disk to the output instead of reading each file into memory (cannot be combined with redaction, comment stripping, token counting, diffs, chunking or templates)")
+	flag.Int64Var(&c.MaxInFlightMB, "max-inflight", 256, "With -concurrent, max MB of file content workers may read ahead of the output (0 = no limit)")
+	flag.StringVar(&c.BinaryPolicy, "binary", "skip", "How to handle binary files: skip, placeholder (size and MIME type), or base64")
+	flag.BoolVar(&c.ShowTokens, "tokens", false, "Estimate token counts and show them per file and in total at the end")
+	flag.StringVar(&c.Tokenizer, "tokenizer", "bpe", "Token estimator: bpe (embedded vocabulary) or chars (4 bytes per token)")
+	flag.IntVar(&c.MaxTokens, "max-tokens", 0, "Skip files that would push the estimated token total past this budget (0 = no limit)")
+	flag.BoolVar(&c.Redact, "redact", false, "Replace secrets (cloud keys, private keys, tokens, passwords) in the output with placeholders")
+	flag.BoolVar(&c.FailOnSecrets, "fail-on-secrets", false, "Scan for secrets first and exit with an error, writing nothing, if any are found")
+	flag.BoolVar(&c.StripComments, "strip-comments", false, "Remove comments and collapse blank lines in supported languages, keeping strings intact")
+	flag.BoolVar(&c.KeepLicense, "keep-license", false, "With -strip-comments, keep license comments at the start of files")
+	flag.StringVar(&c.Outline, "outline", "", "Reduce files with these extensions to an outline of their declarations without function bodies (comma-separated; supported: "+strings.Join(outline.Extensions(), ", ")+")")
+	flag.IntVar(&c.Head, "head", 0, "Keep only the first N lines of each text file, noting how many lines were left out (0 = all)")
+	flag.IntVar(&c.Tail, "tail", 0, "Keep only the last N lines of each text file; with -head, keep both ends (0 = all)")
+	flag.StringVar(&c.Truncate, "truncate", "", "Per-file line limits overriding -head/-tail, as comma-separated pattern=limit rules where limit is head:N, tail:N, head:N+tail:N or none (e.g. '*.log=tail:200'); the last matching rule wins")
+	flag.BoolVar(&c.FollowSymlinks, "follow-symlinks", false, "Follow symlinks, descending into linked directories (loops and links outside the root are skipped)")
+	flag.BoolVar(&c.SymlinkTargets, "symlink-targets", false, "Emit symlinks as 'path -> target' entries instead of their content")
+	flag.IntVar(&c.MaxDepth, "max-depth", 0, "Max directory depth to include files from (1 = root only, 0 = no limit)")
+	flag.IntVar(&c.MaxFilesDir, "max-files-per-dir", 0, "Max number of files to include from each directory (0 = no limit)")
 	flag.BoolVar(&c.IgnoreHidden, "hidden", true, "Ignore hidden files/directories (starting with '.')")
 	flag.BoolVar(&c.IgnoreGit, "git", true, "Ignore .git directories")
+	flag.BoolVar(&c.DumperIgnore, "dumperignore", true, "Honor .dumperignore/.dirdumperignore files found in the directory tree")
 	flag.StringVar(&c.CustomIgnore, "ignore", "", "Custom ignore patterns (comma-separated, gitignore syntax)")
 	flag.StringVar(&c.Extensions, "ext", "", "Only include files with these extensions (comma-separated, e.g., 'go,md,txt')")
+	flag.StringVar(&c.Include, "include", "", "Only include files matching these patterns (comma-separated, gitignore syntax, e.g., 'cmd/**/*.go,Makefile')")
+	flag.BoolVar(&c.GitTracked, "git-tracked", false, "Only include files tracked by git (read from the repository index)")
+	flag.BoolVar(&c.GitUntracked, "git-untracked", false, "With git tracked files, also include untracked files that are not ignored (implies -git-tracked)")
+	flag.StringVar(&c.ChangedSince, "changed-since", "", "Only include files changed in the working tree since a git revision (e.g., 'main', 'HEAD~3')")
+	flag.BoolVar(&c.Staged, "staged", false, "Only include files with staged changes (index differs from HEAD)")
+	flag.BoolVar(&c.Modified, "modified", false, "Only include files with unstaged changes (working tree differs from index)")
+	flag.BoolVar(&c.ShowDiff, "diff", false, "With -changed-since, -staged or -modified, include each file's unified diff")
 	flag.BoolVar(&c.NoColor, "no-color", false, "Disable color output")
 	flag.StringVar(&c.OutputFile, "output", "", "Output to file instead of stdout")
 	flag.BoolVar(&c.ShowProgress, "progress", false, "Show progress information")
 	flag.DurationVar(&c.Timeout, "timeout", 0, "Maximum execution time (e.g., '30s', '5m')")
 	flag.BoolVar(&c.ShowSkipped, "show-skipped", false, "Show a list of skipped files/directories and reasons at the end")
 	flag.BoolVar(&c.ShowVersion, "version", false, "Show version information")
-	flag.BoolVar(&c.JSONOutput, "json", false, "Output results in JSON format")
-	flag.BoolVar(&c.MarkdownOutput, "markdown", false, "Output results in Markdown format")
+	flag.StringVar(&c.Format, "format", "text", "Output format: "+strings.Join(printer.Formats(), ", "))
+	flag.Int64Var(&c.ChunkBytes, "chunk-bytes", 0, "With -output, split the dump into numbered files of at most this many bytes (0 = no limit)")
+	flag.Int64Var(&c.ChunkLines, "chunk-lines", 0, "With -output, split the dump into numbered files of at most this many lines (0 = no limit)")
+	flag.IntVar(&c.ChunkTokens, "chunk-tokens", 0, "With -output, split the dump into numbered files of at most this many estimated tokens (0 = no limit)")
+	flag.BoolVar(&c.Tree, "tree", false, "Write a directory tree of the included files before the contents")
+	flag.BoolVar(&c.TreeSkipped, "tree-skipped", false, "With -tree or -tree-only, also show skipped paths marked with their reason")
+	flag.BoolVar(&c.TreeOnly, "tree-only", false, "Write only the directory tree, without file contents")
+	flag.StringVar(&c.Template, "template", "", "Render the output with a text/template file or a built-in template ("+strings.Join(printer.BuiltinTemplates(), ", ")+"); implies -format template")
+	flag.BoolVar(&c.LineNumbers, "line-numbers", false, "Prefix each line of content with its line number (JSON: lines as {n, text} objects, JSONL: a start_line field)")
+	flag.StringVar(&c.Meta, "meta", "", "Include file metadata in the output (comma-separated: size, mode, mtime, lines, language, sha256, or all)")
+	flag.BoolVar(&c.TOC, "toc", false, "With -format markdown, start the document with a table of contents linking to each file")
+	flag.StringVar(&c.XMLRoot, "xml-root", printer.DefaultXMLRoot, "With -format xml, the element wrapping all documents (empty for none)")
+	flag.StringVar(&c.XMLTags, "xml-tags", "", "With -format xml, override tag names (comma-separated key=name, keys: document, source, content, diff, tree)")
+	flag.BoolVar(&c.XMLCDATA, "xml-cdata", false, "With -format xml, wrap content in CDATA sections instead of escaping it")
 
 	flag.Parse()
 
@@ -79,3 +170,8 @@ func New() *Config {
 
 	return c
 }
+
+// Chunked reports whether the output is split into several files
+func (c *Config) Chunked() bool {
+	return c.ChunkBytes > 0 || c.ChunkLines > 0 || c.ChunkTokens > 0
+}
@@ -0,0 +1,261 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Change statuses
+const (
+	StatusAdded    = "added"
+	StatusModified = "modified"
+)
+
+// ChangeOptions selects which changes ChangedFiles reports. When several
+// are set, the union is returned and each file is compared against the
+// first applicable base in the order Since, Staged, Modified.
+type ChangeOptions struct {
+	Since    string // Working tree differs from this revision
+	Staged   bool   // Index differs from HEAD
+	Modified bool   // Working tree differs from the index
+}
+
+// Change is a file whose content differs from a base version. Deleted files
+// are not reported, since there is nothing left to dump.
+type Change struct {
+	Path   string   // Slash-separated path relative to the requested directory
+	Status string   // StatusAdded or StatusModified
+	BaseID ObjectID // Base blob, empty for added files
+}
+
+// ChangedFiles returns the changed files below dir, sorted by path
+func (r *Repository) ChangedFiles(dir string, opts ChangeOptions) ([]Change, error) {
+	index, err := r.ReadIndex()
+	if err != nil {
+		return nil, err
+	}
+	staged := make(map[string]IndexEntry, len(index.Entries))
+	for _, entry := range index.Entries {
+		if entry.Stage == 0 && entry.IsFile() && !entry.SkipWorktree {
+			staged[entry.Path] = entry
+		}
+	}
+	tree := &worktree{repo: r, index: index, staged: staged, autocrlf: r.autoCRLF()}
+
+	changes := make(map[string]Change)
+	add := func(path, status string, base ObjectID) {
+		if _, exists := changes[path]; !exists {
+			changes[path] = Change{Path: path, Status: status, BaseID: base}
+		}
+	}
+
+	if opts.Since != "" {
+		id, err := r.ResolveRevision(opts.Since)
+		if err != nil {
+			return nil, err
+		}
+		files, err := r.CommitFiles(id)
+		if err != nil {
+			return nil, err
+		}
+
+		paths := make(map[string]struct{}, len(files)+len(staged))
+		for path := range files {
+			paths[path] = struct{}{}
+		}
+		for path := range staged {
+			paths[path] = struct{}{}
+		}
+		for path := range paths {
+			current, ok, err := tree.blobID(path)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue // Deleted from the working tree
+			}
+			if base, inBase := files[path]; !inBase {
+				add(path, StatusAdded, "")
+			} else if base.ID != current {
+				add(path, StatusModified, base.ID)
+			}
+		}
+	}
+
+	if opts.Staged {
+		head, err := r.headFiles()
+		if err != nil {
+			return nil, err
+		}
+		for path, entry := range staged {
+			indexID := ObjectID(fmt.Sprintf("%x", entry.Hash))
+			if base, inHead := head[path]; !inHead {
+				add(path, StatusAdded, "")
+			} else if base.ID != indexID {
+				add(path, StatusModified, base.ID)
+			}
+		}
+	}
+
+	if opts.Modified {
+		for path, entry := range staged {
+			current, ok, err := tree.blobID(path)
+			if err != nil {
+				return nil, err
+			}
+			indexID := ObjectID(fmt.Sprintf("%x", entry.Hash))
+			if ok && current != indexID {
+				add(path, StatusModified, indexID)
+			}
+		}
+	}
+
+	// Keep changes below dir, with paths relative to it
+	result := make([]Change, 0, len(changes))
+	for _, change := range changes {
+		if rel, ok := r.RelativePath(dir, change.Path); ok {
+			change.Path = rel
+			result = append(result, change)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Path < result[j].Path })
+	return result, nil
+}
+
+// headFiles returns the files in HEAD's tree, or none on an unborn branch
+func (r *Repository) headFiles() (map[string]TreeEntry, error) {
+	id, ok, err := r.readRef("HEAD", 0)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return map[string]TreeEntry{}, nil
+	}
+	return r.CommitFiles(id)
+}
+
+// worktree hashes working tree files against an index
+type worktree struct {
+	repo     *Repository
+	index    *Index
+	staged   map[string]IndexEntry // Stage 0 entries by path
+	autocrlf bool
+}
+
+// blobID returns the object ID a working tree file would have if staged.
+// Files whose stat data matches their index entry are not read: they are
+// taken to hold the staged blob, as git status does. ok is false if the
+// file does not exist.
+func (w *worktree) blobID(path string) (ObjectID, bool, error) {
+	info, err := os.Lstat(filepath.Join(w.repo.WorkTree, filepath.FromSlash(path)))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	if entry, ok := w.staged[path]; ok && w.statClean(entry, info) {
+		return ObjectID(fmt.Sprintf("%x", entry.Hash)), true, nil
+	}
+
+	content, err := w.repo.ReadWorktreeFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return w.repo.HashBlob(convertToGit(content, w.autocrlf)), true, nil
+}
+
+// statClean reports whether a file's type, size and mtime match its index
+// entry. Entries modified in the same second as the index was written are
+// "racily clean": a later write within that second would leave the stat
+// data unchanged, so like git they are never trusted.
+func (w *worktree) statClean(entry IndexEntry, info os.FileInfo) bool {
+	isLink := info.Mode()&os.ModeSymlink != 0
+	if isLink != (entry.Mode&modeTypeMask == ModeSymlink&modeTypeMask) || uint32(info.Size()) != entry.Size {
+		return false
+	}
+
+	mtime := info.ModTime()
+	if mtime.Unix() != entry.MTime.Unix() {
+		return false
+	}
+	// Nanoseconds are zero when the filesystem or git did not record them
+	if entry.MTime.Nanosecond() != 0 && mtime.Nanosecond() != entry.MTime.Nanosecond() {
+		return false
+	}
+	return entry.MTime.Before(w.index.ModTime.Truncate(time.Second))
+}
+
+// autoCRLF reports whether core.autocrlf converts CRLF line endings to LF
+// when files are staged ("true" or "input")
+func (r *Repository) autoCRLF() bool {
+	value, _ := r.configValue("core", "autocrlf")
+	switch strings.ToLower(value) {
+	case "true", "yes", "on", "1", "input":
+		return true
+	default:
+		return false
+	}
+}
+
+// NormalizeContent converts working tree content to what git would store
+// when staging it. Only core.autocrlf is applied; .gitattributes text, eol
+// and filter settings are not.
+func (r *Repository) NormalizeContent(content []byte) []byte {
+	return convertToGit(content, r.autoCRLF())
+}
+
+// convertToGit applies the autocrlf CRLF to LF conversion. As in git,
+// content that looks binary (a NUL or a lone CR) is left alone.
+func convertToGit(content []byte, autocrlf bool) []byte {
+	if !autocrlf || bytes.IndexByte(content, 0) >= 0 {
+		return content
+	}
+	crlf := bytes.Count(content, []byte("\r\n"))
+	if crlf == 0 || bytes.Count(content, []byte("\r")) != crlf {
+		return content
+	}
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// ReadWorktreeFile reads a file from the working tree as git sees it:
+// symlinks yield their target path rather than the linked content
+func (r *Repository) ReadWorktreeFile(path string) ([]byte, error) {
+	full := filepath.Join(r.WorkTree, filepath.FromSlash(path))
+	info, err := os.Lstat(full)
+	if err != nil {
+		return nil, err
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		target, err := os.Readlink(full)
+		if err != nil {
+			return nil, err
+		}
+		return []byte(filepath.ToSlash(target)), nil
+	}
+	if !info.Mode().IsRegular() {
+		return nil, os.ErrNotExist
+	}
+	return os.ReadFile(full)
+}
+
+// ReadBlob returns the content of a blob object
+func (r *Repository) ReadBlob(id ObjectID) ([]byte, error) {
+	objType, data, err := r.ReadObject(id)
+	if err != nil {
+		return nil, err
+	}
+	if objType != ObjectBlob {
+		return nil, fmt.Errorf("git: %s is a %s, not a blob", id, objType)
+	}
+	return data, nil
+}
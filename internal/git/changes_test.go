@@ -0,0 +1,178 @@
+package git
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// changesFixture creates a repository with two commits and then stages,
+// modifies, deletes and adds files in the working tree
+func changesFixture(t *testing.T) *fixture {
+	f := newFixture(t)
+	f.write("a.txt", "a\n")
+	f.write("b.txt", "b\n")
+	f.write("dir/c.txt", "c\n")
+	f.commit("first")
+	f.write("b.txt", "b changed\n")
+	f.write("d.txt", "d\n")
+	f.commit("second")
+
+	f.write("a.txt", "a staged\n")
+	f.write("e.txt", "e staged\n")
+	f.git("add", "a.txt", "e.txt")
+	f.write("dir/c.txt", "c modified\n")
+	f.write("untracked.txt", "u\n")
+	if err := os.Remove(filepath.Join(f.root, "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// formatChanges renders changes as "status path base" lines
+func formatChanges(changes []Change) string {
+	var lines []string
+	for _, c := range changes {
+		lines = append(lines, fmt.Sprintf("%s %s %s", c.Status, c.Path, c.BaseID))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestChangedFiles(t *testing.T) {
+	f := changesFixture(t)
+	blob := func(rev string) string { return f.git("rev-parse", rev) }
+
+	tests := []struct {
+		name string
+		dir  string
+		opts ChangeOptions
+		want []string
+	}{
+		{"staged", "", ChangeOptions{Staged: true}, []string{
+			"modified a.txt " + blob("HEAD:a.txt"),
+			"added e.txt ",
+		}},
+		{"modified", "", ChangeOptions{Modified: true}, []string{
+			"modified dir/c.txt " + blob(":dir/c.txt"),
+		}},
+		{"since", "", ChangeOptions{Since: "HEAD~1"}, []string{
+			"modified a.txt " + blob("HEAD~1:a.txt"),
+			"added d.txt ",
+			"modified dir/c.txt " + blob("HEAD~1:dir/c.txt"),
+			"added e.txt ",
+		}},
+		{"union", "", ChangeOptions{Staged: true, Modified: true}, []string{
+			"modified a.txt " + blob("HEAD:a.txt"),
+			"modified dir/c.txt " + blob(":dir/c.txt"),
+			"added e.txt ",
+		}},
+		{"below dir", "dir", ChangeOptions{Modified: true, Staged: true}, []string{
+			"modified c.txt " + blob(":dir/c.txt"),
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changes, err := f.repo().ChangedFiles(filepath.Join(f.root, tt.dir), tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got, want := formatChanges(changes), strings.Join(tt.want, "\n"); got != want {
+				t.Errorf("ChangedFiles =\n%s\nwant\n%s", got, want)
+			}
+		})
+	}
+}
+
+func TestChangedFilesUnbornBranch(t *testing.T) {
+	f := newFixture(t)
+	f.write("a.txt", "a\n")
+	f.git("add", "a.txt")
+	changes, err := f.repo().ChangedFiles(f.root, ChangeOptions{Staged: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := formatChanges(changes); got != "added a.txt " {
+		t.Errorf("ChangedFiles = %q, want a.txt added", got)
+	}
+}
+
+// setMTime sets a working tree file's modification time
+func (f *fixture) setMTime(path string, mtime time.Time) {
+	f.t.Helper()
+	if err := os.Chtimes(filepath.Join(f.root, path), mtime, mtime); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// TestModifiedUsesStatData checks that files whose size and mtime match the
+// index are not re-hashed, and that racily clean entries still are
+func TestModifiedUsesStatData(t *testing.T) {
+	past := time.Now().Add(-time.Hour).Truncate(time.Second)
+	future := time.Now().Add(time.Hour).Truncate(time.Second)
+
+	f := newFixture(t)
+	f.write("clean.txt", "aaaa\n")
+	f.write("racy.txt", "aaaa\n")
+	f.write("touched.txt", "aaaa\n")
+	f.setMTime("clean.txt", past)
+	f.setMTime("touched.txt", past)
+	f.setMTime("racy.txt", future) // Not older than the index, so racily clean
+	f.commit("initial")
+
+	// Same size and mtime: a content change is not seen, which shows the
+	// file was not read
+	f.write("clean.txt", "bbbb\n")
+	f.setMTime("clean.txt", past)
+	f.write("racy.txt", "bbbb\n")
+	f.setMTime("racy.txt", future)
+	// A different mtime with identical content is hashed and found unchanged
+	f.setMTime("touched.txt", past.Add(time.Minute))
+
+	changes, err := f.repo().ChangedFiles(f.root, ChangeOptions{Modified: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := formatChanges(changes), "modified racy.txt "+f.git("rev-parse", ":racy.txt"); got != want {
+		t.Errorf("ChangedFiles = %q, want %q", got, want)
+	}
+}
+
+func TestModifiedAutoCRLF(t *testing.T) {
+	for _, autocrlf := range []string{"true", "input", "false"} {
+		t.Run(autocrlf, func(t *testing.T) {
+			f := newFixture(t)
+			f.git("config", "core.autocrlf", autocrlf)
+			f.write("lf.txt", "one\ntwo\n")
+			f.write("binary.bin", "one\r\ntwo\x00\r\n")
+			f.commit("initial")
+
+			// Rewrite with CRLF line endings, as a Windows checkout would
+			f.write("lf.txt", "one\r\ntwo\r\n")
+			f.setMTime("binary.bin", time.Now().Add(-time.Hour))
+
+			repo := f.repo()
+			changes, err := repo.ChangedFiles(f.root, ChangeOptions{Modified: true})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := ""
+			if f.git("diff", "--name-only") == "lf.txt" {
+				want = "modified lf.txt " + f.git("rev-parse", ":lf.txt")
+			}
+			if got := formatChanges(changes); got != want {
+				t.Errorf("ChangedFiles = %q, want %q as git diff reports", got, want)
+			}
+			if autocrlf != "false" && want != "" {
+				t.Errorf("git reports lf.txt modified with core.autocrlf=%s", autocrlf)
+			}
+
+			diff := UnifiedDiff("lf.txt", "lf.txt", []byte("one\ntwo\n"), repo.NormalizeContent([]byte("one\r\ntwo\r\n")), 3)
+			if (diff == "") != (autocrlf != "false") {
+				t.Errorf("diff after NormalizeContent = %q", diff)
+			}
+		})
+	}
+}
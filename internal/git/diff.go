@@ -0,0 +1,285 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// DefaultDiffContext is the number of context lines around each hunk
+const DefaultDiffContext = 3
+
+// maxEditDistance bounds the Myers search; beyond it the changed region is
+// reported as a whole replacement, which keeps memory use predictable
+const maxEditDistance = 2000
+
+// edit is one line of an edit script: ' ' keep, '-' delete, '+' insert
+type edit struct {
+	op   byte
+	text string // Line including its trailing newline, if any
+}
+
+// binarySniffLen is how much of each side is checked for NUL bytes, as git
+// does to tell binary files from text
+const binarySniffLen = 8000
+
+// UnifiedDiff returns a unified diff from oldContent to newContent with
+// the given number of context lines, or "" if they are identical. An empty
+// oldName marks the file as added. If either side holds a NUL byte, the diff
+// is the one-line BinaryDiff instead.
+func UnifiedDiff(oldName, newName string, oldContent, newContent []byte, context int) string {
+	if isBinary(oldContent) || isBinary(newContent) {
+		if bytes.Equal(oldContent, newContent) {
+			return ""
+		}
+		return BinaryDiff(oldName, newName)
+	}
+	oldLines, newLines := splitLines(string(oldContent)), splitLines(string(newContent))
+	edits := diffLines(oldLines, newLines)
+
+	var sb strings.Builder
+	for _, h := range buildHunks(edits, context) {
+		if sb.Len() == 0 {
+			if oldName == "" {
+				sb.WriteString("--- /dev/null\n")
+			} else {
+				fmt.Fprintf(&sb, "--- a/%s\n", oldName)
+			}
+			fmt.Fprintf(&sb, "+++ b/%s\n", newName)
+		}
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(h.oldStart, h.oldCount), hunkRange(h.newStart, h.newCount))
+		for _, e := range h.edits {
+			sb.WriteByte(e.op)
+			sb.WriteString(e.text)
+			if !strings.HasSuffix(e.text, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+	}
+	return sb.String()
+}
+
+// BinaryDiff returns the line git prints in place of the diff of binary
+// files. An empty oldName marks the file as added.
+func BinaryDiff(oldName, newName string) string {
+	if oldName == "" {
+		return fmt.Sprintf("Binary files /dev/null and b/%s differ\n", newName)
+	}
+	return fmt.Sprintf("Binary files a/%s and b/%s differ\n", oldName, newName)
+}
+
+// isBinary reports whether content holds a NUL byte near its start
+func isBinary(content []byte) bool {
+	return bytes.IndexByte(content[:min(len(content), binarySniffLen)], 0) >= 0
+}
+
+// splitLines splits text into lines, keeping each line's newline
+func splitLines(text string) []string {
+	var lines []string
+	for text != "" {
+		i := strings.IndexByte(text, '\n')
+		if i < 0 {
+			lines = append(lines, text)
+			break
+		}
+		lines = append(lines, text[:i+1])
+		text = text[i+1:]
+	}
+	return lines
+}
+
+// diffLines computes a shortest edit script with Myers' algorithm, after
+// trimming the common prefix and suffix
+func diffLines(a, b []string) []edit {
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a[:prefix] {
+		edits = append(edits, edit{' ', line})
+	}
+	edits = append(edits, myers(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		edits = append(edits, edit{' ', line})
+	}
+	return edits
+}
+
+// myers returns the edit script transforming a into b
+func myers(a, b []string) []edit {
+	n, m := len(a), len(b)
+	if n == 0 || m == 0 {
+		return replaceAll(a, b)
+	}
+
+	max := n + m
+	offset := max + 1
+	v := make([]int, 2*max+3)
+	// trace[d] holds the furthest x for each diagonal k in [-d, d] after step d
+	var trace [][]int
+
+	for d := 0; d <= max; d++ {
+		if d > maxEditDistance {
+			return replaceAll(a, b)
+		}
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1] // Move down (insertion)
+			} else {
+				x = v[offset+k-1] + 1 // Move right (deletion)
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+
+		if x := v[offset+n-m]; n-m >= -d && n-m <= d && x >= n {
+			return backtrack(a, b, trace)
+		}
+	}
+	return replaceAll(a, b)
+}
+
+// backtrack walks the Myers trace from the end to recover the edit script
+func backtrack(a, b []string, trace [][]int) []edit {
+	var reversed []edit
+	x, y := len(a), len(b)
+
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		at := func(k int) int { return prev[k+d-1] }
+
+		k := x - y
+		var prevK int
+		if k == -d || (k != d && at(k-1) < at(k+1)) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := at(prevK)
+		prevY := prevX - prevK
+
+		for x > prevX && y > prevY {
+			reversed = append(reversed, edit{' ', a[x-1]})
+			x--
+			y--
+		}
+		if x == prevX {
+			reversed = append(reversed, edit{'+', b[y-1]})
+			y--
+		} else {
+			reversed = append(reversed, edit{'-', a[x-1]})
+			x--
+		}
+	}
+	for x > 0 && y > 0 {
+		reversed = append(reversed, edit{' ', a[x-1]})
+		x--
+		y--
+	}
+
+	edits := make([]edit, len(reversed))
+	for i, e := range reversed {
+		edits[len(reversed)-1-i] = e
+	}
+	return edits
+}
+
+// replaceAll deletes every line of a and inserts every line of b
+func replaceAll(a, b []string) []edit {
+	edits := make([]edit, 0, len(a)+len(b))
+	for _, line := range a {
+		edits = append(edits, edit{'-', line})
+	}
+	for _, line := range b {
+		edits = append(edits, edit{'+', line})
+	}
+	return edits
+}
+
+// hunk is a contiguous region of changes with surrounding context
+type hunk struct {
+	oldStart, oldCount int
+	newStart, newCount int
+	edits              []edit
+}
+
+// buildHunks groups changes that are at most 2*context lines apart
+func buildHunks(edits []edit, context int) []hunk {
+	var hunks []hunk
+	start, end := -1, -1
+
+	flush := func() {
+		from, to := start-context, end+context+1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(edits) {
+			to = len(edits)
+		}
+
+		h := hunk{edits: edits[from:to]}
+		oldLine, newLine := 0, 0
+		for _, e := range edits[:from] {
+			if e.op != '+' {
+				oldLine++
+			}
+			if e.op != '-' {
+				newLine++
+			}
+		}
+		for _, e := range h.edits {
+			if e.op != '+' {
+				h.oldCount++
+			}
+			if e.op != '-' {
+				h.newCount++
+			}
+		}
+		h.oldStart, h.newStart = oldLine+1, newLine+1
+		if h.oldCount == 0 {
+			h.oldStart = oldLine
+		}
+		if h.newCount == 0 {
+			h.newStart = newLine
+		}
+		hunks = append(hunks, h)
+	}
+
+	for i, e := range edits {
+		if e.op == ' ' {
+			continue
+		}
+		if start >= 0 && i-end-1 > 2*context {
+			flush()
+			start = -1
+		}
+		if start < 0 {
+			start = i
+		}
+		end = i
+	}
+	if start >= 0 {
+		flush()
+	}
+	return hunks
+}
+
+// hunkRange formats a hunk's "start,count" range as diff does
+func hunkRange(start, count int) string {
+	if count == 1 {
+		return fmt.Sprintf("%d", start)
+	}
+	return fmt.Sprintf("%d,%d", start, count)
+}
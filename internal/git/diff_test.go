@@ -0,0 +1,172 @@
+package git
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name     string
+		oldName  string
+		old, new string
+		context  int
+		want     string
+	}{
+		{
+			name: "identical", oldName: "a.txt",
+			old: "a\nb\n", new: "a\nb\n", context: 3,
+			want: "",
+		},
+		{
+			name: "binary base", oldName: "a.txt",
+			old: "a\x00b\n", new: "[binary file: 4 bytes]", context: 3,
+			want: "Binary files a/a.txt and b/a.txt differ\n",
+		},
+		{
+			name: "binary content", oldName: "a.txt",
+			old: "text\n", new: "a\x00b\n", context: 3,
+			want: "Binary files a/a.txt and b/a.txt differ\n",
+		},
+		{
+			name: "identical binary", oldName: "a.txt",
+			old: "a\x00b\n", new: "a\x00b\n", context: 3,
+			want: "",
+		},
+		{
+			name: "added binary", oldName: "",
+			old: "", new: "a\x00b\n", context: 3,
+			want: "Binary files /dev/null and b/a.txt differ\n",
+		},
+		{
+			name: "added file", oldName: "",
+			old: "", new: "one\ntwo\n", context: 3,
+			want: "--- /dev/null\n+++ b/a.txt\n" +
+				"@@ -0,0 +1,2 @@\n+one\n+two\n",
+		},
+		{
+			name: "emptied file", oldName: "a.txt",
+			old: "one\n", new: "", context: 3,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -1 +0,0 @@\n-one\n",
+		},
+		{
+			name: "change in the middle", oldName: "a.txt",
+			old: "1\n2\n3\n4\n5\n6\n7\n8\n9\n", new: "1\n2\n3\n4\nfive\n6\n7\n8\n9\n", context: 3,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -2,7 +2,7 @@\n 2\n 3\n 4\n-5\n+five\n 6\n 7\n 8\n",
+		},
+		{
+			name: "zero context", oldName: "a.txt",
+			old: "1\n2\n3\n", new: "1\nx\n3\n", context: 0,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -2 +2 @@\n-2\n+x\n",
+		},
+		{
+			name: "pure insertion", oldName: "a.txt",
+			old: "1\n2\n", new: "1\nnew\n2\n", context: 0,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -1,0 +2 @@\n+new\n",
+		},
+		{
+			name: "separate hunks", oldName: "a.txt",
+			old: "a\n1\n2\n3\n4\n5\n6\n7\nb\n", new: "A\n1\n2\n3\n4\n5\n6\n7\nB\n", context: 1,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -1,2 +1,2 @@\n-a\n+A\n 1\n" +
+				"@@ -8,2 +8,2 @@\n 7\n-b\n+B\n",
+		},
+		{
+			name: "merged hunks", oldName: "a.txt",
+			old: "a\n1\n2\nb\n", new: "A\n1\n2\nB\n", context: 1,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -1,4 +1,4 @@\n-a\n+A\n 1\n 2\n-b\n+B\n",
+		},
+		{
+			name: "no newline at end", oldName: "a.txt",
+			old: "a\nb", new: "a\nb\n", context: 3,
+			want: "--- a/a.txt\n+++ b/a.txt\n" +
+				"@@ -1,2 +1,2 @@\n a\n-b\n\\ No newline at end of file\n+b\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := UnifiedDiff(tt.oldName, "a.txt", []byte(tt.old), []byte(tt.new), tt.context)
+			if got != tt.want {
+				t.Errorf("UnifiedDiff =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffLinesMinimal checks on random inputs that the edit script turns
+// one side into the other and is as short as a longest common subsequence
+// allows
+func TestDiffLinesMinimal(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, rng.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a'+rng.Intn(4))) + "\n"
+		}
+		return lines
+	}
+
+	for i := 0; i < 500; i++ {
+		a, b := randomLines(), randomLines()
+		edits := diffLines(a, b)
+
+		var gotA, gotB []string
+		kept := 0
+		for _, e := range edits {
+			if e.op != '+' {
+				gotA = append(gotA, e.text)
+			}
+			if e.op != '-' {
+				gotB = append(gotB, e.text)
+			}
+			if e.op == ' ' {
+				kept++
+			}
+		}
+		if strings.Join(gotA, "") != strings.Join(a, "") || strings.Join(gotB, "") != strings.Join(b, "") {
+			t.Fatalf("edit script does not transform %q into %q", a, b)
+		}
+		if want := lcsLength(a, b); kept != want {
+			t.Fatalf("edit script keeps %d lines of %q and %q, want %d", kept, a, b, want)
+		}
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b
+func lcsLength(a, b []string) int {
+	row := make([]int, len(b)+1)
+	for i := range a {
+		prev := 0
+		for j := range b {
+			cur := row[j+1]
+			if a[i] == b[j] {
+				row[j+1] = prev + 1
+			} else if row[j] > row[j+1] {
+				row[j+1] = row[j]
+			}
+			prev = cur
+		}
+	}
+	return row[len(b)]
+}
+
+// TestMyersFallback checks that inputs beyond maxEditDistance are reported
+// as a whole replacement
+func TestMyersFallback(t *testing.T) {
+	a := make([]string, maxEditDistance+1)
+	b := make([]string, maxEditDistance+1)
+	for i := range a {
+		a[i] = "a\n"
+		b[i] = "b\n"
+	}
+	edits := myers(a, b)
+	if len(edits) != len(a)+len(b) || edits[0].op != '-' || edits[len(edits)-1].op != '+' {
+		t.Errorf("myers did not fall back to a replacement")
+	}
+}
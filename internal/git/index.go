@@ -0,0 +1,212 @@
+package git
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// File mode types stored in the index
+const (
+	ModeRegular    = 0o100644
+	ModeExecutable = 0o100755
+	ModeSymlink    = 0o120000
+	ModeGitlink    = 0o160000 // Submodule commit
+	ModeSparseDir  = 0o040000 // Directory entry in a sparse index
+	modeTypeMask   = 0o170000
+)
+
+// Index entry flag bits
+const (
+	flagExtended     = 0x4000
+	flagStageMask    = 0x3000
+	flagStageShift   = 12
+	flagNameMask     = 0x0fff
+	flagSkipWorktree = 0x4000 // In the extended flags
+)
+
+// IndexEntry is a single path recorded in the git index
+type IndexEntry struct {
+	Path         string    // Slash-separated path relative to the working tree
+	Mode         uint32    // File mode (see Mode* constants)
+	Size         uint32    // Size of the file when it was staged (truncated to 32 bits)
+	MTime        time.Time // Modification time of the file when it was staged
+	Hash         []byte    // Object ID of the staged blob
+	Stage        int       // Merge stage (0 unless there is a conflict)
+	SkipWorktree bool      // Entry is not checked out (sparse checkout)
+}
+
+// IsFile reports whether the entry is a regular file or symlink
+func (e IndexEntry) IsFile() bool {
+	switch e.Mode & modeTypeMask {
+	case ModeRegular & modeTypeMask, ModeSymlink & modeTypeMask:
+		return true
+	default:
+		return false
+	}
+}
+
+// Index is a parsed git index (.git/index)
+type Index struct {
+	Version int
+	Entries []IndexEntry
+	ModTime time.Time // Modification time of the index file itself
+}
+
+var errIndexTruncated = errors.New("git: index file is truncated")
+
+// ReadIndex parses the repository's index file. Versions 2, 3 and 4 are supported.
+func (r *Repository) ReadIndex() (*Index, error) {
+	path := filepath.Join(r.GitDir, "index")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			// A fresh repository has no index yet
+			return &Index{Version: 2}, nil
+		}
+		return nil, fmt.Errorf("git: failed to read index: %w", err)
+	}
+	index, err := parseIndex(data, r.HashSize)
+	if err != nil {
+		return nil, err
+	}
+	if info, err := os.Stat(path); err == nil {
+		index.ModTime = info.ModTime()
+	}
+	return index, nil
+}
+
+// parseIndex decodes the index header and entries; extensions are ignored
+func parseIndex(data []byte, hashSize int) (*Index, error) {
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, errors.New("git: invalid index signature")
+	}
+	version := int(binary.BigEndian.Uint32(data[4:8]))
+	if version < 2 || version > 4 {
+		return nil, fmt.Errorf("git: unsupported index version %d", version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	pos := 12
+	previous := ""
+	// Fixed-size part: ctime, mtime, dev, ino, mode, uid, gid, size (4 bytes each, times 2 for ctime/mtime)
+	const statSize = 40
+
+	// Every entry takes at least its fixed part and a NUL, so a count the
+	// data cannot hold is not trusted for the allocation
+	index := &Index{Version: version, Entries: make([]IndexEntry, 0, min(count, len(data)/(statSize+hashSize+3)))}
+
+	for i := 0; i < count; i++ {
+		start := pos
+		if pos+statSize+hashSize+2 > len(data) {
+			return nil, errIndexTruncated
+		}
+
+		entry := IndexEntry{
+			Mode: binary.BigEndian.Uint32(data[pos+24 : pos+28]),
+			Size: binary.BigEndian.Uint32(data[pos+36 : pos+40]),
+			MTime: time.Unix(int64(binary.BigEndian.Uint32(data[pos+8:pos+12])),
+				int64(binary.BigEndian.Uint32(data[pos+12:pos+16]))),
+		}
+		pos += statSize
+		entry.Hash = append([]byte(nil), data[pos:pos+hashSize]...)
+		pos += hashSize
+
+		flags := binary.BigEndian.Uint16(data[pos : pos+2])
+		pos += 2
+		entry.Stage = int(flags&flagStageMask) >> flagStageShift
+
+		if version >= 3 && flags&flagExtended != 0 {
+			if pos+2 > len(data) {
+				return nil, errIndexTruncated
+			}
+			extended := binary.BigEndian.Uint16(data[pos : pos+2])
+			entry.SkipWorktree = extended&flagSkipWorktree != 0
+			pos += 2
+		}
+
+		if version == 4 {
+			// Path is prefix-compressed against the previous entry
+			strip, n := readOffsetVarint(data[pos:])
+			if n == 0 || strip > len(previous) {
+				return nil, errIndexTruncated
+			}
+			pos += n
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 {
+				return nil, errIndexTruncated
+			}
+			entry.Path = previous[:len(previous)-strip] + string(data[pos:pos+end])
+			pos += end + 1
+		} else {
+			nameLen := int(flags & flagNameMask)
+			end := bytes.IndexByte(data[pos:], 0)
+			if end < 0 || (nameLen < flagNameMask && end != nameLen) {
+				return nil, errIndexTruncated
+			}
+			entry.Path = string(data[pos : pos+end])
+			// Entries are NUL-padded to a multiple of 8 bytes
+			pos = start + ((pos + end - start + 8) &^ 7)
+			if pos > len(data) {
+				return nil, errIndexTruncated
+			}
+		}
+
+		previous = entry.Path
+		index.Entries = append(index.Entries, entry)
+	}
+
+	return index, nil
+}
+
+// readOffsetVarint decodes git's offset varint encoding, returning the value
+// and the number of bytes consumed (0 on malformed input)
+func readOffsetVarint(data []byte) (int, int) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	c := data[0]
+	value := int(c & 0x7f)
+	n := 1
+	for c&0x80 != 0 {
+		if n >= len(data) {
+			return 0, 0
+		}
+		c = data[n]
+		n++
+		value = ((value + 1) << 7) | int(c&0x7f)
+	}
+	return value, n
+}
+
+// TrackedFiles returns the tracked files below dir, relative to dir and
+// slash-separated. Submodules, sparse directory entries and files not
+// checked out are excluded; conflicted paths are listed once.
+func (r *Repository) TrackedFiles(dir string) ([]string, error) {
+	index, err := r.ReadIndex()
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	seen := make(map[string]struct{}, len(index.Entries))
+	for _, entry := range index.Entries {
+		if !entry.IsFile() || entry.SkipWorktree {
+			continue
+		}
+		rel, ok := r.RelativePath(dir, entry.Path)
+		if !ok {
+			continue
+		}
+		if _, dup := seen[rel]; dup {
+			continue
+		}
+		seen[rel] = struct{}{}
+		files = append(files, rel)
+	}
+	return files, nil
+}
@@ -0,0 +1,133 @@
+package git
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// checkIndex compares the parsed index with git ls-files --stage
+func checkIndex(t *testing.T, f *fixture, index *Index) {
+	t.Helper()
+	var lines []string
+	for _, e := range index.Entries {
+		lines = append(lines, fmt.Sprintf("%06o %s %d\t%s", e.Mode, hex.EncodeToString(e.Hash), e.Stage, e.Path))
+	}
+	got, want := strings.Join(lines, "\n"), f.git("ls-files", "--stage")
+	if got != want {
+		t.Errorf("index entries differ from git ls-files --stage:\ngot\n%s\nwant\n%s", got, want)
+	}
+}
+
+// indexFixture creates a repository with nested paths sharing prefixes, an
+// executable and a symlink
+func indexFixture(t *testing.T) *fixture {
+	f := newFixture(t)
+	f.write("README.md", "readme\n")
+	f.write("cmd/tool/main.go", "package main\n")
+	f.write("cmd/tool/main_test.go", "package main\n")
+	f.write("internal/a/a.go", "package a\n")
+	f.write("internal/a/b/b.go", "package b\n")
+	f.write("internal/ab/ab.go", "package ab\n")
+	f.write("run.sh", "#!/bin/sh\n")
+	f.git("update-index", "--add", "--chmod=+x", "run.sh")
+	f.git("add", "-A")
+	f.git("update-index", "--add", "--cacheinfo", "120000,"+f.git("hash-object", "-w", "README.md")+",link")
+	f.git("commit", "-q", "-m", "initial")
+	return f
+}
+
+func TestReadIndex(t *testing.T) {
+	f := indexFixture(t)
+	index, err := f.repo().ReadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Version != 2 {
+		t.Errorf("Version = %d, want 2", index.Version)
+	}
+	checkIndex(t, f, index)
+}
+
+// TestReadIndexExtendedFlags checks a version 3 index, whose entries may
+// carry a second flags word
+func TestReadIndexExtendedFlags(t *testing.T) {
+	f := indexFixture(t)
+	f.git("update-index", "--skip-worktree", "internal/a/a.go")
+	f.write("new.go", "package main\n")
+	f.git("add", "--intent-to-add", "new.go")
+
+	repo := f.repo()
+	index, err := repo.ReadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Version != 3 {
+		t.Fatalf("Version = %d, want 3", index.Version)
+	}
+	checkIndex(t, f, index)
+	for _, e := range index.Entries {
+		if want := e.Path == "internal/a/a.go"; e.SkipWorktree != want {
+			t.Errorf("%s: SkipWorktree = %v, want %v", e.Path, e.SkipWorktree, want)
+		}
+	}
+
+	files, err := repo.TrackedFiles(f.root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(files, ",")
+	if strings.Contains(joined, "internal/a/a.go") {
+		t.Errorf("TrackedFiles includes a skip-worktree entry: %v", files)
+	}
+	if !strings.Contains(joined, "internal/a/b/b.go") || !strings.Contains(joined, "new.go") {
+		t.Errorf("TrackedFiles = %v, missing entries", files)
+	}
+}
+
+// TestReadIndexV4 checks a version 4 index, whose paths are compressed
+// against the previous entry
+func TestReadIndexV4(t *testing.T) {
+	f := indexFixture(t)
+	f.git("update-index", "--index-version", "4")
+	f.git("update-index", "--skip-worktree", "internal/ab/ab.go")
+
+	index, err := f.repo().ReadIndex()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index.Version != 4 {
+		t.Fatalf("Version = %d, want 4", index.Version)
+	}
+	checkIndex(t, f, index)
+	for _, e := range index.Entries {
+		if want := e.Path == "internal/ab/ab.go"; e.SkipWorktree != want {
+			t.Errorf("%s: SkipWorktree = %v, want %v", e.Path, e.SkipWorktree, want)
+		}
+	}
+}
+
+func TestTrackedFilesBelowDir(t *testing.T) {
+	f := indexFixture(t)
+	files, err := f.repo().TrackedFiles(f.root + "/internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := strings.Join(files, ","), "a/a.go,a/b/b.go,ab/ab.go"; got != want {
+		t.Errorf("TrackedFiles = %s, want %s", got, want)
+	}
+}
+
+func TestParseIndexRejectsCorruptData(t *testing.T) {
+	header := []byte("DIRC\x00\x00\x00\x02\xff\xff\xff\xff") // Claims 4 billion entries
+	if _, err := parseIndex(header, 20); err == nil {
+		t.Errorf("parseIndex accepted a count the data cannot hold")
+	}
+	if _, err := parseIndex([]byte("DIRX\x00\x00\x00\x02\x00\x00\x00\x00"), 20); err == nil {
+		t.Errorf("parseIndex accepted a bad signature")
+	}
+	if _, err := parseIndex([]byte("DIRC\x00\x00\x00\x05\x00\x00\x00\x00"), 20); err == nil {
+		t.Errorf("parseIndex accepted version 5")
+	}
+}
@@ -0,0 +1,180 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+// ObjectID is a hex-encoded git object name
+type ObjectID string
+
+// Object types
+const (
+	ObjectCommit = "commit"
+	ObjectTree   = "tree"
+	ObjectBlob   = "blob"
+	ObjectTag    = "tag"
+)
+
+// ErrObjectNotFound is returned when an object is in neither loose nor packed storage
+var ErrObjectNotFound = errors.New("git: object not found")
+
+// objectStore reads objects from a repository's object database
+type objectStore struct {
+	dir      string // objects directory
+	hashSize int
+
+	once    sync.Once
+	packs   []*packFile
+	packErr error
+}
+
+// objects returns the repository's object store, creating it on first use
+func (r *Repository) objects() *objectStore {
+	r.storeOnce.Do(func() {
+		r.store = &objectStore{dir: filepath.Join(r.CommonDir, "objects"), hashSize: r.HashSize}
+	})
+	return r.store
+}
+
+// ReadObject returns the type and content of an object
+func (r *Repository) ReadObject(id ObjectID) (string, []byte, error) {
+	return r.objects().read(id)
+}
+
+// HashBlob computes the object ID git would assign to content stored as a blob
+func (r *Repository) HashBlob(content []byte) ObjectID {
+	var h hash.Hash
+	if r.HashSize == 32 {
+		h = sha256.New()
+	} else {
+		h = sha1.New()
+	}
+	fmt.Fprintf(h, "%s %d\x00", ObjectBlob, len(content))
+	h.Write(content)
+	return ObjectID(hex.EncodeToString(h.Sum(nil)))
+}
+
+// read looks an object up in loose storage first, then in the packs
+func (s *objectStore) read(id ObjectID) (string, []byte, error) {
+	if len(id) != s.hashSize*2 {
+		return "", nil, fmt.Errorf("git: invalid object id %q", id)
+	}
+
+	objType, data, err := s.readLoose(id)
+	if err == nil || !errors.Is(err, ErrObjectNotFound) {
+		return objType, data, err
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return "", nil, err
+	}
+	raw, err := hex.DecodeString(string(id))
+	if err != nil {
+		return "", nil, fmt.Errorf("git: invalid object id %q", id)
+	}
+	for _, pack := range packs {
+		if offset, ok := pack.find(raw); ok {
+			return pack.readAt(offset, s)
+		}
+	}
+	return "", nil, fmt.Errorf("%w: %s", ErrObjectNotFound, id)
+}
+
+// readLoose reads a zlib-compressed loose object file
+func (s *objectStore) readLoose(id ObjectID) (string, []byte, error) {
+	file, err := os.Open(filepath.Join(s.dir, string(id[:2]), string(id[2:])))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil, ErrObjectNotFound
+		}
+		return "", nil, fmt.Errorf("git: failed to open object %s: %w", id, err)
+	}
+	defer file.Close()
+
+	zr, err := zlib.NewReader(file)
+	if err != nil {
+		return "", nil, fmt.Errorf("git: corrupt object %s: %w", id, err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return "", nil, fmt.Errorf("git: corrupt object %s: %w", id, err)
+	}
+
+	// Header is "<type> <size>\0"
+	nul := bytes.IndexByte(data, 0)
+	space := bytes.IndexByte(data, ' ')
+	if nul < 0 || space < 0 || space > nul {
+		return "", nil, fmt.Errorf("git: corrupt object header in %s", id)
+	}
+	size, err := strconv.Atoi(string(data[space+1 : nul]))
+	if err != nil || size != len(data)-nul-1 {
+		return "", nil, fmt.Errorf("git: corrupt object size in %s", id)
+	}
+	return string(data[:space]), data[nul+1:], nil
+}
+
+// loadPacks opens every pack index in the objects directory once
+func (s *objectStore) loadPacks() ([]*packFile, error) {
+	s.once.Do(func() {
+		matches, err := filepath.Glob(filepath.Join(s.dir, "pack", "pack-*.idx"))
+		if err != nil {
+			s.packErr = err
+			return
+		}
+		for _, idxPath := range matches {
+			pack, err := openPack(idxPath, s.hashSize)
+			if err != nil {
+				s.packErr = err
+				return
+			}
+			s.packs = append(s.packs, pack)
+		}
+	})
+	return s.packs, s.packErr
+}
+
+// findPrefix returns every object ID starting with the given hex prefix
+func (s *objectStore) findPrefix(prefix string) ([]ObjectID, error) {
+	var found []ObjectID
+	seen := make(map[ObjectID]bool)
+
+	if len(prefix) >= 2 {
+		entries, _ := os.ReadDir(filepath.Join(s.dir, prefix[:2]))
+		for _, entry := range entries {
+			id := ObjectID(prefix[:2] + entry.Name())
+			if len(id) == s.hashSize*2 && bytes.HasPrefix([]byte(id), []byte(prefix)) && !seen[id] {
+				seen[id] = true
+				found = append(found, id)
+			}
+		}
+	}
+
+	packs, err := s.loadPacks()
+	if err != nil {
+		return nil, err
+	}
+	for _, pack := range packs {
+		for _, id := range pack.findPrefix(prefix) {
+			if !seen[id] {
+				seen[id] = true
+				found = append(found, id)
+			}
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,153 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// objectFixture creates a repository with a history long enough for git gc
+// to store some objects as deltas
+func objectFixture(t *testing.T, args ...string) *fixture {
+	f := newFixture(t, args...)
+	var content strings.Builder
+	for i := 0; i < 200; i++ {
+		fmt.Fprintf(&content, "line %d of a file that changes a little in every commit\n", i)
+	}
+	for rev := 0; rev < 8; rev++ {
+		f.write("big.txt", content.String()+fmt.Sprintf("revision %d\n", rev))
+		f.write(fmt.Sprintf("dir/file%d.txt", rev), fmt.Sprintf("file %d\n", rev))
+		f.commit(fmt.Sprintf("revision %d", rev))
+	}
+	f.git("tag", "-a", "-m", "release", "v1", "HEAD~2")
+	return f
+}
+
+// checkObjects reads every object in the repository and compares it with
+// git cat-file
+func checkObjects(t *testing.T, f *fixture) {
+	t.Helper()
+	repo := f.repo()
+	reader := bufio.NewReader(bytes.NewReader(f.output("cat-file", "--batch", "--batch-all-objects")))
+	count := 0
+	for {
+		header, err := reader.ReadString('\n')
+		if err == io.EOF {
+			break
+		}
+		fields := strings.Fields(header)
+		if len(fields) != 3 {
+			t.Fatalf("unexpected cat-file header %q", header)
+		}
+		size, _ := strconv.Atoi(fields[2])
+		want := make([]byte, size+1) // Content is followed by a newline
+		if _, err := io.ReadFull(reader, want); err != nil {
+			t.Fatal(err)
+		}
+		want = want[:size]
+
+		objType, data, err := repo.ReadObject(ObjectID(fields[0]))
+		if err != nil {
+			t.Errorf("ReadObject(%s): %v", fields[0], err)
+			continue
+		}
+		if objType != fields[1] || !bytes.Equal(data, want) {
+			t.Errorf("ReadObject(%s) = %s of %d bytes, want %s of %d bytes", fields[0], objType, len(data), fields[1], size)
+		}
+		count++
+	}
+	if count == 0 {
+		t.Fatal("fixture has no objects")
+	}
+}
+
+func TestReadLooseObjects(t *testing.T) {
+	for _, format := range []string{"sha1", "sha256"} {
+		t.Run(format, func(t *testing.T) {
+			f := objectFixture(t, "--object-format="+format)
+			if packs, _ := filepath.Glob(filepath.Join(f.root, ".git", "objects", "pack", "*.pack")); len(packs) != 0 {
+				t.Fatalf("fixture has packs before gc: %v", packs)
+			}
+			checkObjects(t, f)
+		})
+	}
+}
+
+func TestReadPackedObjects(t *testing.T) {
+	tests := []struct {
+		name         string
+		init         []string
+		indexVersion string
+	}{
+		{"sha1 idx v2", []string{"--object-format=sha1"}, "2"},
+		{"sha1 idx v1", []string{"--object-format=sha1"}, "1"},
+		{"sha256 idx v2", []string{"--object-format=sha256"}, "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := objectFixture(t, tt.init...)
+			f.git("-c", "pack.indexVersion="+tt.indexVersion, "gc", "-q", "--aggressive")
+			if loose, _ := filepath.Glob(filepath.Join(f.root, ".git", "objects", "??", "*")); len(loose) != 0 {
+				t.Fatalf("loose objects left after gc: %v", loose)
+			}
+
+			// Make sure the pack exercises delta decoding
+			idx, _ := filepath.Glob(filepath.Join(f.root, ".git", "objects", "pack", "*.idx"))
+			if len(idx) != 1 {
+				t.Fatalf("want one pack index, found %v", idx)
+			}
+			if !strings.Contains(f.git("verify-pack", "-v", idx[0]), "chain length") {
+				t.Fatal("pack has no deltas")
+			}
+			checkIdxVersion(t, idx[0], tt.indexVersion)
+
+			checkObjects(t, f)
+		})
+	}
+}
+
+// checkIdxVersion checks that git wrote the pack index version under test
+func checkIdxVersion(t *testing.T, path, version string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v2 := bytes.HasPrefix(data, []byte("\377tOc\x00\x00\x00\x02"))
+	if v2 != (version == "2") {
+		t.Fatalf("pack index is not version %s", version)
+	}
+}
+
+func TestReadObjectNotFound(t *testing.T) {
+	f := objectFixture(t)
+	repo := f.repo()
+	if _, _, err := repo.ReadObject(ObjectID(strings.Repeat("0", 40))); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("loose: ReadObject = %v, want ErrObjectNotFound", err)
+	}
+	f.git("gc", "-q")
+	if _, _, err := f.repo().ReadObject(ObjectID(strings.Repeat("0", 40))); !errors.Is(err, ErrObjectNotFound) {
+		t.Errorf("packed: ReadObject = %v, want ErrObjectNotFound", err)
+	}
+	if _, _, err := repo.ReadObject("abc"); err == nil {
+		t.Errorf("ReadObject accepted a short id")
+	}
+}
+
+func TestHashBlob(t *testing.T) {
+	for _, format := range []string{"sha1", "sha256"} {
+		f := newFixture(t, "--object-format="+format)
+		f.write("a.txt", "hello\r\nworld")
+		want := f.git("hash-object", "--no-filters", "a.txt")
+		if got := f.repo().HashBlob([]byte("hello\r\nworld")); string(got) != want {
+			t.Errorf("%s: HashBlob = %s, want %s", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,322 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Packed object types
+const (
+	packCommit   = 1
+	packTree     = 2
+	packBlob     = 3
+	packTag      = 4
+	packOfsDelta = 6
+	packRefDelta = 7
+)
+
+// maxDeltaChain bounds delta resolution to guard against corrupt packs
+const maxDeltaChain = 10000
+
+// packFile is a pack with its parsed index
+type packFile struct {
+	path     string   // .pack file path
+	hashSize int      // Object ID length in bytes
+	ids      [][]byte // Sorted object IDs
+	offsets  []int64  // Pack offset of each object, parallel to ids
+}
+
+// openPack parses a pack index (version 1 or 2)
+func openPack(idxPath string, hashSize int) (*packFile, error) {
+	data, err := os.ReadFile(idxPath)
+	if err != nil {
+		return nil, fmt.Errorf("git: failed to read pack index: %w", err)
+	}
+	pack := &packFile{path: strings.TrimSuffix(idxPath, ".idx") + ".pack", hashSize: hashSize}
+	corrupt := fmt.Errorf("git: corrupt pack index %s", idxPath)
+
+	if len(data) >= 8 && bytes.Equal(data[:4], []byte{0xff, 't', 'O', 'c'}) {
+		if binary.BigEndian.Uint32(data[4:8]) != 2 {
+			return nil, fmt.Errorf("git: unsupported pack index version in %s", idxPath)
+		}
+		if len(data) < 8+256*4 {
+			return nil, corrupt
+		}
+		count := int(binary.BigEndian.Uint32(data[8+255*4 : 8+256*4]))
+		idsStart := 8 + 256*4
+		crcStart := idsStart + count*hashSize
+		offStart := crcStart + count*4
+		largeStart := offStart + count*4
+		if len(data) < largeStart {
+			return nil, corrupt
+		}
+
+		pack.ids = make([][]byte, count)
+		pack.offsets = make([]int64, count)
+		for i := 0; i < count; i++ {
+			pack.ids[i] = data[idsStart+i*hashSize : idsStart+(i+1)*hashSize]
+			offset := binary.BigEndian.Uint32(data[offStart+i*4:])
+			if offset&0x80000000 != 0 {
+				// Offsets past 2GB are stored in the 8-byte table
+				large := largeStart + int(offset&0x7fffffff)*8
+				if len(data) < large+8 {
+					return nil, corrupt
+				}
+				pack.offsets[i] = int64(binary.BigEndian.Uint64(data[large:]))
+			} else {
+				pack.offsets[i] = int64(offset)
+			}
+		}
+		return pack, nil
+	}
+
+	// Version 1: fanout table followed by (offset, id) pairs
+	if len(data) < 256*4 {
+		return nil, corrupt
+	}
+	count := int(binary.BigEndian.Uint32(data[255*4 : 256*4]))
+	entrySize := 4 + hashSize
+	if len(data) < 256*4+count*entrySize {
+		return nil, corrupt
+	}
+	pack.ids = make([][]byte, count)
+	pack.offsets = make([]int64, count)
+	for i := 0; i < count; i++ {
+		entry := data[256*4+i*entrySize:]
+		pack.offsets[i] = int64(binary.BigEndian.Uint32(entry[:4]))
+		pack.ids[i] = entry[4 : 4+hashSize]
+	}
+	return pack, nil
+}
+
+// find returns the pack offset of an object
+func (p *packFile) find(id []byte) (int64, bool) {
+	i := sort.Search(len(p.ids), func(i int) bool {
+		return bytes.Compare(p.ids[i], id) >= 0
+	})
+	if i < len(p.ids) && bytes.Equal(p.ids[i], id) {
+		return p.offsets[i], true
+	}
+	return 0, false
+}
+
+// findPrefix returns the IDs in this pack starting with a hex prefix
+func (p *packFile) findPrefix(prefix string) []ObjectID {
+	var found []ObjectID
+	for _, id := range p.ids {
+		if hexID := hex.EncodeToString(id); strings.HasPrefix(hexID, prefix) {
+			found = append(found, ObjectID(hexID))
+		}
+	}
+	return found
+}
+
+// readAt reads and fully resolves the object at offset
+func (p *packFile) readAt(offset int64, store *objectStore) (string, []byte, error) {
+	file, err := os.Open(p.path)
+	if err != nil {
+		return "", nil, fmt.Errorf("git: failed to open pack: %w", err)
+	}
+	defer file.Close()
+
+	// Collect the delta chain down to a base object, then apply it in reverse
+	var deltas [][]byte
+	for depth := 0; depth < maxDeltaChain; depth++ {
+		objType, data, baseOffset, baseID, err := p.readEntry(file, offset)
+		if err != nil {
+			return "", nil, err
+		}
+
+		switch objType {
+		case packOfsDelta:
+			deltas = append(deltas, data)
+			offset = baseOffset
+			continue
+		case packRefDelta:
+			deltas = append(deltas, data)
+			if next, ok := p.find(baseID); ok {
+				offset = next
+				continue
+			}
+			// Thin packs may reference bases stored elsewhere
+			baseType, base, err := store.read(ObjectID(hex.EncodeToString(baseID)))
+			if err != nil {
+				return "", nil, err
+			}
+			return applyDeltas(baseType, base, deltas)
+		}
+
+		typeName, err := packTypeName(objType)
+		if err != nil {
+			return "", nil, err
+		}
+		return applyDeltas(typeName, data, deltas)
+	}
+	return "", nil, errors.New("git: delta chain too long")
+}
+
+// readEntry decodes one pack entry header and inflates its data
+func (p *packFile) readEntry(file *os.File, offset int64) (objType int, data []byte, baseOffset int64, baseID []byte, err error) {
+	reader := bufio.NewReader(io.NewSectionReader(file, offset, 1<<62))
+
+	c, err := reader.ReadByte()
+	if err != nil {
+		return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack entry: %w", err)
+	}
+	objType = int(c>>4) & 7
+	size := int64(c & 0x0f)
+	for shift := uint(4); c&0x80 != 0; shift += 7 {
+		if c, err = reader.ReadByte(); err != nil {
+			return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack entry: %w", err)
+		}
+		size |= int64(c&0x7f) << shift
+	}
+
+	switch objType {
+	case packOfsDelta:
+		c, err = reader.ReadByte()
+		if err != nil {
+			return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack entry: %w", err)
+		}
+		distance := int64(c & 0x7f)
+		for c&0x80 != 0 {
+			if c, err = reader.ReadByte(); err != nil {
+				return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack entry: %w", err)
+			}
+			distance = ((distance + 1) << 7) | int64(c&0x7f)
+		}
+		baseOffset = offset - distance
+	case packRefDelta:
+		baseID = make([]byte, p.hashSize)
+		if _, err = io.ReadFull(reader, baseID); err != nil {
+			return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack entry: %w", err)
+		}
+	}
+
+	zr, err := zlib.NewReader(reader)
+	if err != nil {
+		return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack data: %w", err)
+	}
+	defer zr.Close()
+
+	// The size comes from the pack, so it is checked against the data
+	// inflated rather than trusted for an allocation up front
+	data, err = io.ReadAll(io.LimitReader(zr, size+1))
+	if err != nil {
+		return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack data: %w", err)
+	}
+	if int64(len(data)) != size {
+		return 0, nil, 0, nil, fmt.Errorf("git: corrupt pack data: object is %d bytes, header says %d", len(data), size)
+	}
+	return objType, data, baseOffset, baseID, nil
+}
+
+// packTypeName maps a packed object type to its name
+func packTypeName(objType int) (string, error) {
+	switch objType {
+	case packCommit:
+		return ObjectCommit, nil
+	case packTree:
+		return ObjectTree, nil
+	case packBlob:
+		return ObjectBlob, nil
+	case packTag:
+		return ObjectTag, nil
+	default:
+		return "", fmt.Errorf("git: unknown packed object type %d", objType)
+	}
+}
+
+// applyDeltas applies deltas (collected from the target down) to base
+func applyDeltas(objType string, base []byte, deltas [][]byte) (string, []byte, error) {
+	var err error
+	for i := len(deltas) - 1; i >= 0; i-- {
+		if base, err = applyDelta(base, deltas[i]); err != nil {
+			return "", nil, err
+		}
+	}
+	return objType, base, nil
+}
+
+// applyDelta reconstructs an object from its base and a delta
+func applyDelta(base, delta []byte) ([]byte, error) {
+	corrupt := errors.New("git: corrupt delta")
+
+	srcSize, n := readSizeVarint(delta)
+	if n == 0 || srcSize != len(base) {
+		return nil, corrupt
+	}
+	delta = delta[n:]
+	dstSize, n := readSizeVarint(delta)
+	if n == 0 {
+		return nil, corrupt
+	}
+	delta = delta[n:]
+
+	// dstSize is only a hint until the output is checked against it
+	out := make([]byte, 0, min(dstSize, len(base)+len(delta)))
+	for len(delta) > 0 {
+		op := delta[0]
+		delta = delta[1:]
+
+		if op&0x80 == 0 {
+			// Insert the next op bytes literally
+			if op == 0 || int(op) > len(delta) {
+				return nil, corrupt
+			}
+			out = append(out, delta[:op]...)
+			delta = delta[op:]
+			continue
+		}
+
+		// Copy from base; bits 0-3 select offset bytes, bits 4-6 size bytes
+		var offset, size int
+		for i := uint(0); i < 7; i++ {
+			if op&(1<<i) == 0 {
+				continue
+			}
+			if len(delta) == 0 {
+				return nil, corrupt
+			}
+			if i < 4 {
+				offset |= int(delta[0]) << (8 * i)
+			} else {
+				size |= int(delta[0]) << (8 * (i - 4))
+			}
+			delta = delta[1:]
+		}
+		if size == 0 {
+			size = 0x10000
+		}
+		if offset+size > len(base) {
+			return nil, corrupt
+		}
+		out = append(out, base[offset:offset+size]...)
+	}
+
+	if len(out) != dstSize {
+		return nil, corrupt
+	}
+	return out, nil
+}
+
+// readSizeVarint decodes the little-endian base-128 sizes used in deltas
+func readSizeVarint(data []byte) (int, int) {
+	var value int
+	for i, shift := 0, uint(0); i < len(data); i, shift = i+1, shift+7 {
+		value |= int(data[i]&0x7f) << shift
+		if data[i]&0x80 == 0 {
+			return value, i + 1
+		}
+	}
+	return 0, 0
+}
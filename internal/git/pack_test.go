@@ -0,0 +1,122 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyDelta(t *testing.T) {
+	base := []byte("0123456789abcdef")
+	tests := []struct {
+		name  string
+		delta []byte
+		want  string
+		err   bool
+	}{
+		{"insert", []byte{16, 3, 3, 'x', 'y', 'z'}, "xyz", false},
+		{"copy", []byte{16, 4, 0x91, 2, 4}, "2345", false},                            // Offset 2, size 4
+		{"copy and insert", []byte{16, 6, 0x91, 10, 4, 2, '!', '?'}, "abcd!?", false}, // Offset 10, size 4
+		{"copy whole", []byte{16, 16, 0x90, 16}, "0123456789abcdef", false},           // Offset 0 omitted
+		{"wrong source size", []byte{15, 3, 3, 'x', 'y', 'z'}, "", true},
+		{"short output", []byte{16, 4, 3, 'x', 'y', 'z'}, "", true},
+		{"long output", []byte{16, 2, 3, 'x', 'y', 'z'}, "", true},
+		{"copy past base", []byte{16, 4, 0x91, 14, 4}, "", true},
+		{"truncated insert", []byte{16, 3, 3, 'x'}, "", true},
+		{"truncated copy", []byte{16, 4, 0x91, 2}, "", true},
+		{"zero op", []byte{16, 0, 0}, "", true},
+		{"huge claimed size", []byte{16, 0xff, 0xff, 0xff, 0xff, 0x0f, 3, 'x', 'y', 'z'}, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := applyDelta(base, tt.delta)
+			if tt.err {
+				if err == nil {
+					t.Errorf("applyDelta = %q, want an error", got)
+				}
+				return
+			}
+			if err != nil || string(got) != tt.want {
+				t.Errorf("applyDelta = %q, %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+// TestReadEntryChecksSize checks that the object size in an entry header is
+// verified against the inflated data rather than trusted
+func TestReadEntryChecksSize(t *testing.T) {
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write([]byte("hello"))
+	zw.Close()
+
+	tests := []struct {
+		name   string
+		header []byte
+		err    string
+	}{
+		{"exact", []byte{packBlob<<4 | 5}, ""},
+		{"too small", []byte{packBlob<<4 | 3}, "header says 3"},
+		{"too large", []byte{packBlob<<4 | 9}, "header says 9"},
+		// 4 GiB, which must not be allocated up front
+		{"huge", []byte{0x80 | packBlob<<4, 0x80, 0x80, 0x80, 0x80, 0x01}, "header says 4294967296"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "test.pack")
+			if err := os.WriteFile(path, append(append([]byte{}, tt.header...), compressed.Bytes()...), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			file, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer file.Close()
+
+			p := &packFile{path: path, hashSize: 20}
+			objType, data, _, _, err := p.readEntry(file, 0)
+			if tt.err == "" {
+				if err != nil || objType != packBlob || string(data) != "hello" {
+					t.Errorf("readEntry = %d, %q, %v, want blob \"hello\"", objType, data, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("readEntry error = %v, want one containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestOpenPackRejectsTruncatedIndex(t *testing.T) {
+	f := objectFixture(t)
+	f.git("gc", "-q")
+	idx, _ := filepath.Glob(filepath.Join(f.root, ".git", "objects", "pack", "*.idx"))
+	if len(idx) != 1 {
+		t.Fatalf("want one pack index, found %v", idx)
+	}
+	data, err := os.ReadFile(idx[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	pack, err := openPack(idx[0], 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pack.ids) == 0 || len(pack.ids) != len(pack.offsets) {
+		t.Fatalf("openPack read %d ids and %d offsets", len(pack.ids), len(pack.offsets))
+	}
+
+	truncated := filepath.Join(t.TempDir(), "truncated.idx")
+	if err := os.WriteFile(truncated, data[:8+256*4+10], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := openPack(truncated, 20); err == nil {
+		t.Errorf("openPack accepted a truncated index")
+	}
+}
@@ -0,0 +1,219 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// maxSymrefDepth bounds symbolic ref resolution
+const maxSymrefDepth = 10
+
+// ResolveRevision resolves a revision to a commit ID. Supported forms are
+// full or abbreviated object IDs, HEAD and other ref names (branches, tags,
+// remotes, full "refs/..." names), each optionally followed by "~N", "^" or
+// "^N" suffixes. Annotated tags are peeled to the commit they point to.
+func (r *Repository) ResolveRevision(rev string) (ObjectID, error) {
+	base, suffix := splitRevisionSuffix(rev)
+
+	id, err := r.resolveName(base)
+	if err != nil {
+		return "", err
+	}
+	if id, err = r.peelToCommit(id); err != nil {
+		return "", err
+	}
+
+	for suffix != "" {
+		var steps, parent int
+		switch suffix[0] {
+		case '~':
+			n, rest := leadingNumber(suffix[1:], 1)
+			steps, parent, suffix = n, 1, rest
+		case '^':
+			n, rest := leadingNumber(suffix[1:], 1)
+			steps, parent, suffix = 1, n, rest
+		default:
+			return "", fmt.Errorf("git: unsupported revision syntax %q", rev)
+		}
+
+		for i := 0; i < steps; i++ {
+			commit, err := r.ReadCommit(id)
+			if err != nil {
+				return "", err
+			}
+			if parent < 1 || parent > len(commit.Parents) {
+				return "", fmt.Errorf("git: revision %q has no such parent", rev)
+			}
+			id = commit.Parents[parent-1]
+		}
+	}
+	return id, nil
+}
+
+// splitRevisionSuffix separates a ref name from trailing ~ and ^ navigation
+func splitRevisionSuffix(rev string) (string, string) {
+	if i := strings.IndexAny(rev, "~^"); i > 0 {
+		return rev[:i], rev[i:]
+	}
+	return rev, ""
+}
+
+// leadingNumber parses an optional decimal number at the start of s
+func leadingNumber(s string, def int) (int, string) {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	if end == 0 {
+		return def, s
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n, s[end:]
+}
+
+// resolveName resolves a ref name or (abbreviated) object ID
+func (r *Repository) resolveName(name string) (ObjectID, error) {
+	if name == "" {
+		return "", fmt.Errorf("git: empty revision")
+	}
+	if name == "@" {
+		name = "HEAD"
+	}
+
+	candidates := []string{name}
+	if name != "HEAD" && !strings.HasPrefix(name, "refs/") {
+		candidates = append(candidates,
+			"refs/"+name,
+			"refs/tags/"+name,
+			"refs/heads/"+name,
+			"refs/remotes/"+name,
+			"refs/remotes/"+name+"/HEAD",
+		)
+	}
+	for _, ref := range candidates {
+		if id, ok, err := r.readRef(ref, 0); err != nil {
+			return "", err
+		} else if ok {
+			return id, nil
+		}
+	}
+
+	if isHex(name) && len(name) >= 4 && len(name) <= r.HashSize*2 {
+		matches, err := r.objects().findPrefix(strings.ToLower(name))
+		if err != nil {
+			return "", err
+		}
+		switch len(matches) {
+		case 1:
+			return matches[0], nil
+		case 0:
+		default:
+			return "", fmt.Errorf("git: short object ID %s is ambiguous", name)
+		}
+	}
+
+	return "", fmt.Errorf("git: unknown revision %q", name)
+}
+
+// readRef resolves a ref from loose ref files or packed-refs
+func (r *Repository) readRef(name string, depth int) (ObjectID, bool, error) {
+	if depth > maxSymrefDepth {
+		return "", false, fmt.Errorf("git: symbolic ref loop at %s", name)
+	}
+
+	// HEAD and other pseudo-refs are per-worktree; everything else is shared
+	dir := r.CommonDir
+	if !strings.HasPrefix(name, "refs/") {
+		dir = r.GitDir
+	}
+	data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(name)))
+	if err == nil {
+		content := strings.TrimSpace(string(data))
+		if target, ok := strings.CutPrefix(content, "ref:"); ok {
+			return r.readRef(strings.TrimSpace(target), depth+1)
+		}
+		if len(content) == r.HashSize*2 && isHex(content) {
+			return ObjectID(strings.ToLower(content)), true, nil
+		}
+		return "", false, nil
+	}
+
+	return r.packedRef(name)
+}
+
+// packedRef looks a ref up in the packed-refs file
+func (r *Repository) packedRef(name string) (ObjectID, bool, error) {
+	file, err := os.Open(filepath.Join(r.CommonDir, "packed-refs"))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, fmt.Errorf("git: failed to read packed-refs: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" || line[0] == '#' || line[0] == '^' {
+			continue
+		}
+		id, ref, found := strings.Cut(line, " ")
+		if found && ref == name {
+			return ObjectID(id), true, nil
+		}
+	}
+	return "", false, scanner.Err()
+}
+
+// peelToCommit follows annotated tags until a commit is reached
+func (r *Repository) peelToCommit(id ObjectID) (ObjectID, error) {
+	for depth := 0; depth <= maxSymrefDepth; depth++ {
+		objType, data, err := r.ReadObject(id)
+		if err != nil {
+			return "", err
+		}
+		switch objType {
+		case ObjectCommit:
+			return id, nil
+		case ObjectTag:
+			target, ok := headerValue(data, "object")
+			if !ok {
+				return "", fmt.Errorf("git: corrupt tag %s", id)
+			}
+			id = ObjectID(target)
+		default:
+			return "", fmt.Errorf("git: %s is a %s, not a commit", id, objType)
+		}
+	}
+	return "", fmt.Errorf("git: tag chain too long at %s", id)
+}
+
+// headerValue returns the first value of a header line in a commit or tag
+func headerValue(data []byte, key string) (string, bool) {
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			break // End of headers
+		}
+		if k, v, ok := bytes.Cut(line, []byte(" ")); ok && string(k) == key {
+			return string(v), true
+		}
+	}
+	return "", false
+}
+
+// isHex reports whether s consists only of hexadecimal digits
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'f' || c >= 'A' && c <= 'F') {
+			return false
+		}
+	}
+	return s != ""
+}
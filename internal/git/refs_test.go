@@ -0,0 +1,39 @@
+package git
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestResolveRevision(t *testing.T) {
+	f := objectFixture(t)
+	f.git("branch", "feature", "HEAD~3")
+	revisions := []string{"HEAD", "HEAD~1", "HEAD^", "HEAD~3^", "main", "main~2", "feature", "refs/heads/feature", "v1", "v1~1"}
+
+	check := func(t *testing.T) {
+		repo := f.repo()
+		for _, rev := range revisions {
+			want := f.git("rev-parse", rev+"^{commit}")
+			got, err := repo.ResolveRevision(rev)
+			if err != nil || string(got) != want {
+				t.Errorf("ResolveRevision(%q) = %s, %v, want %s", rev, got, err, want)
+			}
+		}
+		short := f.git("rev-parse", "--short=8", "HEAD~4")
+		if got, err := repo.ResolveRevision(short); err != nil || string(got) != f.git("rev-parse", "HEAD~4") {
+			t.Errorf("ResolveRevision(%q) = %s, %v", short, got, err)
+		}
+		for _, rev := range []string{"missing", "HEAD~100", "HEAD^2"} {
+			if got, err := repo.ResolveRevision(rev); err == nil {
+				t.Errorf("ResolveRevision(%q) = %s, want an error", rev, got)
+			}
+		}
+	}
+
+	t.Run("loose", check)
+	f.git("gc", "-q")
+	if refs, _ := filepath.Glob(filepath.Join(f.root, ".git", "refs", "heads", "*")); len(refs) != 0 {
+		t.Fatalf("refs left loose after gc: %v", refs)
+	}
+	t.Run("packed", check)
+}
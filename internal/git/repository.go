@@ -0,0 +1,148 @@
+// Package git reads git repository metadata directly from the .git directory,
+// without shelling out to the git binary
+package git
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrNotRepository is returned when no enclosing git repository is found
+var ErrNotRepository = errors.New("git: not a git repository (or any parent directory)")
+
+// Repository describes the on-disk layout of a git repository
+type Repository struct {
+	WorkTree  string // Top-level working tree directory
+	GitDir    string // Git directory for this working tree (.git or .git/worktrees/<name>)
+	CommonDir string // Directory holding objects, refs and config shared across worktrees
+	HashSize  int    // Object ID length in bytes (20 for SHA-1, 32 for SHA-256)
+
+	storeOnce sync.Once
+	store     *objectStore
+}
+
+// FindRepository locates the git repository containing dir by searching
+// dir and its parents for a .git directory or gitdir file
+func FindRepository(dir string) (*Repository, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, fmt.Errorf("git: failed to get absolute path for '%s': %w", dir, err)
+	}
+
+	for current := absDir; ; {
+		dotGit := filepath.Join(current, ".git")
+		if info, err := os.Stat(dotGit); err == nil {
+			gitDir := dotGit
+			if !info.IsDir() {
+				// Worktrees and submodules use a file pointing to the real git dir
+				if gitDir, err = readGitDirFile(dotGit); err != nil {
+					return nil, err
+				}
+			}
+			return openRepository(current, gitDir)
+		}
+
+		parent := filepath.Dir(current)
+		if parent == current {
+			return nil, ErrNotRepository
+		}
+		current = parent
+	}
+}
+
+// openRepository resolves the common dir and object format for a git dir
+func openRepository(workTree, gitDir string) (*Repository, error) {
+	repo := &Repository{
+		WorkTree:  workTree,
+		GitDir:    gitDir,
+		CommonDir: gitDir,
+		HashSize:  20,
+	}
+
+	// Linked worktrees keep shared data in the main repository's git dir
+	if data, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		common := strings.TrimSpace(string(data))
+		if !filepath.IsAbs(common) {
+			common = filepath.Join(gitDir, common)
+		}
+		repo.CommonDir = filepath.Clean(common)
+	}
+
+	if format, _ := repo.configValue("extensions", "objectformat"); strings.EqualFold(format, "sha256") {
+		repo.HashSize = 32
+	}
+
+	return repo, nil
+}
+
+// readGitDirFile parses a "gitdir: <path>" file
+func readGitDirFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("git: failed to read %s: %w", path, err)
+	}
+
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("git: invalid gitdir file %s", path)
+	}
+	gitDir := strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))
+	if !filepath.IsAbs(gitDir) {
+		gitDir = filepath.Join(filepath.Dir(path), gitDir)
+	}
+	return filepath.Clean(gitDir), nil
+}
+
+// configValue looks up a key in the repository config. It understands the
+// plain "[section]" / "key = value" subset of the git config format.
+func (r *Repository) configValue(section, key string) (string, bool) {
+	file, err := os.Open(filepath.Join(r.CommonDir, "config"))
+	if err != nil {
+		return "", false
+	}
+	defer file.Close()
+
+	current := ""
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			current = strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		name, value, found := strings.Cut(line, "=")
+		if current == section && found && strings.EqualFold(strings.TrimSpace(name), key) {
+			return strings.Trim(strings.TrimSpace(value), `"`), true
+		}
+	}
+	return "", false
+}
+
+// RelativePath converts a path relative to the working tree into a path
+// relative to dir. ok is false if the path lies outside dir.
+func (r *Repository) RelativePath(dir, repoPath string) (string, bool) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	prefix, err := filepath.Rel(r.WorkTree, absDir)
+	if err != nil {
+		return "", false
+	}
+	prefix = filepath.ToSlash(prefix)
+	if prefix == "." {
+		return repoPath, true
+	}
+	if !strings.HasPrefix(repoPath, prefix+"/") {
+		return "", false
+	}
+	return repoPath[len(prefix)+1:], true
+}
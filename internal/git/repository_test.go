@@ -0,0 +1,119 @@
+package git
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fixture is a real repository created with the git binary, for checking
+// the readers against what git itself writes
+type fixture struct {
+	t    *testing.T
+	root string
+	env  []string
+}
+
+// newFixture runs git init with args in a temp dir, skipping the test if
+// git is not installed
+func newFixture(t *testing.T, args ...string) *fixture {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git binary not found")
+	}
+	home := t.TempDir()
+	f := &fixture{
+		t:    t,
+		root: t.TempDir(),
+		env: append(os.Environ(),
+			"HOME="+home,
+			"XDG_CONFIG_HOME="+home,
+			"GIT_CONFIG_NOSYSTEM=1",
+			"GIT_AUTHOR_NAME=Test", "GIT_AUTHOR_EMAIL=test@example.com",
+			"GIT_COMMITTER_NAME=Test", "GIT_COMMITTER_EMAIL=test@example.com",
+			"GIT_AUTHOR_DATE=2024-01-01T00:00:00Z", "GIT_COMMITTER_DATE=2024-01-01T00:00:00Z",
+		),
+	}
+	f.git(append([]string{"init", "-q", "-b", "main"}, args...)...)
+	return f
+}
+
+// git runs a git command in the fixture and returns its trimmed output
+func (f *fixture) git(args ...string) string {
+	f.t.Helper()
+	return strings.TrimRight(string(f.output(args...)), "\n")
+}
+
+// output runs a git command in the fixture and returns its raw output
+func (f *fixture) output(args ...string) []byte {
+	f.t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = f.root
+	cmd.Env = f.env
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		f.t.Fatalf("git %s: %v\n%s", strings.Join(args, " "), err, stderr.String())
+	}
+	return out
+}
+
+// write creates or replaces a file in the working tree
+func (f *fixture) write(path, content string) {
+	f.t.Helper()
+	full := filepath.Join(f.root, filepath.FromSlash(path))
+	if err := os.MkdirAll(filepath.Dir(full), 0o755); err != nil {
+		f.t.Fatal(err)
+	}
+	if err := os.WriteFile(full, []byte(content), 0o644); err != nil {
+		f.t.Fatal(err)
+	}
+}
+
+// commit stages everything and commits it
+func (f *fixture) commit(message string) {
+	f.t.Helper()
+	f.git("add", "-A")
+	f.git("commit", "-q", "-m", message)
+}
+
+// repo opens the fixture with FindRepository
+func (f *fixture) repo() *Repository {
+	f.t.Helper()
+	repo, err := FindRepository(f.root)
+	if err != nil {
+		f.t.Fatal(err)
+	}
+	return repo
+}
+
+func TestFindRepository(t *testing.T) {
+	f := newFixture(t)
+	f.write("sub/dir/a.txt", "a\n")
+	f.commit("initial")
+
+	repo, err := FindRepository(filepath.Join(f.root, "sub", "dir"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	root, _ := filepath.EvalSymlinks(f.root)
+	if got, _ := filepath.EvalSymlinks(repo.WorkTree); got != root {
+		t.Errorf("WorkTree = %q, want %q", got, root)
+	}
+	if repo.HashSize != 20 {
+		t.Errorf("HashSize = %d, want 20", repo.HashSize)
+	}
+	if rel, ok := repo.RelativePath(filepath.Join(repo.WorkTree, "sub"), "sub/dir/a.txt"); !ok || rel != "dir/a.txt" {
+		t.Errorf("RelativePath = %q, %v, want dir/a.txt", rel, ok)
+	}
+	if _, ok := repo.RelativePath(filepath.Join(repo.WorkTree, "sub"), "other/b.txt"); ok {
+		t.Errorf("RelativePath accepted a path outside the directory")
+	}
+
+	if _, err := FindRepository(t.TempDir()); err != ErrNotRepository {
+		t.Errorf("FindRepository outside a repository = %v, want ErrNotRepository", err)
+	}
+}
@@ -0,0 +1,102 @@
+package git
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+)
+
+// Commit holds the parts of a commit object needed to navigate history
+type Commit struct {
+	Tree    ObjectID
+	Parents []ObjectID
+}
+
+// TreeEntry is a file recorded in a tree
+type TreeEntry struct {
+	Mode uint32
+	ID   ObjectID
+}
+
+// ReadCommit parses a commit object
+func (r *Repository) ReadCommit(id ObjectID) (*Commit, error) {
+	objType, data, err := r.ReadObject(id)
+	if err != nil {
+		return nil, err
+	}
+	if objType != ObjectCommit {
+		return nil, fmt.Errorf("git: %s is a %s, not a commit", id, objType)
+	}
+
+	commit := &Commit{}
+	for _, line := range bytes.Split(data, []byte("\n")) {
+		if len(line) == 0 {
+			break
+		}
+		key, value, _ := bytes.Cut(line, []byte(" "))
+		switch string(key) {
+		case "tree":
+			commit.Tree = ObjectID(value)
+		case "parent":
+			commit.Parents = append(commit.Parents, ObjectID(value))
+		}
+	}
+	if commit.Tree == "" {
+		return nil, fmt.Errorf("git: commit %s has no tree", id)
+	}
+	return commit, nil
+}
+
+// CommitFiles returns every file in a commit's tree, keyed by slash-separated
+// path relative to the working tree. Submodule entries are omitted.
+func (r *Repository) CommitFiles(id ObjectID) (map[string]TreeEntry, error) {
+	commit, err := r.ReadCommit(id)
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]TreeEntry)
+	if err := r.collectTree(commit.Tree, "", files); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// collectTree flattens a tree recursively into files
+func (r *Repository) collectTree(id ObjectID, prefix string, files map[string]TreeEntry) error {
+	objType, data, err := r.ReadObject(id)
+	if err != nil {
+		return err
+	}
+	if objType != ObjectTree {
+		return fmt.Errorf("git: %s is a %s, not a tree", id, objType)
+	}
+
+	// Each entry is "<octal mode> <name>\0<raw object id>"
+	for len(data) > 0 {
+		space := bytes.IndexByte(data, ' ')
+		nul := bytes.IndexByte(data, 0)
+		if space < 0 || nul < space || nul+1+r.HashSize > len(data) {
+			return fmt.Errorf("git: corrupt tree %s", id)
+		}
+		mode, err := strconv.ParseUint(string(data[:space]), 8, 32)
+		if err != nil {
+			return fmt.Errorf("git: corrupt tree %s: %w", id, err)
+		}
+		name := prefix + string(data[space+1:nul])
+		entryID := ObjectID(hex.EncodeToString(data[nul+1 : nul+1+r.HashSize]))
+		data = data[nul+1+r.HashSize:]
+
+		switch uint32(mode) & modeTypeMask {
+		case ModeSparseDir:
+			if err := r.collectTree(entryID, name+"/", files); err != nil {
+				return err
+			}
+		case ModeGitlink:
+			// Submodule commits are not files
+		default:
+			files[name] = TreeEntry{Mode: uint32(mode), ID: entryID}
+		}
+	}
+	return nil
+}
@@ -3,9 +3,14 @@ package ignore
 import (
 	"path/filepath"
 	"strings"
+
+	gitignore "github.com/denormal/go-gitignore"
 )
 
-// ShouldIgnore checks if a file or directory should be ignored
+// ShouldIgnore checks if a file or directory should be ignored.
+// Rules are evaluated in order: hidden rule, .git rule, custom patterns,
+// .dumperignore/.dirdumperignore files, then repository .gitignore rules.
+// The first layer with a verdict wins.
 func (m *IgnoreMatcher) ShouldIgnore(relativePath string, isDir bool) bool {
 	// Return early if matcher is nil or disabled
 	if m == nil || m.disabled {
@@ -46,47 +51,70 @@ func (m *IgnoreMatcher) ShouldIgnore(relativePath string, isDir bool) bool {
 		return true
 	}
 
+	// Custom patterns (-ignore) take precedence over repository rules, so a
+	// negated custom pattern can re-include a path that .gitignore excludes
+	if matched, ignored := m.customRules.match(relativePath, isDir); matched {
+		if ignored {
+			m.logger.Debug("ignore.ShouldIgnore: Ignored %q (custom rule)", relativePath)
+		} else {
+			m.logger.Debug("ignore.ShouldIgnore: Path %q explicitly included by custom negation rule", relativePath)
+		}
+		return ignored
+	}
+
+	// Tool-specific ignore files (.dumperignore/.dirdumperignore) come next,
+	// so a dump policy can re-include paths that .gitignore excludes
+	if matched, ignored := m.dumperRules.match(relativePath, isDir); matched {
+		if ignored {
+			m.logger.Debug("ignore.ShouldIgnore: Ignored %q (dumper ignore file rule)", relativePath)
+		} else {
+			m.logger.Debug("ignore.ShouldIgnore: Path %q explicitly included by dumper ignore file negation rule", relativePath)
+		}
+		return ignored
+	}
+
 	// Delegate to gitignore library
 	if m.repoIgnore != nil {
-		unixPath := filepath.ToSlash(relativePath) // Ensure forward slashes
-
-		m.logger.Debug("ignore.ShouldIgnore: Checking library for path %q", unixPath) // Log before library call
-
-		ignored := false
-		included := false
-		// Defensive wrapper for library calls
-		func() {
-			defer func() {
-				if r := recover(); r != nil {
-					m.logger.Error("PANIC recovered in gitignore library for path %q: %v", relativePath, r)
-					// Treat panic as "cannot determine", maybe default to not ignoring? Or log and ignore?
-					// Let's default to NOT ignoring if the library panics.
-					ignored = false
-					included = false
-				}
-			}()
-			ignored = m.repoIgnore.Ignore(unixPath)
+		if matched, ignored := m.matchRepository(m.repoIgnore, relativePath, isDir); matched {
 			if ignored {
-				included = m.repoIgnore.Include(unixPath)
-			}
-		}() // End defensive func
-
-		if ignored {
-			m.logger.Debug("ignore.ShouldIgnore: Path %q ignored by library matcher", relativePath)
-			if included {
+				m.logger.Debug("ignore.ShouldIgnore: Path %q ignored by library matcher", relativePath)
+			} else {
 				m.logger.Debug("ignore.ShouldIgnore: Path %q explicitly included by negation rule", relativePath)
-				return false // Explicitly included, so NOT ignored
 			}
-			return true // Ignored and not explicitly included
+			return ignored
 		}
 	} else {
-		m.logger.Debug("ignore.ShouldIgnore: No repository ignore patterns loaded (m.repoIgnore is nil).", relativePath)
+		m.logger.Debug("ignore.ShouldIgnore: No repository ignore patterns loaded (m.repoIgnore is nil) for %q.", relativePath)
 	}
 
 	m.logger.Debug("ignore.ShouldIgnore: Path %q NOT ignored by any rule", relativePath)
 	return false
 }
 
+// matchRepository evaluates a path against a hierarchical ignore-file repository.
+// matched reports whether any pattern applied; ignored is that pattern's verdict.
+func (m *IgnoreMatcher) matchRepository(repo gitignore.GitIgnore, relativePath string, isDir bool) (matched, ignored bool) {
+	m.logger.Debug("ignore.ShouldIgnore: Checking library for path %q", filepath.ToSlash(relativePath))
+
+	// Defensive wrapper for library calls
+	defer func() {
+		if r := recover(); r != nil {
+			m.logger.Error("PANIC recovered in gitignore library for path %q: %v", relativePath, r)
+			// Default to NOT ignoring if the library panics
+			matched, ignored = false, false
+		}
+	}()
+
+	// Ignore and Include resolve the path against the working directory and
+	// stat it, so they only work when run from the root. Relative matches
+	// against the repository root without touching the filesystem.
+	match := repo.Relative(relativePath, isDir)
+	if match == nil {
+		return false, false
+	}
+	return true, match.Ignore()
+}
+
 // isPathInGitDir checks if a path is inside a .git directory
 func isPathInGitDir(relativePath string, isDir bool) bool {
 	parts := strings.Split(filepath.ToSlash(relativePath), "/")
@@ -0,0 +1,61 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files under root from a map of slash-separated paths to
+// contents
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestRepositoryRulesOutsideWorkingDir checks that .gitignore rules apply when
+// the matcher root is not the working directory, as with -dir
+func TestRepositoryRulesOutsideWorkingDir(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":     "*.log\nbuild/\n!keep.log\n",
+		"main.go":        "",
+		"app.log":        "",
+		"keep.log":       "",
+		"build/out.txt":  "",
+		"sub/.gitignore": "*.tmp\n",
+		"sub/a.tmp":      "",
+		"sub/a.txt":      "",
+	})
+
+	m, err := New(root, WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path  string
+		isDir bool
+		want  bool
+	}{
+		{"main.go", false, false},
+		{"app.log", false, true},
+		{"keep.log", false, false},
+		{"build", true, true},
+		{"build/out.txt", false, true},
+		{"sub/a.tmp", false, true},
+		{"sub/a.txt", false, false},
+	}
+	for _, tt := range tests {
+		if got := m.ShouldIgnore(tt.path, tt.isDir); got != tt.want {
+			t.Errorf("ShouldIgnore(%q, %v) = %v, want %v", tt.path, tt.isDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package ignore
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bethropolis/dir-dumper/internal/utils"
+)
+
+// dumperRules lazily loads tool-specific ignore files and caches, per
+// directory, the combined rules from the root down to that directory.
+// Rules from deeper files come later, so they take precedence.
+type dumperRules struct {
+	rootDir string
+	logger  utils.Logger
+	cache   map[string]*ruleSet // Keyed by slash-separated dir relative to root
+	mutex   sync.Mutex
+}
+
+// newDumperRules creates an empty, lazily populated rule cache
+func newDumperRules(rootDir string, logger utils.Logger) *dumperRules {
+	return &dumperRules{
+		rootDir: rootDir,
+		logger:  logger,
+		cache:   make(map[string]*ruleSet),
+	}
+}
+
+// match evaluates relativePath against the ignore files in scope for it
+func (d *dumperRules) match(relativePath string, isDir bool) (matched, ignored bool) {
+	if d == nil {
+		return false, false
+	}
+
+	dir := path.Dir(strings.Trim(filepath.ToSlash(relativePath), "/"))
+	d.mutex.Lock()
+	rules := d.load(dir)
+	d.mutex.Unlock()
+
+	return rules.match(relativePath, isDir)
+}
+
+// load returns the combined rules for dir, reading its ignore files if needed.
+// The caller must hold d.mutex.
+func (d *dumperRules) load(dir string) *ruleSet {
+	if dir == "." || dir == "/" {
+		dir = ""
+	}
+	if rules, ok := d.cache[dir]; ok {
+		return rules
+	}
+
+	var inherited *ruleSet
+	if dir != "" {
+		inherited = d.load(path.Dir(dir))
+	}
+
+	var own []*Pattern
+	for _, name := range DumperIgnoreFiles {
+		file := filepath.Join(d.rootDir, filepath.FromSlash(dir), name)
+		data, err := os.ReadFile(file)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				d.logger.Warn("ignore: Failed to read %s: %v", file, err)
+			}
+			continue
+		}
+
+		d.logger.Debug("ignore: Loaded dumper ignore file %s", file)
+		fileRules := &ruleSet{}
+		fileRules.add(strings.Split(string(data), "\n"), dir)
+		own = append(own, fileRules.patterns...)
+	}
+
+	// Directories without their own files share the parent's rules
+	rules := inherited
+	if rules == nil {
+		rules = &ruleSet{}
+	}
+	if len(own) > 0 {
+		combined := &ruleSet{}
+		combined.patterns = append(combined.patterns, rules.patterns...)
+		combined.patterns = append(combined.patterns, own...)
+		rules = combined
+	}
+
+	d.cache[dir] = rules
+	return rules
+}
@@ -16,6 +16,7 @@ func NewFromConfig(cfg Config) (*IgnoreMatcher, error) {
 		WithHiddenIgnore(cfg.IgnoreHidden),
 		WithGitIgnore(cfg.IgnoreGit),
 		WithRecursive(cfg.RecursiveMode),
+		WithDumperIgnore(!cfg.NoDumperIgnore),
 		WithDisabled(cfg.Disabled),
 	}
 
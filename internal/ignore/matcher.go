@@ -18,11 +18,13 @@ func New(rootDir string, opts ...Option) (*IgnoreMatcher, error) {
 
 	// Initialize with default configuration
 	matcher := &IgnoreMatcher{
-		rootDir:       absRootDir,
-		ignoreHidden:  true, // Default
-		ignoreGit:     true, // Default
-		recursiveMode: true, // Default
-		logger:        &utils.NoopLogger{},
+		rootDir:        absRootDir,
+		ignoreHidden:   true, // Default
+		ignoreGit:      true, // Default
+		gitignoreRules: true, // Default
+		recursiveMode:  true, // Default
+		dumperIgnore:   true, // Default
+		logger:         &utils.NoopLogger{},
 	}
 
 	// Apply functional options
@@ -43,6 +45,7 @@ func (m *IgnoreMatcher) init() error {
 	m.logger.Debug("ignore.New: Initializing for root: %s", m.rootDir)
 	m.logger.Debug("ignore.New: ignoreHidden flag set to: %v", m.ignoreHidden)
 	m.logger.Debug("ignore.New: ignoreGit flag set to: %v", m.ignoreGit)
+	m.logger.Debug("ignore.New: dumperIgnore flag set to: %v", m.dumperIgnore)
 
 	// Skip gitignore initialization if the matcher is disabled
 	if m.disabled {
@@ -50,6 +53,12 @@ func (m *IgnoreMatcher) init() error {
 		return nil
 	}
 
+	// Load tool-specific ignore files and custom rules even without gitignore rules
+	if !m.gitignoreRules {
+		m.logger.Debug("ignore.New: Repository .gitignore rules disabled")
+		return m.initCustomRules()
+	}
+
 	// Always use the repository approach to load gitignore files recursively
 	// This better matches git's actual behavior
 	repoMatcher, repoErr := gitignore.NewRepository(m.rootDir)
@@ -69,12 +78,30 @@ func (m *IgnoreMatcher) init() error {
 	m.repoIgnore = repoMatcher
 	m.logger.Debug("ignore.New: Successfully loaded repository ignores.")
 
+	return m.initCustomRules()
+}
+
+// initCustomRules sets up the tool-specific ignore files and custom patterns
+func (m *IgnoreMatcher) initCustomRules() error {
+	// Tool-specific ignore files use the same hierarchical scoping as
+	// .gitignore; individual files are read lazily as directories are matched
+	if m.dumperIgnore {
+		m.dumperRules = newDumperRules(m.rootDir, m.logger)
+		m.logger.Debug("ignore.New: Enabled dumper ignore files: %v", DumperIgnoreFiles)
+	}
+
 	// Explicitly ignore .git directory if the flag requires it
+	patterns := append([]string(nil), m.customPatterns...)
 	if m.ignoreGit {
 		m.logger.Debug("ignore.New: Explicitly adding /.git/ pattern.")
 		// Add to custom patterns
-		m.customPatterns = append(m.customPatterns, "/.git/")
+		patterns = append(patterns, "/.git/")
 	}
 
+	// Compile custom patterns; they take precedence over repository rules
+	m.customRules = &ruleSet{}
+	m.customRules.add(patterns, "")
+	m.logger.Debug("ignore.New: Compiled %d custom ignore patterns.", len(m.customRules.patterns))
+
 	return nil
 }
@@ -17,6 +17,15 @@ func WithGitIgnore(ignore bool) Option {
 	}
 }
 
+// WithGitignoreRules enables or disables the repository .gitignore rules.
+// Disabling them is useful when paths come from the git index, where a
+// tracked file is included even if it matches a .gitignore pattern.
+func WithGitignoreRules(enabled bool) Option {
+	return func(m *IgnoreMatcher) {
+		m.gitignoreRules = enabled
+	}
+}
+
 func WithRecursive(recursive bool) Option {
 	return func(m *IgnoreMatcher) {
 		m.recursiveMode = recursive
@@ -29,6 +38,13 @@ func WithCustomRules(patterns []string) Option {
 	}
 }
 
+// WithDumperIgnore enables or disables .dumperignore/.dirdumperignore files
+func WithDumperIgnore(enabled bool) Option {
+	return func(m *IgnoreMatcher) {
+		m.dumperIgnore = enabled
+	}
+}
+
 func WithLogger(logger utils.Logger) Option {
 	return func(m *IgnoreMatcher) {
 		if logger != nil {
@@ -0,0 +1,228 @@
+package ignore
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Pattern is a single compiled gitignore-style pattern
+type Pattern struct {
+	source   string   // Original pattern text, for logging
+	base     string   // Directory the pattern is relative to ("" for the root)
+	segments []string // Slash-separated glob segments ("**" matches any depth)
+	negate   bool     // Pattern started with '!'
+	dirOnly  bool     // Pattern ended with '/'
+}
+
+// ParsePattern compiles a single gitignore-style pattern relative to base
+// (a slash-separated directory relative to the matcher root, "" for the root).
+// It returns nil for blank lines and comments.
+func ParsePattern(line string, base string) *Pattern {
+	line = trimTrailingSpaces(strings.TrimSuffix(line, "\r"))
+	if line == "" || strings.HasPrefix(line, "#") {
+		return nil
+	}
+
+	p := &Pattern{source: line, base: strings.Trim(filepath.ToSlash(base), "/")}
+	if p.base == "." {
+		p.base = ""
+	}
+
+	// Leading '!' negates; "\!" and "\#" are literal
+	if strings.HasPrefix(line, "!") {
+		p.negate = true
+		line = line[1:]
+	} else if strings.HasPrefix(line, `\!`) || strings.HasPrefix(line, `\#`) {
+		line = line[1:]
+	}
+
+	// Trailing '/' restricts the pattern to directories
+	if strings.HasSuffix(line, "/") {
+		p.dirOnly = true
+		line = strings.TrimRight(line, "/")
+	}
+	if line == "" {
+		return nil
+	}
+
+	// A separator at the beginning or in the middle anchors the pattern to base;
+	// otherwise it may match at any depth below base
+	anchored := strings.Contains(line, "/")
+	line = strings.TrimPrefix(line, "/")
+
+	for _, seg := range strings.Split(line, "/") {
+		if seg == "" {
+			continue
+		}
+		// Collapse consecutive "**" segments, they are equivalent to one
+		if seg == "**" && len(p.segments) > 0 && p.segments[len(p.segments)-1] == "**" {
+			continue
+		}
+		p.segments = append(p.segments, seg)
+	}
+	if len(p.segments) == 0 {
+		return nil
+	}
+	if !anchored && p.segments[0] != "**" {
+		p.segments = append([]string{"**"}, p.segments...)
+	}
+
+	return p
+}
+
+// String returns the original pattern text
+func (p *Pattern) String() string {
+	return p.source
+}
+
+// Negated reports whether the pattern re-includes paths ('!' prefix)
+func (p *Pattern) Negated() bool {
+	return p.negate
+}
+
+// Match reports whether relativePath (relative to the matcher root) matches
+// the pattern. Only the path itself is considered, not its parent directories.
+func (p *Pattern) Match(relativePath string, isDir bool) bool {
+	if p.dirOnly && !isDir {
+		return false
+	}
+
+	rel, ok := p.relativeToBase(relativePath)
+	if !ok || rel == "" {
+		return false
+	}
+
+	return matchSegments(p.segments, strings.Split(rel, "/"))
+}
+
+// MayMatchBelow reports whether the pattern could match dir itself or any
+// path inside it. It is used to prune directories that cannot contain matches.
+func (p *Pattern) MayMatchBelow(dir string) bool {
+	rel, ok := p.relativeToBase(dir)
+	if !ok {
+		// dir may still be an ancestor of the pattern's base directory
+		d := strings.Trim(filepath.ToSlash(dir), "/")
+		return d == "" || d == "." || strings.HasPrefix(p.base+"/", d+"/")
+	}
+	if rel == "" || rel == "." {
+		return true
+	}
+	return matchPrefix(p.segments, strings.Split(rel, "/"))
+}
+
+// relativeToBase strips the pattern's base directory from relativePath
+func (p *Pattern) relativeToBase(relativePath string) (string, bool) {
+	rel := strings.Trim(filepath.ToSlash(relativePath), "/")
+	if p.base == "" {
+		return rel, true
+	}
+	if !strings.HasPrefix(rel, p.base+"/") {
+		return "", false
+	}
+	return rel[len(p.base)+1:], true
+}
+
+// matchSegments matches glob segments against path segments, expanding "**"
+func matchSegments(pattern, segs []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			rest := pattern[1:]
+			// A trailing "**" matches everything inside, but not the directory itself
+			if len(rest) == 0 {
+				return len(segs) > 0
+			}
+			for i := 0; i <= len(segs); i++ {
+				if matchSegments(rest, segs[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(segs) == 0 {
+			return false
+		}
+		if ok, err := path.Match(pattern[0], segs[0]); err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		segs = segs[1:]
+	}
+	return len(segs) == 0
+}
+
+// matchPrefix reports whether path segments could be a leading part of a
+// path matched by pattern, or lie inside a directory the pattern matches
+func matchPrefix(pattern, segs []string) bool {
+	for len(segs) > 0 {
+		if len(pattern) == 0 || pattern[0] == "**" {
+			return true
+		}
+		if ok, err := path.Match(pattern[0], segs[0]); err != nil || !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		segs = segs[1:]
+	}
+	return true
+}
+
+// trimTrailingSpaces removes unescaped trailing spaces, as git does
+func trimTrailingSpaces(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	if strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-2] + " "
+	}
+	return s
+}
+
+// ruleSet is an ordered list of patterns evaluated with gitignore semantics
+type ruleSet struct {
+	patterns []*Pattern
+}
+
+// add compiles each line relative to base and appends it to the set
+func (rs *ruleSet) add(lines []string, base string) {
+	for _, line := range lines {
+		if p := ParsePattern(line, base); p != nil {
+			rs.patterns = append(rs.patterns, p)
+		}
+	}
+}
+
+// empty reports whether the set holds no patterns
+func (rs *ruleSet) empty() bool {
+	return rs == nil || len(rs.patterns) == 0
+}
+
+// match evaluates relativePath against the set. matched reports whether any
+// pattern applied; ignored is the verdict of the last matching pattern.
+// A path inside an ignored directory is ignored and cannot be re-included.
+func (rs *ruleSet) match(relativePath string, isDir bool) (matched, ignored bool) {
+	if rs.empty() {
+		return false, false
+	}
+
+	rel := strings.Trim(filepath.ToSlash(relativePath), "/")
+	parts := strings.Split(rel, "/")
+	for i := 1; i < len(parts); i++ {
+		if m, ign := rs.matchOne(strings.Join(parts[:i], "/"), true); m && ign {
+			return true, true
+		}
+	}
+	return rs.matchOne(rel, isDir)
+}
+
+// matchOne evaluates a single path, ignoring its parents; the last match wins
+func (rs *ruleSet) matchOne(rel string, isDir bool) (matched, ignored bool) {
+	for i := len(rs.patterns) - 1; i >= 0; i-- {
+		p := rs.patterns[i]
+		if p.Match(rel, isDir) {
+			return true, !p.negate
+		}
+	}
+	return false, false
+}
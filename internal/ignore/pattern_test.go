@@ -0,0 +1,161 @@
+package ignore
+
+import "testing"
+
+func TestPatternMatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		base    string
+		path    string
+		isDir   bool
+		want    bool
+	}{
+		// Unanchored patterns match at any depth
+		{"unanchored root", "*.log", "", "app.log", false, true},
+		{"unanchored nested", "*.log", "", "a/b/app.log", false, true},
+		{"unanchored name", "build", "", "src/build", true, true},
+		{"unanchored other", "*.log", "", "app.txt", false, false},
+
+		// A leading or middle slash anchors the pattern to its base
+		{"leading slash root", "/build", "", "build", true, true},
+		{"leading slash nested", "/build", "", "src/build", true, false},
+		{"middle slash", "src/*.go", "", "src/main.go", false, true},
+		{"middle slash nested", "src/*.go", "", "pkg/src/main.go", false, false},
+		{"middle slash no recursion", "src/*.go", "", "src/sub/main.go", false, false},
+		{"anchored to base", "/gen", "pkg", "pkg/gen", true, true},
+		{"anchored outside base", "/gen", "pkg", "gen", true, false},
+		{"unanchored below base", "*.tmp", "pkg", "pkg/a/b.tmp", false, true},
+		{"unanchored outside base", "*.tmp", "pkg", "other/b.tmp", false, false},
+
+		// Leading "**" matches in all directories
+		{"leading ** root", "**/foo", "", "foo", false, true},
+		{"leading ** nested", "**/foo", "", "a/b/foo", false, true},
+		{"leading ** with dir", "**/foo/bar", "", "x/foo/bar", false, true},
+		{"leading ** with dir mismatch", "**/foo/bar", "", "foo/x/bar", false, false},
+
+		// Middle "**" matches zero or more directories
+		{"middle ** zero", "a/**/b", "", "a/b", false, true},
+		{"middle ** one", "a/**/b", "", "a/x/b", false, true},
+		{"middle ** many", "a/**/b", "", "a/x/y/z/b", false, true},
+		{"middle ** anchored", "a/**/b", "", "c/a/x/b", false, false},
+
+		// Trailing "**" matches everything inside, but not the directory itself
+		{"trailing ** inside", "abc/**", "", "abc/x", false, true},
+		{"trailing ** deep", "abc/**", "", "abc/x/y", false, true},
+		{"trailing ** itself", "abc/**", "", "abc", true, false},
+
+		// A trailing slash only matches directories
+		{"dir only dir", "logs/", "", "logs", true, true},
+		{"dir only nested dir", "logs/", "", "a/logs", true, true},
+		{"dir only file", "logs/", "", "logs", false, false},
+
+		// Escaped leading characters are literal
+		{"escaped hash", `\#notes`, "", "#notes", false, true},
+		{"escaped bang", `\!important`, "", "!important", false, true},
+		{"escaped bang not negated", `\!important`, "", "important", false, false},
+
+		// Trailing spaces are trimmed unless escaped
+		{"trailing space", "a.txt  ", "", "a.txt", false, true},
+		{"escaped trailing space", `a\ `, "", "a ", false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ParsePattern(tt.pattern, tt.base)
+			if p == nil {
+				t.Fatalf("ParsePattern(%q) = nil", tt.pattern)
+			}
+			if got := p.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("%q.Match(%q, %v) = %v, want %v", tt.pattern, tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParsePatternSkipsBlankAndComments(t *testing.T) {
+	for _, line := range []string{"", "   ", "# comment", "\r", "/", "!"} {
+		if p := ParsePattern(line, ""); p != nil {
+			t.Errorf("ParsePattern(%q) = %v, want nil", line, p)
+		}
+	}
+	if p := ParsePattern("!*.log", ""); p == nil || !p.Negated() {
+		t.Errorf("ParsePattern(%q) is not negated", "!*.log")
+	}
+	if p := ParsePattern(`\!*.log`, ""); p == nil || p.Negated() {
+		t.Errorf("ParsePattern(%q) is negated", `\!*.log`)
+	}
+}
+
+func TestRuleSetMatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		rules       []string
+		path        string
+		isDir       bool
+		wantMatched bool
+		wantIgnored bool
+	}{
+		{"no match", []string{"*.log"}, "main.go", false, false, false},
+		{"ignored", []string{"*.log"}, "app.log", false, true, true},
+		{"last match wins", []string{"*.log", "!keep.log"}, "keep.log", false, true, false},
+		{"last match wins again", []string{"!keep.log", "*.log"}, "keep.log", false, true, true},
+		{"negation of other file", []string{"*.log", "!keep.log"}, "app.log", false, true, true},
+
+		// A file cannot be re-included if a parent directory is excluded
+		{"excluded parent", []string{"build/", "!build/keep.txt"}, "build/keep.txt", false, true, true},
+		{"excluded parent deep", []string{"vendor", "!vendor/a/keep.go"}, "vendor/a/keep.go", false, true, true},
+
+		// Excluding the directory's contents instead keeps the directory, so
+		// a file inside it can be re-included
+		{"re-include under contents", []string{"build/*", "!build/keep.txt"}, "build/keep.txt", false, true, false},
+		{"other file under contents", []string{"build/*", "!build/keep.txt"}, "build/out.bin", false, true, true},
+		{"re-include under **", []string{"docs/**", "!docs/**/", "!docs/**/*.md"}, "docs/a/b.md", false, true, false},
+		{"exclude under **", []string{"docs/**", "!docs/**/", "!docs/**/*.md"}, "docs/a/b.png", false, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rs := &ruleSet{}
+			rs.add(tt.rules, "")
+			matched, ignored := rs.match(tt.path, tt.isDir)
+			if matched != tt.wantMatched || ignored != tt.wantIgnored {
+				t.Errorf("match(%q) with %q = (%v, %v), want (%v, %v)",
+					tt.path, tt.rules, matched, ignored, tt.wantMatched, tt.wantIgnored)
+			}
+		})
+	}
+}
+
+// TestCustomRulesOverrideRepository checks that -ignore patterns take
+// precedence over the repository .gitignore in both directions
+func TestCustomRulesOverrideRepository(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		".gitignore":   "*.log\n",
+		"app.log":      "",
+		"debug.log":    "",
+		"main.go":      "",
+		"main_test.go": "",
+	})
+
+	m, err := New(root,
+		WithDumperIgnore(false),
+		WithCustomRules([]string{"!app.log", "*_test.go"}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"app.log", false},     // Re-included by a custom negation over .gitignore
+		{"debug.log", true},    // Still excluded by .gitignore
+		{"main_test.go", true}, // Excluded by a custom pattern
+		{"main.go", false},
+	}
+	for _, tt := range tests {
+		if got := m.ShouldIgnore(tt.path, false); got != tt.want {
+			t.Errorf("ShouldIgnore(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
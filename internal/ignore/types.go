@@ -6,28 +6,41 @@ import (
 	gitignore "github.com/denormal/go-gitignore"
 )
 
+// DumperIgnoreFiles are the tool-specific ignore file names discovered at
+// every directory level, in the order they are consulted
+var DumperIgnoreFiles = []string{".dumperignore", ".dirdumperignore"}
+
 // IgnoreMatcher determines whether a file or directory should be ignored
 type IgnoreMatcher struct {
 	// The core gitignore object handling repository rules
 	repoIgnore gitignore.GitIgnore
 
+	// Compiled -ignore patterns, evaluated before the repository rules
+	customRules *ruleSet
+
+	// Lazily loaded rules from tool-specific ignore files
+	dumperRules *dumperRules
+
 	// Configuration flags
 	rootDir        string
 	ignoreHidden   bool
 	ignoreGit      bool
+	gitignoreRules bool
 	recursiveMode  bool
 	customPatterns []string
+	dumperIgnore   bool
 	logger         utils.Logger
 	disabled       bool
 }
 
 // Config holds configuration options for the ignore matcher
 type Config struct {
-	RootDir       string
-	IgnoreHidden  bool
-	IgnoreGit     bool
-	RecursiveMode bool
-	CustomRules   []string
-	Logger        utils.Logger
-	Disabled      bool
+	RootDir        string
+	IgnoreHidden   bool
+	IgnoreGit      bool
+	RecursiveMode  bool
+	CustomRules    []string
+	NoDumperIgnore bool // Skip .dumperignore/.dirdumperignore files (honored by default, as with New)
+	Logger         utils.Logger
+	Disabled       bool
 }
@@ -0,0 +1,197 @@
+// Package language detects the programming language of a file from its name
+// and content, using the identifiers Markdown fences use for highlighting
+package language
+
+import (
+	"bytes"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// byExtension maps lowercase file extensions (without the dot) to languages
+var byExtension = map[string]string{
+	"asm":        "asm",
+	"bat":        "batch",
+	"c":          "c",
+	"cc":         "cpp",
+	"cjs":        "javascript",
+	"clj":        "clojure",
+	"cmake":      "cmake",
+	"cmd":        "batch",
+	"cpp":        "cpp",
+	"cs":         "csharp",
+	"css":        "css",
+	"csv":        "csv",
+	"cxx":        "cpp",
+	"dart":       "dart",
+	"diff":       "diff",
+	"dockerfile": "dockerfile",
+	"el":         "elisp",
+	"env":        "dotenv",
+	"erl":        "erlang",
+	"ex":         "elixir",
+	"exs":        "elixir",
+	"fish":       "fish",
+	"fs":         "fsharp",
+	"go":         "go",
+	"gradle":     "groovy",
+	"graphql":    "graphql",
+	"groovy":     "groovy",
+	"h":          "c",
+	"hcl":        "hcl",
+	"hh":         "cpp",
+	"hpp":        "cpp",
+	"hs":         "haskell",
+	"htm":        "html",
+	"html":       "html",
+	"ini":        "ini",
+	"java":       "java",
+	"jl":         "julia",
+	"js":         "javascript",
+	"json":       "json",
+	"jsonc":      "jsonc",
+	"jsx":        "jsx",
+	"kt":         "kotlin",
+	"kts":        "kotlin",
+	"less":       "less",
+	"lua":        "lua",
+	"m":          "objectivec",
+	"md":         "markdown",
+	"mjs":        "javascript",
+	"mk":         "makefile",
+	"ml":         "ocaml",
+	"nim":        "nim",
+	"nix":        "nix",
+	"patch":      "diff",
+	"php":        "php",
+	"pl":         "perl",
+	"pm":         "perl",
+	"properties": "properties",
+	"proto":      "protobuf",
+	"ps1":        "powershell",
+	"py":         "python",
+	"r":          "r",
+	"rb":         "ruby",
+	"rs":         "rust",
+	"rst":        "rst",
+	"sass":       "sass",
+	"scala":      "scala",
+	"scss":       "scss",
+	"sh":         "bash",
+	"sql":        "sql",
+	"svelte":     "svelte",
+	"swift":      "swift",
+	"tex":        "latex",
+	"tf":         "hcl",
+	"toml":       "toml",
+	"ts":         "typescript",
+	"tsx":        "tsx",
+	"txt":        "text",
+	"vue":        "vue",
+	"xml":        "xml",
+	"yaml":       "yaml",
+	"yml":        "yaml",
+	"zig":        "zig",
+	"zsh":        "zsh",
+}
+
+// byFilename maps well-known file names without a telling extension
+var byFilename = map[string]string{
+	".bash_profile":  "bash",
+	".bashrc":        "bash",
+	".dockerignore":  "gitignore",
+	".dumperignore":  "gitignore",
+	".env":           "dotenv",
+	".gitattributes": "gitattributes",
+	".gitignore":     "gitignore",
+	".zshrc":         "zsh",
+	"cmakelists.txt": "cmake",
+	"containerfile":  "dockerfile",
+	"dockerfile":     "dockerfile",
+	"gemfile":        "ruby",
+	"gnumakefile":    "makefile",
+	"go.mod":         "go-mod",
+	"go.sum":         "text",
+	"jenkinsfile":    "groovy",
+	"makefile":       "makefile",
+	"rakefile":       "ruby",
+	"vagrantfile":    "ruby",
+}
+
+// byInterpreter maps shebang interpreters (version suffixes removed)
+var byInterpreter = map[string]string{
+	"bash":    "bash",
+	"dash":    "sh",
+	"deno":    "typescript",
+	"fish":    "fish",
+	"lua":     "lua",
+	"node":    "javascript",
+	"perl":    "perl",
+	"php":     "php",
+	"pwsh":    "powershell",
+	"python":  "python",
+	"ruby":    "ruby",
+	"runghc":  "haskell",
+	"sh":      "sh",
+	"ts-node": "typescript",
+	"tsx":     "typescript",
+	"zsh":     "zsh",
+}
+
+// Detect returns the language of the file at relativePath, or "" if unknown.
+// Well-known file names take precedence over extensions; files without a
+// recognized name or extension are identified by their shebang line.
+func Detect(relativePath string, content []byte) string {
+	name := strings.ToLower(path.Base(filepath.ToSlash(relativePath)))
+
+	if lang, ok := byFilename[name]; ok {
+		return lang
+	}
+	// Variants like Dockerfile.dev, Makefile.linux or .env.local
+	for _, prefix := range []string{"dockerfile", "containerfile", "makefile", ".env"} {
+		if strings.HasPrefix(name, prefix+".") {
+			return byFilename[prefix]
+		}
+	}
+
+	if ext := path.Ext(name); ext != "" {
+		if lang, ok := byExtension[ext[1:]]; ok {
+			return lang
+		}
+	}
+
+	return FromShebang(content)
+}
+
+// FromShebang returns the language named by a "#!" interpreter line at the
+// start of content, or "" if there is none or it is not recognized
+func FromShebang(content []byte) string {
+	if !bytes.HasPrefix(content, []byte("#!")) {
+		return ""
+	}
+	line := content[2:]
+	if i := bytes.IndexByte(line, '\n'); i >= 0 {
+		line = line[:i]
+	}
+
+	fields := strings.Fields(string(line))
+	if len(fields) == 0 {
+		return ""
+	}
+	// "#!/usr/bin/env [-S] python3" names the interpreter in a later field
+	interpreter := path.Base(fields[0])
+	if interpreter == "env" {
+		interpreter = ""
+		for _, f := range fields[1:] {
+			if !strings.HasPrefix(f, "-") && !strings.Contains(f, "=") {
+				interpreter = path.Base(f)
+				break
+			}
+		}
+	}
+
+	// Strip version suffixes such as python3.11 or ruby2
+	interpreter = strings.TrimRight(interpreter, "0123456789.")
+	return byInterpreter[interpreter]
+}
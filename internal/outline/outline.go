@@ -0,0 +1,113 @@
+// Package outline reduces source files to an outline of their declarations,
+// leaving out function bodies, for dumps that only need a codebase's API
+package outline
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"sort"
+)
+
+// Func returns the outline of a source file's content, with the line in
+// content of each line of the outline
+type Func func(content []byte) ([]byte, []int, error)
+
+// byExtension maps file extensions (without the dot) to their outline
+var byExtension = map[string]Func{
+	"go": Go,
+}
+
+// For returns the outline for files with extension (without the dot)
+func For(extension string) (Func, bool) {
+	fn, ok := byExtension[extension]
+	return fn, ok
+}
+
+// Extensions returns the extensions that have an outline, sorted
+func Extensions() []string {
+	extensions := make([]string, 0, len(byExtension))
+	for ext := range byExtension {
+		extensions = append(extensions, ext)
+	}
+	sort.Strings(extensions)
+	return extensions
+}
+
+// elidedBody replaces the bodies of function literals and composite literals
+const elidedBody = "{ /* ... */ }"
+
+// cut is a range of the source replaced in the outline
+type cut struct {
+	start, end int
+	with       string
+}
+
+// Go returns the outline of Go source: the package clause, imports, types,
+// constants, variables and function and method signatures, with their
+// comments, as written. Function and method bodies are removed. In
+// declarations, the bodies of function literals and the elements of
+// composite literals spanning several lines in variables are elided.
+func Go(content []byte) ([]byte, []int, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", content, parser.SkipObjectResolution)
+	if err != nil {
+		return nil, nil, fmt.Errorf("outline: %w", err)
+	}
+	tf := fset.File(file.Pos())
+
+	var cuts []cut
+	for _, decl := range file.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			if d.Body != nil {
+				cuts = append(cuts, cut{start: tf.Offset(d.Type.End()), end: tf.Offset(d.Body.End())})
+			}
+		case *ast.GenDecl:
+			ast.Inspect(d, func(n ast.Node) bool {
+				switch lit := n.(type) {
+				case *ast.FuncLit:
+					cuts = append(cuts, cut{start: tf.Offset(lit.Body.Pos()), end: tf.Offset(lit.Body.End()), with: elidedBody})
+					return false
+				case *ast.CompositeLit:
+					// Tables of data are not part of the API; short values stay
+					if d.Tok == token.VAR && tf.Line(lit.Lbrace) != tf.Line(lit.Rbrace) {
+						cuts = append(cuts, cut{start: tf.Offset(lit.Lbrace), end: tf.Offset(lit.Rbrace) + 1, with: elidedBody})
+						return false
+					}
+				}
+				return true
+			})
+		}
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	var lines []int
+	lineStart := true
+	// write appends text standing in for the source at offset src; text
+	// copied from the source advances with it, a replacement does not
+	write := func(text []byte, src int, copied bool) {
+		for i, b := range text {
+			if lineStart {
+				offset := src
+				if copied {
+					offset += i
+				}
+				lines = append(lines, tf.Line(tf.Pos(offset)))
+			}
+			lineStart = b == '\n'
+		}
+		out.Write(text)
+	}
+	pos := 0
+	for _, c := range cuts {
+		write(content[pos:c.start], pos, true)
+		write([]byte(c.with), c.start, false)
+		pos = c.end
+	}
+	write(content[pos:], pos, true)
+	return out.Bytes(), lines, nil
+}
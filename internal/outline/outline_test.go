@@ -0,0 +1,81 @@
+package outline
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const source = `// Package demo is a demo.
+package demo
+
+import "fmt"
+
+// F prints.
+func F() {
+	fmt.Println("F")
+}
+
+var handlers = map[string]func(){
+	"a": func() {
+		F()
+	},
+}
+
+func G(a int) int { return a }
+
+type T struct{ A int }
+`
+
+func TestGo(t *testing.T) {
+	got, lines, err := Go([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `// Package demo is a demo.
+package demo
+
+import "fmt"
+
+// F prints.
+func F()
+
+var handlers = map[string]func(){ /* ... */ }
+
+func G(a int) int
+
+type T struct{ A int }
+`
+	if string(got) != want {
+		t.Errorf("Go =\n%s\nwant\n%s", got, want)
+	}
+	if want := []int{1, 2, 3, 4, 5, 6, 7, 10, 11, 16, 17, 18, 19}; !reflect.DeepEqual(lines, want) {
+		t.Errorf("lines = %v, want %v", lines, want)
+	}
+}
+
+// TestGoLinesPointAtSource checks that every line of the outline starts as
+// the source line it is mapped to
+func TestGoLinesPointAtSource(t *testing.T) {
+	got, lines, err := Go([]byte(source))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sourceLines := strings.Split(source, "\n")
+	outline := strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")
+	if len(outline) != len(lines) {
+		t.Fatalf("%d lines mapped for %d lines of outline", len(lines), len(outline))
+	}
+	for i, line := range outline {
+		prefix, _, _ := strings.Cut(line, "{")
+		if !strings.HasPrefix(sourceLines[lines[i]-1], prefix) {
+			t.Errorf("outline line %d %q is mapped to source line %d %q", i+1, line, lines[i], sourceLines[lines[i]-1])
+		}
+	}
+}
+
+func TestGoInvalid(t *testing.T) {
+	if _, _, err := Go([]byte("package")); err == nil {
+		t.Error("Go accepted invalid source")
+	}
+}
@@ -0,0 +1,512 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bethropolis/dir-dumper/internal/tokens"
+)
+
+// ChunkOptions splits the document into numbered files once a chunk reaches
+// a size limit. Limits of 0 are disabled.
+type ChunkOptions struct {
+	Path      string         // Output path; chunk numbers go before its extension
+	MaxBytes  int64          // Max bytes per chunk
+	MaxLines  int64          // Max lines per chunk
+	MaxTokens int            // Max estimated tokens per chunk
+	Counter   tokens.Counter // Token estimator, required with MaxTokens
+}
+
+// Enabled reports whether any chunk limit is set
+func (o ChunkOptions) Enabled() bool {
+	return o.MaxBytes > 0 || o.MaxLines > 0 || o.MaxTokens > 0
+}
+
+// ChunkManifest lists the chunks written and the files in each
+type ChunkManifest struct {
+	Tree   string      `json:"tree,omitempty"` // Chunk holding the directory tree, if any
+	Chunks []ChunkInfo `json:"chunks"`
+}
+
+// ChunkInfo describes a single chunk file
+type ChunkInfo struct {
+	File   string      `json:"file"`
+	Files  []ChunkFile `json:"files"`
+	Bytes  int64       `json:"bytes"`
+	Lines  int64       `json:"lines"`
+	Tokens int         `json:"tokens,omitempty"`
+}
+
+// ChunkFile is a file entry within a chunk. Files too large for a single
+// chunk are split, and each part is listed with its position.
+type ChunkFile struct {
+	Path  string `json:"path"`
+	Part  int    `json:"part,omitempty"`
+	Parts int    `json:"parts,omitempty"`
+}
+
+// chunkSize is the measured size of rendered output
+type chunkSize struct {
+	bytes  int64
+	lines  int64
+	tokens int64
+}
+
+// chunker writes entries to numbered chunk files, each a complete document
+// rendered by its own formatter
+type chunker struct {
+	opts      ChunkOptions
+	factory   func() (Formatter, error)
+	doc       Document
+	formatter Formatter
+	file      *os.File
+	current   *ChunkInfo
+	manifest  ChunkManifest
+	header    *chunkSize // Size of a document header, once measured
+	footer    *chunkSize // Size of a document footer, once measured
+	buf       bytes.Buffer
+}
+
+// newChunker creates a chunker; no file is created until the first write
+func newChunker(opts ChunkOptions, factory func() (Formatter, error)) *chunker {
+	return &chunker{opts: opts, factory: factory}
+}
+
+// chunkPath returns the path of chunk n: dump.md becomes dump.001.md
+func (c *chunker) chunkPath(n int) string {
+	ext := filepath.Ext(c.opts.Path)
+	return fmt.Sprintf("%s.%03d%s", strings.TrimSuffix(c.opts.Path, ext), n, ext)
+}
+
+// manifestPath returns the path of the manifest: dump.md becomes dump.manifest.json
+func (c *chunker) manifestPath() string {
+	return strings.TrimSuffix(c.opts.Path, filepath.Ext(c.opts.Path)) + ".manifest.json"
+}
+
+// open starts the next chunk with a fresh formatter
+func (c *chunker) open() error {
+	formatter, err := c.factory()
+	if err != nil {
+		return err
+	}
+	path := c.chunkPath(len(c.manifest.Chunks) + 1)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("printer: creating chunk: %w", err)
+	}
+
+	c.formatter, c.file = formatter, file
+	c.manifest.Chunks = append(c.manifest.Chunks, ChunkInfo{File: filepath.Base(path), Files: []ChunkFile{}})
+	c.current = &c.manifest.Chunks[len(c.manifest.Chunks)-1]
+
+	c.buf.Reset()
+	if err := c.formatter.Begin(&c.buf, c.doc); err != nil {
+		return err
+	}
+	return c.write(c.buf.Bytes())
+}
+
+// close ends the current chunk, if one is open
+func (c *chunker) close() error {
+	if c.file == nil {
+		return nil
+	}
+	c.buf.Reset()
+	err := c.formatter.End(&c.buf)
+	if err == nil {
+		err = c.write(c.buf.Bytes())
+	}
+	if cerr := c.file.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("printer: closing chunk: %w", cerr)
+	}
+	c.file, c.formatter = nil, nil
+	return err
+}
+
+// write appends rendered output to the current chunk and updates its size
+func (c *chunker) write(b []byte) error {
+	size := c.measure(b)
+	c.current.Bytes += size.bytes
+	c.current.Lines += size.lines
+	c.current.Tokens += int(size.tokens)
+	if _, err := c.file.Write(b); err != nil {
+		return fmt.Errorf("printer: writing chunk: %w", err)
+	}
+	return nil
+}
+
+// measure returns the size of b in the units that have limits
+func (c *chunker) measure(b []byte) chunkSize {
+	size := chunkSize{bytes: int64(len(b)), lines: int64(bytes.Count(b, []byte("\n")))}
+	if c.opts.MaxTokens > 0 {
+		size.tokens = int64(c.opts.Counter.Count(b))
+	}
+	return size
+}
+
+// fits reports whether output of the given size fits in the current chunk
+func (c *chunker) fits(size chunkSize) bool {
+	// The header is already part of the chunk; the footer is still to come
+	_, footer := c.framing()
+	used := chunkSize{c.current.Bytes, c.current.Lines, int64(c.current.Tokens)}
+	return within(c.add(c.add(used, size), footer), c.limits())
+}
+
+// measureEntry returns the rendered size of an entry. Formatters keep state
+// between entries (JSON writes a comma before all but the first), so it
+// renders with a fresh formatter, primed with a throwaway entry unless the
+// entry would be the first in its chunk. The chunk's own formatter is left
+// untouched.
+func (c *chunker) measureEntry(entry Entry, first bool) (chunkSize, error) {
+	probe, err := c.newProbe()
+	if err != nil {
+		return chunkSize{}, err
+	}
+	if !first {
+		if err := probe.WriteEntry(io.Discard, Entry{Index: entry.Index, Path: entry.Path}); err != nil {
+			return chunkSize{}, err
+		}
+	}
+	c.buf.Reset()
+	if err := probe.WriteEntry(&c.buf, entry); err != nil {
+		return chunkSize{}, err
+	}
+	return c.measure(c.buf.Bytes()), nil
+}
+
+// newProbe returns a fresh formatter that has begun a document, for
+// measuring output without disturbing the chunk's formatter
+func (c *chunker) newProbe() (Formatter, error) {
+	probe, err := c.factory()
+	if err != nil {
+		return nil, err
+	}
+	if err := probe.Begin(io.Discard, c.doc); err != nil {
+		return nil, err
+	}
+	return probe, nil
+}
+
+// writeEntry writes an entry to the current chunk, rolling over to a new
+// chunk if it does not fit. Entries too large for a chunk of their own are
+// split at line boundaries, one part per chunk.
+func (c *chunker) writeEntry(entry Entry) error {
+	size, err := c.measureEntry(entry, true)
+	if err != nil {
+		return err
+	}
+	if within(c.add(size, c.overhead()), c.limits()) {
+		return c.writeUnit(entry, ChunkFile{Path: entry.Path}, false)
+	}
+
+	parts, err := c.split(entry)
+	if err != nil {
+		return err
+	}
+	for i, part := range parts {
+		file := ChunkFile{Path: entry.Path}
+		if len(parts) > 1 {
+			file.Part, file.Parts = i+1, len(parts)
+		}
+		if err := c.writeUnit(part, file, true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// maxSplitAttempts bounds the rounds split takes to find content limits whose
+// parts all fit. Each round shrinks the limits in proportion to how far the
+// largest part overshot, so one or two rounds normally suffice; more are only
+// needed when rendering grows faster than the content (many short lines in
+// JSON with line numbers, say). If the bound is reached, the parts of the
+// last round are used and may exceed the limits slightly.
+const maxSplitAttempts = 8
+
+// split divides an entry at line boundaries into parts that each fit in a
+// chunk of their own once rendered, adding continuation markers. A single
+// line longer than a limit is kept whole.
+func (c *chunker) split(entry Entry) ([]Entry, error) {
+	// Each part starts a chunk of its own (unless the current chunk is still
+	// empty), so the neighbouring chunk names are known up front
+	first := len(c.manifest.Chunks) + 1
+	if c.file != nil && len(c.current.Files) == 0 {
+		first--
+	}
+
+	// Try progressively smaller content limits until every part fits, as
+	// rendering inflates the content (base64 in JSON, escaping in XML) and
+	// adds headings, fences and markers
+	limits := c.limits()
+	raw := c.measure(entry.Content)
+	rendered, err := c.measureEntry(entry, true)
+	if err != nil {
+		return nil, err
+	}
+	scaled := chunkSize{
+		bytes:  scaleLimit(limits.bytes, raw.bytes, rendered.bytes),
+		lines:  scaleLimit(limits.lines, raw.lines, rendered.lines),
+		tokens: scaleLimit(limits.tokens, raw.tokens, rendered.tokens),
+	}
+
+	var parts []Entry
+	for attempt := 0; attempt < maxSplitAttempts; attempt++ {
+		content := splitLines(entry.Content, scaled, c.measure)
+		parts = c.partEntries(entry, content, first)
+
+		// Find the largest part; parts of a single line cannot shrink further
+		var largest chunkSize
+		for i, part := range parts {
+			if countLines(content[i]) <= 1 {
+				continue
+			}
+			size, err := c.measureEntry(part, true)
+			if err != nil {
+				return nil, err
+			}
+			largest = chunkSize{max(largest.bytes, size.bytes), max(largest.lines, size.lines), max(largest.tokens, size.tokens)}
+		}
+		largest = c.add(largest, c.overhead())
+		if within(largest, limits) {
+			break
+		}
+		scaled = chunkSize{
+			bytes:  shrinkLimit(scaled.bytes, limits.bytes, largest.bytes),
+			lines:  shrinkLimit(scaled.lines, limits.lines, largest.lines),
+			tokens: shrinkLimit(scaled.tokens, limits.tokens, largest.tokens),
+		}
+	}
+	return parts, nil
+}
+
+// partEntries builds the entries for the parts of a split file, the first
+// going to chunk number first
+func (c *chunker) partEntries(entry Entry, parts [][]byte, first int) []Entry {
+	entries := make([]Entry, len(parts))
+	line := entry.firstLine()
+	for i, part := range parts {
+		var content bytes.Buffer
+		if i > 0 {
+			fmt.Fprintf(&content, "[%s continued from %s (part %d/%d)]\n",
+				entry.Path, filepath.Base(c.chunkPath(first+i-1)), i+1, len(parts))
+		}
+		content.Write(part)
+		if i < len(parts)-1 {
+			fmt.Fprintf(&content, "[%s continues in %s (part %d/%d)]\n",
+				entry.Path, filepath.Base(c.chunkPath(first+i+1)), i+1, len(parts))
+		}
+
+		entries[i] = entry
+		entries[i].Content = content.Bytes()
+		entries[i].StartLine = line
+		entries[i].continued = i > 0
+		entries[i].continues = i < len(parts)-1
+		line += countLines(part)
+		if i > 0 {
+			// The diff accompanies the first part only
+			entries[i].Diff = ""
+		}
+	}
+	return entries
+}
+
+// splitLines divides content at line boundaries into parts within limits
+func splitLines(content []byte, limits chunkSize, measure func([]byte) chunkSize) [][]byte {
+	var parts [][]byte
+	var size chunkSize
+	start := 0
+	for pos := 0; pos < len(content); {
+		end := bytes.IndexByte(content[pos:], '\n')
+		if end < 0 {
+			end = len(content)
+		} else {
+			end += pos + 1
+		}
+
+		line := measure(content[pos:end])
+		next := chunkSize{size.bytes + line.bytes, size.lines + line.lines, size.tokens + line.tokens}
+		if pos > start && !within(next, limits) {
+			parts = append(parts, content[start:pos])
+			start, next = pos, line
+		}
+		size = next
+		pos = end
+	}
+	return append(parts, content[start:])
+}
+
+// writeUnit writes a single entry, starting a new chunk when the current one
+// has entries and the entry does not fit after them, or when alone is set
+func (c *chunker) writeUnit(entry Entry, file ChunkFile, alone bool) error {
+	if c.file != nil && len(c.current.Files) > 0 {
+		rollover := alone
+		if !rollover {
+			size, err := c.measureEntry(entry, false)
+			if err != nil {
+				return err
+			}
+			rollover = !c.fits(size)
+		}
+		if rollover {
+			if err := c.close(); err != nil {
+				return err
+			}
+		}
+	}
+	if c.file == nil {
+		if err := c.open(); err != nil {
+			return err
+		}
+	}
+
+	var rendered bytes.Buffer
+	if err := c.formatter.WriteEntry(&rendered, entry); err != nil {
+		return err
+	}
+	c.current.Files = append(c.current.Files, file)
+	return c.write(rendered.Bytes())
+}
+
+// limits returns the configured limits; zero means unlimited
+func (c *chunker) limits() chunkSize {
+	return chunkSize{c.opts.MaxBytes, c.opts.MaxLines, int64(c.opts.MaxTokens)}
+}
+
+// add returns the sum of two sizes
+func (c *chunker) add(a, b chunkSize) chunkSize {
+	return chunkSize{a.bytes + b.bytes, a.lines + b.lines, a.tokens + b.tokens}
+}
+
+// framing returns the rendered sizes of a document header and footer,
+// measured once with a separate formatter
+func (c *chunker) framing() (header, footer chunkSize) {
+	if c.header == nil {
+		c.header, c.footer = &chunkSize{}, &chunkSize{}
+		formatter, err := c.factory()
+		if err != nil {
+			return *c.header, *c.footer
+		}
+		var buf bytes.Buffer
+		if formatter.Begin(&buf, c.doc) == nil {
+			*c.header = c.measure(buf.Bytes())
+		}
+		buf.Reset()
+		if formatter.End(&buf) == nil {
+			*c.footer = c.measure(buf.Bytes())
+		}
+	}
+	return *c.header, *c.footer
+}
+
+// overhead returns the combined size of a document header and footer
+func (c *chunker) overhead() chunkSize {
+	header, footer := c.framing()
+	return c.add(header, footer)
+}
+
+// shrinkLimit reduces a content limit in proportion to how far the largest
+// rendered part overshot its limit
+func shrinkLimit(scaled, limit, largest int64) int64 {
+	if limit <= 0 || largest <= limit {
+		return scaled
+	}
+	return max(scaled*limit/largest-1, 1)
+}
+
+// within reports whether size fits within limits; zero limits are unlimited
+func within(size, limits chunkSize) bool {
+	return (limits.bytes <= 0 || size.bytes <= limits.bytes) &&
+		(limits.lines <= 0 || size.lines <= limits.lines) &&
+		(limits.tokens <= 0 || size.tokens <= limits.tokens)
+}
+
+// scaleLimit converts a limit on rendered output into a limit on raw content
+func scaleLimit(limit, raw, rendered int64) int64 {
+	if limit <= 0 || rendered <= raw || rendered == 0 {
+		return limit
+	}
+	return max(limit*raw/rendered, 1)
+}
+
+// finish writes the skipped items and closes the last chunk (creating one if
+// nothing was written or the items do not fit), then writes the manifest
+func (c *chunker) finish(writeSkipped func(Formatter, *bytes.Buffer) error) error {
+	// The skipped items get a chunk of their own if they do not fit after
+	// the last entry
+	if c.file != nil && len(c.current.Files) > 0 {
+		probe, err := c.newProbe()
+		if err != nil {
+			return err
+		}
+		c.buf.Reset()
+		if err := writeSkipped(probe, &c.buf); err != nil {
+			return err
+		}
+		if !c.fits(c.measure(c.buf.Bytes())) {
+			if err := c.close(); err != nil {
+				return err
+			}
+		}
+	}
+	if c.file == nil {
+		if err := c.open(); err != nil {
+			return err
+		}
+	}
+
+	c.buf.Reset()
+	if err := writeSkipped(c.formatter, &c.buf); err != nil {
+		return err
+	}
+	if err := c.write(c.buf.Bytes()); err != nil {
+		return err
+	}
+	if err := c.close(); err != nil {
+		return err
+	}
+	return c.writeManifest()
+}
+
+// writeDocument writes a standalone document to chunk 0, used for the
+// directory tree since it is only known once all chunks are written
+func (c *chunker) writeDocument(render func(Formatter, *bytes.Buffer) error) error {
+	formatter, err := c.factory()
+	if err != nil {
+		return err
+	}
+
+	c.buf.Reset()
+	if err := formatter.Begin(&c.buf, c.doc); err != nil {
+		return err
+	}
+	if err := render(formatter, &c.buf); err != nil {
+		return err
+	}
+	if err := formatter.End(&c.buf); err != nil {
+		return err
+	}
+
+	path := c.chunkPath(0)
+	if err := os.WriteFile(path, c.buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("printer: writing tree chunk: %w", err)
+	}
+	c.manifest.Tree = filepath.Base(path)
+	return nil
+}
+
+// writeManifest writes the chunk manifest next to the chunks
+func (c *chunker) writeManifest() error {
+	data, err := json.MarshalIndent(c.manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("printer: marshaling manifest: %w", err)
+	}
+	if err := os.WriteFile(c.manifestPath(), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("printer: writing manifest: %w", err)
+	}
+	return nil
+}
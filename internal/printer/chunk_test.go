@@ -0,0 +1,155 @@
+package printer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeChunked prints entries into chunks of at most maxBytes and returns
+// the manifest
+func writeChunked(t *testing.T, format string, maxBytes int64, entries []Entry) (string, ChunkManifest) {
+	t.Helper()
+	opts := formatOptions(t)
+	factory := func() (Formatter, error) { return NewFormatter(format, opts) }
+	formatter, err := factory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	p := New().WithFormatter(formatter).WithChunks(ChunkOptions{Path: filepath.Join(dir, "dump.out"), MaxBytes: maxBytes}, factory)
+	for _, entry := range entries {
+		p.PrintEntry(entry)
+	}
+	p.PrintSkipped(nil)
+	p.Finalize()
+
+	data, err := os.ReadFile(filepath.Join(dir, "dump.manifest.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var manifest ChunkManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		t.Fatal(err)
+	}
+	return dir, manifest
+}
+
+// TestChunksWithinLimit checks for every format that chunks stay within the
+// byte limit and that the manifest records their actual size, so entries are
+// measured as they are written
+func TestChunksWithinLimit(t *testing.T) {
+	var entries []Entry
+	for i := 0; i < 60; i++ {
+		entries = append(entries, Entry{
+			Path:    fmt.Sprintf("f%02d.txt", i),
+			Content: []byte(strings.Repeat(fmt.Sprintf("file %d <&> line\n", i), 1+i%5)),
+		})
+	}
+
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			for _, limit := range []int64{700, 1000, 1500} {
+				dir, manifest := writeChunked(t, format, limit, entries)
+				if len(manifest.Chunks) < 2 {
+					t.Fatalf("limit %d: want several chunks, got %d", limit, len(manifest.Chunks))
+				}
+				files := 0
+				for _, chunk := range manifest.Chunks {
+					info, err := os.Stat(filepath.Join(dir, chunk.File))
+					if err != nil {
+						t.Fatal(err)
+					}
+					if info.Size() != chunk.Bytes {
+						t.Errorf("limit %d: %s is %d bytes, manifest says %d", limit, chunk.File, info.Size(), chunk.Bytes)
+					}
+					if chunk.Bytes > limit && len(chunk.Files) > 1 {
+						t.Errorf("limit %d: %s is %d bytes with %d files", limit, chunk.File, chunk.Bytes, len(chunk.Files))
+					}
+					files += len(chunk.Files)
+				}
+				if files != len(entries) {
+					t.Errorf("limit %d: manifest lists %d files, want %d", limit, files, len(entries))
+				}
+			}
+		})
+	}
+}
+
+// TestChunksSplitLargeFile checks that a file too large for one chunk is
+// split into parts that each fit, keeping every line
+func TestChunksSplitLargeFile(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < 400; i++ {
+		fmt.Fprintf(&content, "line %03d of a large file\n", i)
+	}
+
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			dir, manifest := writeChunked(t, format, 2000, []Entry{{Path: "large.txt", Content: []byte(content.String())}})
+			if len(manifest.Chunks) < 2 {
+				t.Fatalf("want several chunks, got %d", len(manifest.Chunks))
+			}
+			var all strings.Builder
+			for i, chunk := range manifest.Chunks {
+				if chunk.Bytes > 2000 {
+					t.Errorf("%s is %d bytes", chunk.File, chunk.Bytes)
+				}
+				if len(chunk.Files) != 1 || chunk.Files[0].Part != i+1 || chunk.Files[0].Parts != len(manifest.Chunks) {
+					t.Errorf("%s lists %+v", chunk.File, chunk.Files)
+				}
+				data, err := os.ReadFile(filepath.Join(dir, chunk.File))
+				if err != nil {
+					t.Fatal(err)
+				}
+				all.Write(data)
+			}
+			if format == "json" {
+				return // Content is base64 encoded
+			}
+			for i := 0; i < 400; i++ {
+				if !strings.Contains(all.String(), fmt.Sprintf("line %03d of", i)) {
+					t.Fatalf("line %d missing from the chunks", i)
+				}
+			}
+		})
+	}
+}
+
+// TestMeasureEntryMatchesOutput checks that measured sizes equal what the
+// chunk's formatter writes, for the first entry of a chunk and for later ones
+func TestMeasureEntryMatchesOutput(t *testing.T) {
+	opts := formatOptions(t)
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			factory := func() (Formatter, error) { return NewFormatter(format, opts) }
+			c := newChunker(ChunkOptions{MaxBytes: 1 << 20}, factory)
+			live, err := factory()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out strings.Builder
+			if err := live.Begin(&out, c.doc); err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 1; i <= 3; i++ {
+				entry := Entry{Index: i, Path: fmt.Sprintf("f%d.txt", i), Content: []byte("a <b> & c\n")}
+				measured, err := c.measureEntry(entry, i == 1)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out.Reset()
+				if err := live.WriteEntry(&out, entry); err != nil {
+					t.Fatal(err)
+				}
+				if written := c.measure([]byte(out.String())); measured != written {
+					t.Errorf("entry %d: measured %+v, written %+v", i, measured, written)
+				}
+			}
+		})
+	}
+}
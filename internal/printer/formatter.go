@@ -0,0 +1,137 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// Document describes the dump as a whole, passed to Formatter.Begin
+type Document struct {
+	Root string // Absolute path of the scanned root directory
+}
+
+// Entry is a single file to be written by a Formatter
+type Entry struct {
+	Index   int              // 1-based position of the entry in the document
+	Path    string           // Path relative to the root
+	Content []byte           // File content
+	Diff    string           // Unified diff against a base version, if any
+	Meta    *walker.FileMeta // File metadata, if known (may be nil)
+
+	// StartLine is the line number of the first line of Content, for parts
+	// of a file split across chunks (0 means 1)
+	StartLine int
+
+	// Reader, if set, yields the content instead of Content. Formatters that
+	// implement StreamFormatter read it as they write the entry; for others,
+	// the Printer reads it into Content first.
+	Reader io.Reader
+
+	continued bool // Content starts with a continuation marker line
+	continues bool // Content ends with a continuation marker line
+}
+
+// Formatter renders a dump document. The Printer serializes all calls and
+// buffers each entry, so implementations need not be safe for concurrent use.
+type Formatter interface {
+	// Begin writes anything that precedes the first entry
+	Begin(w io.Writer, doc Document) error
+	// WriteEntry writes a single file entry
+	WriteEntry(w io.Writer, entry Entry) error
+	// WriteTree writes the directory tree; with a tree enabled, the Printer
+	// places its output between Begin's output and the first entry
+	WriteTree(w io.Writer, root *tree.Node) error
+	// WriteSkipped writes the list of skipped items, if the format includes one
+	WriteSkipped(w io.Writer, items []walker.SkippedItem) error
+	// End writes anything that follows the last entry
+	End(w io.Writer) error
+}
+
+// StreamFormatter is implemented by formatters that can write an entry's
+// content as they read it from Entry.Reader, without holding it in memory
+type StreamFormatter interface {
+	Formatter
+	// WriteStream writes a single file entry whose content is read from
+	// entry.Reader
+	WriteStream(w io.Writer, entry Entry) error
+}
+
+// TotalsFormatter is implemented by formatters whose summary reports totals
+// for the whole document. The Printer calls SetTotals before WriteSkipped,
+// since with chunks each formatter only sees the entries of its own chunk.
+type TotalsFormatter interface {
+	Formatter
+	SetTotals(totals Totals)
+}
+
+// Totals counts the entries written to a document
+type Totals struct {
+	Files int   // Entries written
+	Bytes int64 // Content bytes of those entries, before rendering
+}
+
+// FormatOptions holds settings for all formatters; each formatter reads the
+// fields that apply to it
+type FormatOptions struct {
+	UseColors   bool            // Whether terminal colors may be used
+	Markdown    MarkdownOptions // Settings for the markdown format
+	XML         XMLOptions      // Settings for the xml format
+	Template    TemplateOptions // Settings for the template format
+	Meta        MetaFields      // File metadata fields to include, where the format has room for them
+	LineNumbers bool            // Prefix content lines with their numbers
+}
+
+// FormatterFactory creates a new Formatter for a single document, or reports
+// why the options are invalid for it
+type FormatterFactory func(opts FormatOptions) (Formatter, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]FormatterFactory)
+)
+
+// Register makes a formatter available under name. It panics if the name is
+// empty or already registered, as registration happens during init.
+func Register(name string, factory FormatterFactory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if name == "" || factory == nil {
+		panic("printer: Register called with empty name or nil factory")
+	}
+	if _, exists := registry[name]; exists {
+		panic(fmt.Sprintf("printer: formatter %q registered twice", name))
+	}
+	registry[name] = factory
+}
+
+// NewFormatter creates the formatter registered under name
+func NewFormatter(name string, opts FormatOptions) (Formatter, error) {
+	registryMu.RLock()
+	factory, ok := registry[strings.ToLower(name)]
+	registryMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("printer: unknown format %q (available: %s)", name, strings.Join(Formats(), ", "))
+	}
+	return factory(opts)
+}
+
+// Formats returns the names of all registered formatters, sorted
+func Formats() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
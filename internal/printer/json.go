@@ -0,0 +1,209 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func init() {
+	Register("json", func(opts FormatOptions) (Formatter, error) {
+		return &jsonFormatter{meta: opts.Meta, lineNumbers: opts.LineNumbers}, nil
+	})
+}
+
+// JSONFileEntry represents a file entry in JSON output
+type JSONFileEntry struct {
+	Path     string      `json:"path"`
+	Content  interface{} `json:"content"`        // Base64 encoded content, or []NumberedLine with line numbers
+	Diff     string      `json:"diff,omitempty"` // Unified diff against the base version, if any
+	Size     *int64      `json:"size,omitempty"` // Metadata fields are only set when selected
+	Mode     string      `json:"mode,omitempty"`
+	ModTime  *time.Time  `json:"mtime,omitempty"`
+	Lines    *int        `json:"lines,omitempty"`
+	Language string      `json:"language,omitempty"`
+	SHA256   string      `json:"sha256,omitempty"`
+	// Truncated is the number of lines left out by a line limit, always set
+	// when the file was truncated
+	Truncated int `json:"truncated,omitempty"`
+}
+
+// jsonFormatter writes the dump as a single JSON array of JSONFileEntry
+type jsonFormatter struct {
+	meta        MetaFields
+	lineNumbers bool
+	started     bool // Whether an entry has been written, so the next needs a comma
+}
+
+// Begin implements Formatter by opening the JSON array
+func (f *jsonFormatter) Begin(w io.Writer, doc Document) error {
+	_, err := fmt.Fprint(w, "[")
+	return err
+}
+
+// WriteEntry implements Formatter
+func (f *jsonFormatter) WriteEntry(w io.Writer, entry Entry) error {
+	var content interface{} = base64.StdEncoding.EncodeToString(entry.Content)
+	if f.lineNumbers {
+		content = numberedLines(entry)
+	}
+	jsonData, err := f.marshal(entry, content)
+	if err != nil {
+		return err
+	}
+	f.writeSeparator(w)
+	_, err = fmt.Fprintf(w, "\n  %s", jsonData)
+	return err
+}
+
+// contentSentinel stands in for streamed content while the rest of the
+// record is marshaled; paths cannot contain NUL, so it only appears once
+const contentSentinel = "\x00"
+
+// WriteStream implements StreamFormatter. The record is marshaled around a
+// placeholder, and the content streamed in its place.
+func (f *jsonFormatter) WriteStream(w io.Writer, entry Entry) error {
+	jsonData, err := f.marshal(entry, contentSentinel)
+	if err != nil {
+		return err
+	}
+	before, after, _ := bytes.Cut(jsonData, []byte(`"\u0000"`))
+	f.writeSeparator(w)
+	fmt.Fprintf(w, "\n  %s", before)
+
+	if f.lineNumbers {
+		err = writeNumberedLines(w, entry)
+	} else {
+		fmt.Fprint(w, `"`)
+		enc := base64.NewEncoder(base64.StdEncoding, w)
+		if _, err = io.Copy(enc, entry.Reader); err == nil {
+			err = enc.Close()
+		}
+		fmt.Fprint(w, `"`)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(after)
+	return err
+}
+
+// writeNumberedLines streams the entry's content as the array of numbered
+// lines marshaled by WriteEntry
+func writeNumberedLines(w io.Writer, entry Entry) error {
+	count := 0
+	var writeErr error
+	err := eachStreamLine(entry, entry.Reader, func(n int, line, eol []byte) {
+		if writeErr != nil {
+			return
+		}
+		var data []byte
+		data, writeErr = json.MarshalIndent(NumberedLine{N: n, Text: string(line)}, "      ", "  ")
+		if writeErr != nil {
+			return
+		}
+		sep := ","
+		if count == 0 {
+			sep = "["
+		}
+		count++
+		_, writeErr = fmt.Fprintf(w, "%s\n      %s", sep, data)
+	})
+	if err != nil {
+		return err
+	}
+	if writeErr != nil {
+		return writeErr
+	}
+	if count == 0 {
+		_, err = fmt.Fprint(w, "[]")
+	} else {
+		_, err = fmt.Fprint(w, "\n    ]")
+	}
+	return err
+}
+
+// marshal renders the entry's record with the given content
+func (f *jsonFormatter) marshal(entry Entry, content interface{}) ([]byte, error) {
+	record := JSONFileEntry{
+		Path:    entry.Path,
+		Content: content,
+		Diff:    entry.Diff,
+	}
+	if meta := entry.Meta; meta != nil {
+		if f.meta.Has(MetaSize) {
+			record.Size = &meta.Size
+		}
+		if f.meta.Has(MetaMode) {
+			record.Mode = meta.Mode.String()
+		}
+		if f.meta.Has(MetaModTime) {
+			record.ModTime = &meta.ModTime
+		}
+		if f.meta.Has(MetaLines) {
+			record.Lines = &meta.Lines
+		}
+		if f.meta.Has(MetaLanguage) {
+			record.Language = meta.Language
+		}
+		if f.meta.Has(MetaSHA256) {
+			record.SHA256 = meta.SHA256
+		}
+		record.Truncated = meta.Truncation.Omitted
+	}
+
+	jsonData, err := json.MarshalIndent(record, "  ", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("printer: marshaling JSON entry: %w", err)
+	}
+	return jsonData, nil
+}
+
+// writeSeparator adds a comma between entries
+func (f *jsonFormatter) writeSeparator(w io.Writer) {
+	if f.started {
+		fmt.Fprint(w, ",")
+	}
+	f.started = true
+}
+
+// JSONTreeEntry is the array element holding the directory tree
+type JSONTreeEntry struct {
+	Tree *tree.Node `json:"tree"`
+}
+
+// WriteTree implements Formatter by writing the tree as the first element
+// of the array
+func (f *jsonFormatter) WriteTree(w io.Writer, root *tree.Node) error {
+	jsonData, err := json.MarshalIndent(JSONTreeEntry{Tree: root}, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("printer: marshaling JSON tree: %w", err)
+	}
+
+	fmt.Fprintf(w, "\n  %s", jsonData)
+	// Entries written so far were rendered without a leading comma, as
+	// they expected to come first
+	if f.started {
+		fmt.Fprint(w, ",")
+	}
+	f.started = true
+	return nil
+}
+
+// WriteSkipped implements Formatter; the JSON document is a plain array of
+// files, so skipped items are left to the summary
+func (f *jsonFormatter) WriteSkipped(w io.Writer, items []walker.SkippedItem) error {
+	return nil
+}
+
+// End implements Formatter by closing the JSON array
+func (f *jsonFormatter) End(w io.Writer) error {
+	_, err := fmt.Fprint(w, "\n]\n")
+	return err
+}
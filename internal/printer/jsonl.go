@@ -0,0 +1,204 @@
+package printer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func init() {
+	Register("jsonl", func(opts FormatOptions) (Formatter, error) {
+		return &jsonlFormatter{meta: opts.Meta, lineNumbers: opts.LineNumbers}, nil
+	})
+}
+
+// JSONLFileRecord is one line of JSONL output describing a single file. Size,
+// lines and hash are always present; mode, mtime and language only when
+// selected with the metadata fields.
+type JSONLFileRecord struct {
+	Type      string     `json:"type"` // Always "file"
+	Path      string     `json:"path"`
+	Content   string     `json:"content"`
+	Encoding  string     `json:"encoding,omitempty"`   // "base64" when content is not valid UTF-8
+	StartLine int        `json:"start_line,omitempty"` // With line numbers, the number of the first numbered line
+	Size      int64      `json:"size"`
+	Lines     int        `json:"lines"`
+	Mode      string     `json:"mode,omitempty"`
+	ModTime   *time.Time `json:"mtime,omitempty"`
+	Language  string     `json:"language,omitempty"`
+	SHA256    string     `json:"sha256"`
+	Truncated int        `json:"truncated,omitempty"` // Lines left out by a line limit, if the file was truncated
+	Diff      string     `json:"diff,omitempty"`      // Unified diff against the base version, if any
+}
+
+// JSONLTreeRecord holds the directory tree
+type JSONLTreeRecord struct {
+	Type string     `json:"type"` // Always "tree"
+	Tree *tree.Node `json:"tree"`
+}
+
+// JSONLSummaryRecord is the final line of JSONL output. Its totals cover the
+// whole document, including other chunks when the output is split.
+type JSONLSummaryRecord struct {
+	Type    string               `json:"type"` // Always "summary"
+	Files   int                  `json:"files"`
+	Bytes   int64                `json:"bytes"` // Content bytes of the files written
+	Skipped []walker.SkippedItem `json:"skipped"`
+}
+
+// jsonlFormatter writes one JSON object per line, so dumps can be processed
+// as they are produced
+type jsonlFormatter struct {
+	meta        MetaFields
+	lineNumbers bool
+	totals      Totals
+}
+
+// Begin implements Formatter; JSON Lines has no document header
+func (f *jsonlFormatter) Begin(w io.Writer, doc Document) error {
+	return nil
+}
+
+// WriteEntry implements Formatter
+func (f *jsonlFormatter) WriteEntry(w io.Writer, entry Entry) error {
+	sum := sha256.Sum256(entry.Content)
+	record := f.record(entry, int64(len(entry.Content)), countLines(entry.Content), hex.EncodeToString(sum[:]))
+	if utf8.Valid(entry.Content) {
+		record.Content = string(entry.Content)
+	} else {
+		record.Content = base64.StdEncoding.EncodeToString(entry.Content)
+		record.Encoding = "base64"
+	}
+	return writeJSONLine(w, record)
+}
+
+// WriteStream implements StreamFormatter. The content is read ahead once to
+// choose its encoding, then streamed into the record in place of a
+// placeholder.
+func (f *jsonlFormatter) WriteStream(w io.Writer, entry Entry) error {
+	scan, r, err := scanContent(entry.Reader)
+	if err != nil {
+		return err
+	}
+	record := f.record(entry, scan.size, scan.lines, scan.sha256)
+	record.Content = contentSentinel
+	if !scan.validUTF8 {
+		record.Encoding = "base64"
+	}
+
+	var line bytes.Buffer
+	if err := writeJSONLine(&line, record); err != nil {
+		return err
+	}
+	before, after, _ := bytes.Cut(line.Bytes(), []byte(`"\u0000"`))
+	w.Write(before)
+	fmt.Fprint(w, `"`)
+	if scan.validUTF8 {
+		sw, flush := newJSONStringWriter(w, false)
+		if _, err = io.Copy(sw, r); err == nil {
+			err = flush()
+		}
+	} else {
+		enc := base64.NewEncoder(base64.StdEncoding, w)
+		if _, err = io.Copy(enc, r); err == nil {
+			err = enc.Close()
+		}
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(w, `"`)
+	_, err = w.Write(after)
+	return err
+}
+
+// record describes the entry, whose content has the given size, lines and
+// hash, without its content
+func (f *jsonlFormatter) record(entry Entry, size int64, lines int, sha string) JSONLFileRecord {
+	record := JSONLFileRecord{
+		Type:   "file",
+		Path:   entry.Path,
+		Size:   size,
+		Lines:  lines,
+		SHA256: sha,
+		Diff:   entry.Diff,
+	}
+	if f.lineNumbers {
+		record.StartLine = entry.firstNumber()
+	}
+	if meta := entry.Meta; meta != nil {
+		// Describe the file as stored rather than as written
+		record.Size = meta.Size
+		record.Lines = meta.Lines
+		record.SHA256 = meta.SHA256
+		record.Truncated = meta.Truncation.Omitted
+		if f.meta.Has(MetaMode) {
+			record.Mode = meta.Mode.String()
+		}
+		if f.meta.Has(MetaModTime) {
+			record.ModTime = &meta.ModTime
+		}
+		if f.meta.Has(MetaLanguage) {
+			record.Language = meta.Language
+		}
+	}
+	return record
+}
+
+// WriteTree implements Formatter
+func (f *jsonlFormatter) WriteTree(w io.Writer, root *tree.Node) error {
+	return writeJSONLine(w, JSONLTreeRecord{Type: "tree", Tree: root})
+}
+
+// SetTotals implements TotalsFormatter
+func (f *jsonlFormatter) SetTotals(totals Totals) {
+	f.totals = totals
+}
+
+// WriteSkipped implements Formatter by writing the summary record
+func (f *jsonlFormatter) WriteSkipped(w io.Writer, items []walker.SkippedItem) error {
+	// Sort a copy for consistent output
+	skipped := append([]walker.SkippedItem{}, items...)
+	sort.Slice(skipped, func(i, j int) bool {
+		return skipped[i].Path < skipped[j].Path
+	})
+
+	return writeJSONLine(w, JSONLSummaryRecord{
+		Type:    "summary",
+		Files:   f.totals.Files,
+		Bytes:   f.totals.Bytes,
+		Skipped: skipped,
+	})
+}
+
+// End implements Formatter
+func (f *jsonlFormatter) End(w io.Writer) error {
+	return nil
+}
+
+// writeJSONLine encodes v as a single line, leaving HTML characters unescaped
+func writeJSONLine(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	return enc.Encode(v)
+}
+
+// countLines returns the number of lines in content; a final line without a
+// trailing newline still counts
+func countLines(content []byte) int {
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return lines
+}
@@ -0,0 +1,108 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// jsonlRecords decodes every line of JSONL output
+func jsonlRecords(t *testing.T, out []byte) []map[string]interface{} {
+	t.Helper()
+	var records []map[string]interface{}
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line is not valid JSON: %v\n%s", err, line)
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func TestJSONLMetaFields(t *testing.T) {
+	meta := &walker.FileMeta{
+		Size: 6, Mode: 0o644, ModTime: time.Unix(0, 0).UTC(),
+		Lines: 1, Language: "go", SHA256: "abc",
+	}
+	tests := []struct {
+		spec    string
+		present []string
+		absent  []string
+	}{
+		{"", []string{"size", "lines", "sha256"}, []string{"mode", "mtime", "language"}},
+		{"mode,language", []string{"size", "lines", "sha256", "mode", "language"}, []string{"mtime"}},
+		{"all", []string{"size", "lines", "sha256", "mode", "mtime", "language"}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			fields, err := ParseMetaFields(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			formatter, err := NewFormatter("jsonl", FormatOptions{Meta: fields})
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			p := New().WithOutput(&out).WithFormatter(formatter)
+			p.PrintEntry(Entry{Path: "a.go", Content: []byte("hello\n"), Meta: meta})
+			p.Finalize()
+
+			record := jsonlRecords(t, out.Bytes())[0]
+			for _, key := range tt.present {
+				if _, ok := record[key]; !ok {
+					t.Errorf("record is missing %q: %v", key, record)
+				}
+			}
+			for _, key := range tt.absent {
+				if _, ok := record[key]; ok {
+					t.Errorf("record has unselected %q: %v", key, record)
+				}
+			}
+		})
+	}
+}
+
+// TestJSONLChunkedSummary checks that the summary in the last chunk counts
+// the files of every chunk
+func TestJSONLChunkedSummary(t *testing.T) {
+	factory := func() (Formatter, error) { return NewFormatter("jsonl", FormatOptions{}) }
+	formatter, err := factory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "dump.jsonl")
+	p := New().WithFormatter(formatter).WithChunks(ChunkOptions{Path: path, MaxLines: 3}, factory)
+
+	const files = 10
+	var total int64
+	for i := 0; i < files; i++ {
+		content := []byte(fmt.Sprintf("file %d\n", i))
+		total += int64(len(content))
+		p.PrintEntry(Entry{Path: fmt.Sprintf("f%d.txt", i), Content: content})
+	}
+	p.PrintSkipped([]walker.SkippedItem{{Path: "big.bin", Reason: walker.ReasonIgnoredRule}})
+	p.Finalize()
+
+	chunks, _ := filepath.Glob(filepath.Join(filepath.Dir(path), "dump.*.jsonl"))
+	if len(chunks) < 2 {
+		t.Fatalf("want several chunks, got %v", chunks)
+	}
+	last, err := os.ReadFile(chunks[len(chunks)-1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	records := jsonlRecords(t, last)
+	summary := records[len(records)-1]
+	if summary["type"] != "summary" || summary["files"] != float64(files) || summary["bytes"] != float64(total) {
+		t.Errorf("summary = %v, want %d files and %d bytes", summary, files, total)
+	}
+}
@@ -0,0 +1,215 @@
+package printer
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strconv"
+)
+
+// NumberedLine is a line of content with its number, as written by the json
+// format with line numbers
+type NumberedLine struct {
+	N    int    `json:"n,omitempty"` // Line number (omitted for continuation markers)
+	Text string `json:"text"`        // The line without its line ending
+}
+
+// firstLine returns the position of the entry's first content line among the
+// lines of the file's content
+func (e Entry) firstLine() int {
+	if e.StartLine > 0 {
+		return e.StartLine
+	}
+	return 1
+}
+
+// lineNumber returns the line number in the file of the content line at
+// position p, or 0 for the marker standing in for the lines left out of a
+// truncated file. Lines of content rewritten by a transform or by stripping
+// comments keep the numbers of the lines they come from.
+func (e Entry) lineNumber(p int) int {
+	if e.Meta == nil {
+		return p
+	}
+	if e.Meta.Truncation.Omitted == 0 {
+		return e.Meta.SourceLine(p)
+	}
+	t := e.Meta.Truncation
+	switch {
+	case p <= t.Head:
+		return p
+	case p == t.Head+1:
+		return 0
+	}
+	return p + t.Omitted - 1
+}
+
+// firstNumber returns the number of the entry's first numbered line, the one
+// after the elision marker if the entry starts with it
+func (e Entry) firstNumber() int {
+	if n := e.lineNumber(e.firstLine()); n > 0 {
+		return n
+	}
+	return e.lineNumber(e.firstLine() + 1)
+}
+
+// eachLine calls fn for every line of the entry's content with its number,
+// the line without its ending and the ending itself ("\n", "\r\n" or "" for
+// a final line without one). Continuation markers added when a file is split
+// across chunks, and the elision marker of a truncated file, are passed with
+// number 0.
+func eachLine(entry Entry, fn func(n int, line, eol []byte)) {
+	content := entry.Content
+	total := countLines(content)
+	p := entry.firstLine()
+
+	for i := 0; len(content) > 0; i++ {
+		end := bytes.IndexByte(content, '\n') + 1
+		if end == 0 {
+			end = len(content)
+		}
+		line := content[:end]
+		content = content[end:]
+
+		eolStart := len(line)
+		if bytes.HasSuffix(line, []byte("\r\n")) {
+			eolStart -= 2
+		} else if bytes.HasSuffix(line, []byte("\n")) {
+			eolStart--
+		}
+
+		if (i == 0 && entry.continued) || (i == total-1 && entry.continues) {
+			fn(0, line[:eolStart], line[eolStart:])
+			continue
+		}
+		fn(entry.lineNumber(p), line[:eolStart], line[eolStart:])
+		p++
+	}
+}
+
+// lastLine returns the number of the entry's last numbered line
+func (e Entry) lastLine() int {
+	return e.lastNumber(countLines(e.Content))
+}
+
+// lastNumber returns the number of the last numbered line of the entry if
+// its content has the given number of lines
+func (e Entry) lastNumber(lines int) int {
+	if e.continued {
+		lines--
+	}
+	if e.continues {
+		lines--
+	}
+	last := e.firstLine() + max(lines, 1) - 1
+	if n := e.lineNumber(last); n > 0 {
+		return n
+	}
+	return max(last-1, 1) // The elision marker ends the content
+}
+
+// writeNumbered writes the entry's content with each line prefixed by its
+// right-aligned number. The width is that of the largest number in the entry;
+// line endings are kept as they are.
+func writeNumbered(w io.Writer, entry Entry) error {
+	g := newGutterWriter(w, entry.lastLine())
+	eachLine(entry, g.line)
+	return g.err
+}
+
+// writeNumberedStream is writeNumbered for content read from r, which has
+// the given number of lines
+func writeNumberedStream(w io.Writer, entry Entry, r io.Reader, lines int) error {
+	g := newGutterWriter(w, entry.lastNumber(lines))
+	if err := eachStreamLine(entry, r, g.line); err != nil {
+		return err
+	}
+	return g.err
+}
+
+// eachStreamLine is eachLine for content read from r, holding one line in
+// memory at a time
+func eachStreamLine(entry Entry, r io.Reader, fn func(n int, line, eol []byte)) error {
+	br := bufio.NewReaderSize(r, streamBlockSize)
+	p := entry.firstLine()
+	for {
+		line, err := br.ReadBytes('\n')
+		if len(line) > 0 {
+			eolStart := len(line)
+			if bytes.HasSuffix(line, []byte("\r\n")) {
+				eolStart -= 2
+			} else if bytes.HasSuffix(line, []byte("\n")) {
+				eolStart--
+			}
+			fn(entry.lineNumber(p), line[:eolStart], line[eolStart:])
+			p++
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// gutterWriter writes lines prefixed with their right-aligned numbers
+type gutterWriter struct {
+	w      io.Writer
+	width  int
+	gutter []byte
+	err    error // First write error; later lines are dropped
+}
+
+// newGutterWriter creates a gutterWriter as wide as the number last
+func newGutterWriter(w io.Writer, last int) *gutterWriter {
+	width := len(strconv.Itoa(last))
+	return &gutterWriter{w: w, width: width, gutter: make([]byte, 0, width+3)}
+}
+
+// line writes a single line; continuation and elision markers (n = 0) get
+// an empty gutter
+func (g *gutterWriter) line(n int, line, eol []byte) {
+	if g.err != nil {
+		return
+	}
+	g.gutter = g.gutter[:0]
+	number := ""
+	if n > 0 {
+		number = strconv.Itoa(n)
+	}
+	for i := len(number); i < g.width; i++ {
+		g.gutter = append(g.gutter, ' ')
+	}
+	g.gutter = append(g.gutter, number...)
+	if len(line) > 0 {
+		g.gutter = append(g.gutter, " | "...)
+	} else {
+		g.gutter = append(g.gutter, " |"...) // No trailing space on empty lines
+	}
+
+	if _, g.err = g.w.Write(g.gutter); g.err == nil {
+		if _, g.err = g.w.Write(line); g.err == nil {
+			_, g.err = g.w.Write(eol)
+		}
+	}
+}
+
+// numbered returns the entry's content with line numbers, for formats that
+// need the whole text at once
+func numbered(entry Entry) []byte {
+	var buf bytes.Buffer
+	width := len(strconv.Itoa(entry.lastLine()))
+	buf.Grow(len(entry.Content) + countLines(entry.Content)*(width+3))
+	writeNumbered(&buf, entry)
+	return buf.Bytes()
+}
+
+// numberedLines returns the entry's content as a list of numbered lines
+func numberedLines(entry Entry) []NumberedLine {
+	lines := make([]NumberedLine, 0, countLines(entry.Content))
+	eachLine(entry, func(n int, line, eol []byte) {
+		lines = append(lines, NumberedLine{N: n, Text: string(line)})
+	})
+	return lines
+}
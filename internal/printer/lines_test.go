@@ -0,0 +1,43 @@
+package printer
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// TestLineNumbersFollowSourceLines checks that content rewritten by a
+// transform is numbered with the lines of the file it comes from
+func TestLineNumbersFollowSourceLines(t *testing.T) {
+	entry := Entry{
+		Path:    "a.go",
+		Content: []byte("package a\n\nfunc F()\n"),
+		Meta:    &walker.FileMeta{Lines: 12, SourceLines: []int{2, 3, 10}},
+	}
+
+	formatter, err := NewFormatter("text", FormatOptions{LineNumbers: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	if err := formatter.WriteEntry(&out, entry); err != nil {
+		t.Fatal(err)
+	}
+	if want := " 2 | package a\n 3 |\n10 | func F()\n"; !strings.Contains(out.String(), want) {
+		t.Errorf("text output is missing\n%s\ngot\n%s", want, out.String())
+	}
+
+	var numbers []int
+	for _, line := range numberedLines(entry) {
+		numbers = append(numbers, line.N)
+	}
+	if !reflect.DeepEqual(numbers, entry.Meta.SourceLines) {
+		t.Errorf("json line numbers = %v, want %v", numbers, entry.Meta.SourceLines)
+	}
+	if n := entry.firstNumber(); n != 2 {
+		t.Errorf("first line number = %d, want 2", n)
+	}
+}
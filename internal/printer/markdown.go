@@ -0,0 +1,240 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"unicode"
+
+	"github.com/bethropolis/dir-dumper/internal/language"
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func init() {
+	Register("markdown", func(opts FormatOptions) (Formatter, error) {
+		return &markdownFormatter{
+			opts:        opts.Markdown,
+			meta:        opts.Meta,
+			lineNumbers: opts.LineNumbers,
+			anchors:     make(map[string]int),
+		}, nil
+	})
+}
+
+// MarkdownOptions configures the markdown format
+type MarkdownOptions struct {
+	TOC bool // Emit a table of contents linking to each file
+}
+
+// markdownFormatter writes each file under a heading as a fenced code block
+// tagged with its detected language
+type markdownFormatter struct {
+	opts        MarkdownOptions
+	meta        MetaFields
+	lineNumbers bool
+	title       string
+	anchors     map[string]int // Anchor slugs already used, for de-duplication
+
+	// With a table of contents, the document can only be written once all
+	// files are known, so entries are collected here until End
+	toc      []tocEntry
+	treeText string
+	body     bytes.Buffer
+}
+
+// tocEntry is a single table of contents line
+type tocEntry struct {
+	path   string
+	anchor string
+}
+
+// Begin implements Formatter by writing the document title
+func (f *markdownFormatter) Begin(w io.Writer, doc Document) error {
+	f.title = "Directory Dump"
+	if doc.Root != "" {
+		f.title = filepath.Base(doc.Root)
+	}
+	if f.opts.TOC {
+		// The title and contents headings claim their anchors first
+		f.anchor(f.title)
+		f.anchor("Tree")
+		f.anchor("Contents")
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "# %s\n\n", inlineCode(f.title))
+	return err
+}
+
+// WriteEntry implements Formatter
+func (f *markdownFormatter) WriteEntry(w io.Writer, entry Entry) error {
+	w = f.writeHeading(w, entry)
+	content := entry.Content
+	if f.lineNumbers {
+		content = numbered(entry)
+	}
+	writeFenced(w, language.Detect(entry.Path, entry.Content), content)
+	f.writeDiff(w, entry)
+	return nil
+}
+
+// WriteStream implements StreamFormatter. The content is read ahead once to
+// find the longest backtick run, which sets the fence length.
+func (f *markdownFormatter) WriteStream(w io.Writer, entry Entry) error {
+	scan, r, err := scanContent(entry.Reader)
+	if err != nil {
+		return err
+	}
+	w = f.writeHeading(w, entry)
+
+	// Line numbers only add digits, spaces and pipes, so the fence still fits
+	fence := strings.Repeat("`", max(3, scan.backticks+1))
+	fmt.Fprintf(w, "%s%s\n", fence, language.Detect(entry.Path, scan.head))
+	lw := &lastByteWriter{w: w}
+	if err := copyContent(lw, entry, r, f.lineNumbers, scan.lines); err != nil {
+		return err
+	}
+	if !lw.endsLine() {
+		fmt.Fprint(w, "\n")
+	}
+	fmt.Fprintf(w, "%s\n\n", fence)
+	f.writeDiff(w, entry)
+	return nil
+}
+
+// writeHeading writes the entry's heading and metadata line, returning the
+// writer the rest of the entry goes to
+func (f *markdownFormatter) writeHeading(w io.Writer, entry Entry) io.Writer {
+	if f.opts.TOC {
+		f.toc = append(f.toc, tocEntry{path: entry.Path, anchor: f.anchor(entry.Path)})
+		w = &f.body
+	}
+
+	heading := inlineCode(filepath.ToSlash(entry.Path))
+	fmt.Fprintf(w, "## %s\n\n", heading)
+	if pairs := f.meta.pairs(entry.Meta); len(pairs) > 0 {
+		fields := make([]string, len(pairs))
+		for i, pair := range pairs {
+			fields[i] = fmt.Sprintf("%s: %s", pair.Name, inlineCode(pair.Value))
+		}
+		fmt.Fprintf(w, "%s\n\n", strings.Join(fields, " · "))
+	}
+	return w
+}
+
+// writeDiff writes the entry's diff section, if it has one
+func (f *markdownFormatter) writeDiff(w io.Writer, entry Entry) {
+	if entry.Diff != "" {
+		fmt.Fprintf(w, "### Diff\n\n")
+		writeFenced(w, "diff", []byte(entry.Diff))
+	}
+}
+
+// WriteTree implements Formatter
+func (f *markdownFormatter) WriteTree(w io.Writer, root *tree.Node) error {
+	if f.opts.TOC {
+		// Written by End, below the title
+		f.treeText = root.String()
+		return nil
+	}
+	writeTree(w, root.String())
+	return nil
+}
+
+// writeTree writes the tree section
+func writeTree(w io.Writer, text string) {
+	fmt.Fprint(w, "## Tree\n\n")
+	writeFenced(w, "text", []byte(text))
+}
+
+// WriteSkipped implements Formatter; skipped items are left to the summary
+func (f *markdownFormatter) WriteSkipped(w io.Writer, items []walker.SkippedItem) error {
+	return nil
+}
+
+// End implements Formatter. With a table of contents, this writes the whole
+// document.
+func (f *markdownFormatter) End(w io.Writer) error {
+	if !f.opts.TOC {
+		return nil
+	}
+
+	fmt.Fprintf(w, "# %s\n\n", inlineCode(f.title))
+	if f.treeText != "" {
+		writeTree(w, f.treeText)
+	}
+	fmt.Fprint(w, "## Contents\n\n")
+	for _, e := range f.toc {
+		fmt.Fprintf(w, "- [%s](#%s)\n", inlineCode(filepath.ToSlash(e.path)), e.anchor)
+	}
+	fmt.Fprint(w, "\n")
+
+	_, err := w.Write(f.body.Bytes())
+	return err
+}
+
+// anchor returns the GitHub-style anchor for a file heading, adding a
+// numeric suffix when the same slug was already used
+func (f *markdownFormatter) anchor(relativePath string) string {
+	slug := slugify(filepath.ToSlash(relativePath))
+	n := f.anchors[slug]
+	f.anchors[slug] = n + 1
+	if n > 0 {
+		return fmt.Sprintf("%s-%d", slug, n)
+	}
+	return slug
+}
+
+// slugify lowercases text, drops punctuation and turns spaces into hyphens,
+// the way GitHub derives heading anchors
+func slugify(text string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteByte('-')
+		}
+	}
+	return b.String()
+}
+
+// writeFenced writes content in a fenced code block whose fence is longer
+// than any backtick run inside the content, so the block cannot be closed
+// early
+func writeFenced(w io.Writer, lang string, content []byte) {
+	fence := strings.Repeat("`", max(3, longestRun(content, '`')+1))
+	fmt.Fprintf(w, "%s%s\n", fence, lang)
+	w.Write(content)
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		fmt.Fprint(w, "\n")
+	}
+	fmt.Fprintf(w, "%s\n\n", fence)
+}
+
+// inlineCode wraps text in a code span, using a longer delimiter if the text
+// itself contains backticks
+func inlineCode(text string) string {
+	delim := strings.Repeat("`", longestRun([]byte(text), '`')+1)
+	if strings.HasPrefix(text, "`") || strings.HasSuffix(text, "`") {
+		return delim + " " + text + " " + delim
+	}
+	return delim + text + delim
+}
+
+// longestRun returns the length of the longest run of c in content
+func longestRun(content []byte, c byte) int {
+	longest, run := 0, 0
+	for _, b := range content {
+		if b == c {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return longest
+}
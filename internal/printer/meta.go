@@ -0,0 +1,119 @@
+package printer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// MetaField names a file metadata field that formatters can include
+type MetaField string
+
+const (
+	MetaSize     MetaField = "size"
+	MetaMode     MetaField = "mode"
+	MetaModTime  MetaField = "mtime"
+	MetaLines    MetaField = "lines"
+	MetaLanguage MetaField = "language"
+	MetaSHA256   MetaField = "sha256"
+
+	// MetaTruncated is the number of lines left out of a truncated file. It
+	// cannot be selected: formatters always show it for truncated files.
+	MetaTruncated MetaField = "truncated"
+)
+
+// allMetaFields lists every field, in the order "all" selects them
+var allMetaFields = []MetaField{MetaSize, MetaMode, MetaModTime, MetaLines, MetaLanguage, MetaSHA256}
+
+// MetaFields is an ordered selection of metadata fields
+type MetaFields []MetaField
+
+// ParseMetaFields parses a comma-separated list of field names, or "all".
+// Fields are kept in the given order, without duplicates.
+func ParseMetaFields(spec string) (MetaFields, error) {
+	var fields MetaFields
+	for _, name := range strings.Split(spec, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if name == "all" {
+			return append(MetaFields{}, allMetaFields...), nil
+		}
+
+		field := MetaField(name)
+		valid := false
+		for _, f := range allMetaFields {
+			valid = valid || f == field
+		}
+		if !valid {
+			return nil, fmt.Errorf("printer: unknown metadata field %q (valid: %s, all)", name, joinMetaFields(allMetaFields))
+		}
+		if !fields.Has(field) {
+			fields = append(fields, field)
+		}
+	}
+	return fields, nil
+}
+
+// Has reports whether field is selected
+func (m MetaFields) Has(field MetaField) bool {
+	for _, f := range m {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// metaPair is a selected field with its value formatted as text
+type metaPair struct {
+	Name  MetaField
+	Value string
+}
+
+// pairs returns the selected fields of meta formatted as text, skipping
+// fields without a value, followed by MetaTruncated for truncated files. A
+// nil meta has no fields.
+func (m MetaFields) pairs(meta *walker.FileMeta) []metaPair {
+	if meta == nil {
+		return nil
+	}
+	pairs := make([]metaPair, 0, len(m))
+	for _, field := range m {
+		var value string
+		switch field {
+		case MetaSize:
+			value = strconv.FormatInt(meta.Size, 10)
+		case MetaMode:
+			value = meta.Mode.String()
+		case MetaModTime:
+			value = meta.ModTime.UTC().Format(time.RFC3339)
+		case MetaLines:
+			value = strconv.Itoa(meta.Lines)
+		case MetaLanguage:
+			value = meta.Language
+		case MetaSHA256:
+			value = meta.SHA256
+		}
+		if value != "" {
+			pairs = append(pairs, metaPair{Name: field, Value: value})
+		}
+	}
+	if meta.Truncation.Omitted > 0 {
+		pairs = append(pairs, metaPair{Name: MetaTruncated, Value: strconv.Itoa(meta.Truncation.Omitted)})
+	}
+	return pairs
+}
+
+// joinMetaFields returns the field names as a comma-separated list
+func joinMetaFields(fields []MetaField) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = string(f)
+	}
+	return strings.Join(names, ", ")
+}
@@ -2,31 +2,54 @@
 package printer
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"bufio"
+	"bytes"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"sync"
 	"sync/atomic"
+
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
 )
 
-// Printer handles output formatting and writing to the configured output destination
+// Printer writes a dump document to the configured output destination using
+// a Formatter. It is safe for concurrent use: each entry is rendered into a
+// buffer and written with a single call while holding the printer's lock.
 type Printer struct {
-	output         io.Writer
-	count          atomic.Int64
-	useColors      bool
-	jsonOutput     bool
-	jsonStarted    bool
-	markdownOutput bool
+	mu        sync.Mutex // Serializes formatter calls and writes
+	output    io.Writer
+	formatter Formatter
+	count     atomic.Int64
+	totals    Totals // Entries written, for summaries
+	begun     bool   // Whether the document header has been written
+	buf       bytes.Buffer
+
+	// Directory tree header; entries are spooled until the tree is known
+	tree    TreeOptions
+	doc     Document
+	header  bytes.Buffer         // Rendered document header, held back until Finalize
+	spool   *spool               // Rendered entries, held back until Finalize
+	paths   []string             // Included paths, in output order
+	skipped []walker.SkippedItem // Skipped items, for the tree
+
+	chunks *chunker // Splits the document into several files, if enabled
+}
+
+// TreeOptions controls the directory tree written before the file contents
+type TreeOptions struct {
+	Enabled bool // Write a tree of the included files ahead of the contents
+	Skipped bool // Also show skipped paths, marked with their reason
+	Only    bool // Write only the tree, without file contents (implies Enabled)
 }
 
-// New creates a new Printer with default settings
+// New creates a new Printer with default settings (plain text to stdout)
 func New() *Printer {
 	return &Printer{
-		output:         os.Stdout,
-		useColors:      true,
-		jsonOutput:     false,
-		markdownOutput: false,
+		output:    os.Stdout,
+		formatter: &textFormatter{useColors: true},
 	}
 }
 
@@ -39,83 +62,272 @@ func (p *Printer) WithOutput(w io.Writer) *Printer {
 	return p
 }
 
-// WithColors enables or disables colored output
-func (p *Printer) WithColors(enabled bool) *Printer {
-	p.useColors = enabled
+// WithFormatter sets the formatter used to render the document
+func (p *Printer) WithFormatter(f Formatter) *Printer {
+	p.formatter = f
 	return p
 }
 
-// WithJSON enables JSON output mode
-func (p *Printer) WithJSON(enabled bool) *Printer {
-	p.jsonOutput = enabled
+// WithTree enables the directory tree header. Since the tree is only known
+// once the walk completes, entries are spooled to a temporary file and the
+// document is written by Finalize.
+func (p *Printer) WithTree(opts TreeOptions) *Printer {
+	if opts.Only {
+		opts.Enabled = true
+	}
+	p.tree = opts
 	return p
 }
 
-// WithMarkdown enables Markdown output mode
-func (p *Printer) WithMarkdown(enabled bool) *Printer {
-	p.markdownOutput = enabled
+// WithChunks splits the document into numbered files bounded by the limits
+// in opts, writing them instead of the configured output. Each chunk is a
+// complete document rendered by a formatter obtained from factory.
+func (p *Printer) WithChunks(opts ChunkOptions, factory func() (Formatter, error)) *Printer {
+	p.chunks = newChunker(opts, factory)
 	return p
 }
 
-// JSONFileEntry represents a file entry in JSON output
-type JSONFileEntry struct {
-	Path    string `json:"path"`
-	Content string `json:"content"` // Base64 encoded content
+// Begin writes the document header. It is called implicitly by the first
+// entry if not called explicitly.
+func (p *Printer) Begin(doc Document) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.begin(doc)
+}
+
+// begin writes the document header once; the caller must hold p.mu
+func (p *Printer) begin(doc Document) {
+	if p.begun {
+		return
+	}
+	p.begun = true
+	p.doc = doc
+
+	if p.chunks != nil {
+		// Each chunk writes its own header
+		p.chunks.doc = doc
+		return
+	}
+
+	if p.tree.Enabled {
+		p.render(&p.header, func(w io.Writer) error { return p.formatter.Begin(w, doc) })
+		return
+	}
+	p.render(p.output, func(w io.Writer) error { return p.formatter.Begin(w, doc) })
+}
+
+// render runs a formatter call against the shared buffer and writes the
+// result to dst with a single call; the caller must hold p.mu
+func (p *Printer) render(dst io.Writer, fn func(w io.Writer) error) bool {
+	p.buf.Reset()
+	if err := fn(&p.buf); err != nil {
+		fmt.Fprintf(os.Stderr, "Error formatting output: %v\n", err)
+		return false
+	}
+	if _, err := dst.Write(p.buf.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return false
+	}
+	return true
 }
 
 // PrintFile outputs the content of a file with its path
 func (p *Printer) PrintFile(relativePath string, content []byte) {
-	// Increment the file counter
-	p.count.Add(1)
+	p.PrintEntry(Entry{Path: relativePath, Content: content})
+}
+
+// PrintEntry outputs a single file entry. The entry's Index is assigned by
+// the printer. An entry with a Reader is streamed to the output if the
+// formatter supports it and the document is not chunked.
+func (p *Printer) PrintEntry(entry Entry) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 
-	if p.jsonOutput {
-		// Handle JSON output mode
-		if !p.jsonStarted {
-			// Start the JSON array
-			fmt.Fprint(p.output, "[\n")
-			p.jsonStarted = true
-		} else {
-			// Add comma between entries
-			fmt.Fprint(p.output, ",\n")
+	p.begin(Document{})
+	entry.Index = int(p.count.Load()) + 1
+
+	dst := p.output
+	if p.tree.Enabled {
+		p.paths = append(p.paths, entry.Path)
+		if p.tree.Only {
+			p.count.Add(1)
+			return
 		}
+	}
 
-		// Create and encode entry
-		entry := JSONFileEntry{
-			Path:    relativePath,
-			Content: base64.StdEncoding.EncodeToString(content),
+	if entry.Reader != nil {
+		if _, ok := p.formatter.(StreamFormatter); !ok || p.chunks != nil {
+			// Chunks are measured whole, so chunked entries need their content too
+			content, err := io.ReadAll(entry.Reader)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", entry.Path, err)
+				return
+			}
+			entry.Content, entry.Reader = content, nil
 		}
+	}
 
-		jsonData, err := json.MarshalIndent(entry, "  ", "  ")
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Error marshaling JSON: %v\n", err)
+	if p.chunks != nil {
+		if err := p.chunks.writeEntry(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
 			return
 		}
+		p.written(int64(len(entry.Content)))
+		return
+	}
 
-		// Write the JSON entry
-		fmt.Fprintf(p.output, "  %s", jsonData)
-	} else if p.markdownOutput {
-		// Handle Markdown output mode
-		fmt.Fprintf(p.output, "file: %s\n\n```\n%s\n```\n\n", relativePath, content)
-	} else {
-		// Standard output mode
-		if p.useColors {
-			// Use colors for the filename
-			fmt.Fprintf(p.output, "\033[1;36m%s\033[0m\n", relativePath)
-		} else {
-			fmt.Fprintf(p.output, "%s\n", relativePath)
+	if p.tree.Enabled {
+		if p.spool == nil {
+			p.spool = newSpool()
 		}
+		dst = p.spool
+	}
+
+	if entry.Reader != nil {
+		counter := &countingReader{r: entry.Reader}
+		entry.Reader = counter
+		if p.stream(dst, entry) {
+			p.written(counter.n)
+		}
+		return
+	}
+	if p.render(dst, func(w io.Writer) error { return p.formatter.WriteEntry(w, entry) }) {
+		p.written(int64(len(entry.Content)))
+	}
+}
+
+// written counts an entry with size bytes of content as written; the caller
+// must hold p.mu
+func (p *Printer) written(size int64) {
+	p.count.Add(1)
+	p.totals.Files++
+	p.totals.Bytes += size
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+// Read implements io.Reader
+func (c *countingReader) Read(b []byte) (int, error) {
+	n, err := c.r.Read(b)
+	c.n += int64(n)
+	return n, err
+}
 
-		// Write the content
-		fmt.Fprintf(p.output, "%s\n\n", content)
+// stream writes an entry to dst as the formatter reads its content; the
+// caller must hold p.mu. Unlike render, output is not held back, so an
+// error can leave a partial entry behind.
+func (p *Printer) stream(dst io.Writer, entry Entry) bool {
+	bw := bufio.NewWriterSize(dst, streamBlockSize)
+	err := p.formatter.(StreamFormatter).WriteStream(bw, entry)
+	if err == nil {
+		err = bw.Flush()
 	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		return false
+	}
+	return true
 }
 
-// Finalize completes any pending operations (like closing JSON array)
+// PrintSkipped passes the skipped items to the formatter, for formats that
+// include them in the document
+func (p *Printer) PrintSkipped(items []walker.SkippedItem) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.begin(Document{})
+	if p.tree.Enabled || p.chunks != nil {
+		// Written by Finalize, after the tree and the spooled entries
+		p.skipped = items
+		return
+	}
+	p.render(p.output, func(w io.Writer) error { return p.writeSkipped(p.formatter, w, items) })
+}
+
+// writeSkipped passes the document totals to f, if it reports them, and
+// writes the skipped items; the caller must hold p.mu
+func (p *Printer) writeSkipped(f Formatter, w io.Writer, items []walker.SkippedItem) error {
+	if tf, ok := f.(TotalsFormatter); ok {
+		tf.SetTotals(p.totals)
+	}
+	return f.WriteSkipped(w, items)
+}
+
+// Finalize completes the document (like closing the JSON array). With a
+// tree header, this writes the whole document.
 func (p *Printer) Finalize() {
-	if p.jsonOutput && p.jsonStarted {
-		// Close the JSON array
-		fmt.Fprint(p.output, "\n]\n")
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.begin(Document{})
+	if p.chunks != nil {
+		p.finalizeChunks()
+		return
+	}
+	if p.tree.Enabled {
+		p.finalizeTree()
+	}
+	p.render(p.output, func(w io.Writer) error { return p.formatter.End(w) })
+}
+
+// finalizeTree writes the held back header, the tree, the spooled entries
+// and the skipped items; the caller must hold p.mu
+func (p *Printer) finalizeTree() {
+	if _, err := p.output.Write(p.header.Bytes()); err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+	}
+
+	root := p.buildTree()
+	p.render(p.output, func(w io.Writer) error { return p.formatter.WriteTree(w, root) })
+
+	if p.spool != nil {
+		if err := p.spool.copyTo(p.output); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+		p.spool.close()
+		p.spool = nil
+	}
+
+	p.render(p.output, func(w io.Writer) error { return p.writeSkipped(p.formatter, w, p.skipped) })
+}
+
+// finalizeChunks writes the tree chunk, if enabled, and completes the last
+// chunk and the manifest; the caller must hold p.mu
+func (p *Printer) finalizeChunks() {
+	if p.tree.Enabled {
+		root := p.buildTree()
+		err := p.chunks.writeDocument(func(f Formatter, buf *bytes.Buffer) error {
+			return f.WriteTree(buf, root)
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+		}
+	}
+
+	err := p.chunks.finish(func(f Formatter, buf *bytes.Buffer) error {
+		return p.writeSkipped(f, buf, p.skipped)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing output: %v\n", err)
+	}
+}
+
+// buildTree builds the directory tree from the recorded paths; the caller
+// must hold p.mu
+func (p *Printer) buildTree() *tree.Node {
+	var skipped []walker.SkippedItem
+	if p.tree.Skipped {
+		skipped = p.skipped
+	}
+	rootName := "."
+	if p.doc.Root != "" {
+		rootName = filepath.Base(p.doc.Root)
 	}
+	return tree.Build(rootName, p.paths, skipped)
 }
 
 // GetCount returns the number of files printed
@@ -0,0 +1,295 @@
+package printer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// writeFixture creates a tree of text files under a temp dir, nested a few
+// levels deep, and returns its root and the file paths in walk order
+func writeFixture(t *testing.T, files int) (string, []string) {
+	t.Helper()
+	root := t.TempDir()
+	var paths []string
+	for i := 0; i < files; i++ {
+		rel := filepath.Join(fmt.Sprintf("d%d", i%7), fmt.Sprintf("s%d", i%3), fmt.Sprintf("f%03d.txt", i))
+		var content strings.Builder
+		for line := 0; line < 1+i%40; line++ {
+			// Markup and quotes exercise escaping; the file number on every
+			// line exposes interleaved writes
+			fmt.Fprintf(&content, "file %d line %d <tag attr=\"%d\"> & ]]> `code`\n", i, line, line)
+		}
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content.String()), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, filepath.ToSlash(rel))
+	}
+	sort.Strings(paths) // Directories are walked in lexical order
+	return root, paths
+}
+
+// formatOptions returns options every registered format accepts
+func formatOptions(t *testing.T) FormatOptions {
+	t.Helper()
+	tmpl, err := LoadTemplate("separator")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return FormatOptions{XML: XMLOptions{Root: DefaultXMLRoot}, Template: tmpl}
+}
+
+// dump walks root into a printer using format and returns the output
+func dump(t *testing.T, root, format string, opts ...walker.Option) []byte {
+	t.Helper()
+	formatter, err := NewFormatter(format, formatOptions(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	p := New().WithOutput(&out).WithFormatter(formatter)
+	p.Begin(Document{Root: root})
+	skipped, err := walker.Walk(root, matcher, func(relativePath string, content []byte, err error) error {
+		if err != nil {
+			t.Errorf("walk error for %s: %v", relativePath, err)
+			return nil
+		}
+		p.PrintFile(relativePath, content)
+		return nil
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.PrintSkipped(skipped)
+	p.Finalize()
+	return out.Bytes()
+}
+
+// TestConcurrentWalkEveryFormat walks a tree with many workers into every
+// registered format and checks the output against a sequential walk, and
+// that structured formats parse. Run with -race to check the printer's
+// locking.
+func TestConcurrentWalkEveryFormat(t *testing.T) {
+	root, paths := writeFixture(t, 150)
+
+	for _, format := range Formats() {
+		t.Run(format, func(t *testing.T) {
+			sequential := dump(t, root, format)
+			concurrent := dump(t, root, format, walker.WithConcurrency(true), walker.WithMaxWorkers(32))
+			if !bytes.Equal(sequential, concurrent) {
+				t.Fatalf("concurrent output differs from sequential output")
+			}
+
+			switch format {
+			case "json":
+				checkJSON(t, concurrent, paths)
+			case "jsonl":
+				checkJSONL(t, concurrent, paths)
+			case "xml":
+				checkXML(t, concurrent, paths)
+			default:
+				checkOrdered(t, concurrent, paths)
+			}
+		})
+	}
+}
+
+// checkJSON checks that out is a JSON array of the files in walk order
+func checkJSON(t *testing.T, out []byte, paths []string) {
+	t.Helper()
+	var records []JSONFileEntry
+	if err := json.Unmarshal(out, &records); err != nil {
+		t.Fatalf("output is not valid JSON: %v", err)
+	}
+	got := make([]string, len(records))
+	for i, r := range records {
+		got[i] = r.Path
+	}
+	checkPaths(t, got, paths)
+}
+
+// checkJSONL checks that every line of out is a JSON object, with the files
+// in walk order followed by the summary
+func checkJSONL(t *testing.T, out []byte, paths []string) {
+	t.Helper()
+	var got []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		var record struct {
+			Type  string `json:"type"`
+			Path  string `json:"path"`
+			Files int    `json:"files"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("line is not valid JSON: %v\n%s", err, scanner.Text())
+		}
+		switch record.Type {
+		case "file":
+			got = append(got, record.Path)
+		case "summary":
+			if record.Files != len(paths) {
+				t.Errorf("summary counts %d files, want %d", record.Files, len(paths))
+			}
+		}
+	}
+	checkPaths(t, got, paths)
+}
+
+// checkXML checks that out is well-formed XML with the files in walk order
+func checkXML(t *testing.T, out []byte, paths []string) {
+	t.Helper()
+	var doc struct {
+		Documents []struct {
+			Source  string `xml:"source"`
+			Content string `xml:"document_content"`
+		} `xml:"document"`
+	}
+	if err := xml.Unmarshal(out, &doc); err != nil {
+		t.Fatalf("output is not well-formed XML: %v", err)
+	}
+	got := make([]string, len(doc.Documents))
+	for i, d := range doc.Documents {
+		got[i] = d.Source
+		checkContent(t, d.Source, d.Content)
+	}
+	checkPaths(t, got, paths)
+}
+
+// checkOrdered checks that each file's path appears in walk order, with its
+// lines following it before the next file starts
+func checkOrdered(t *testing.T, out []byte, paths []string) {
+	t.Helper()
+	text := string(out)
+	pos := 0
+	for i, path := range paths {
+		at := strings.Index(text[pos:], path)
+		if at < 0 {
+			t.Fatalf("%s missing or out of order", path)
+		}
+		pos += at + len(path)
+		end := len(text)
+		if i+1 < len(paths) {
+			if next := strings.Index(text[pos:], paths[i+1]); next >= 0 {
+				end = pos + next
+			}
+		}
+		checkContent(t, path, text[pos:end])
+	}
+}
+
+// checkContent checks that the content written for path holds its own lines
+// and no other file's
+func checkContent(t *testing.T, path, content string) {
+	t.Helper()
+	var n int
+	fmt.Sscanf(filepath.Base(path), "f%03d.txt", &n)
+	own := fmt.Sprintf("file %d line ", n)
+	if !strings.Contains(content, own) {
+		t.Errorf("content of %s is missing its lines", path)
+	}
+	for _, line := range strings.Split(content, "\n") {
+		if i := strings.Index(line, "file "); i >= 0 && !strings.HasPrefix(line[i:], own) {
+			t.Errorf("content of %s has a foreign line: %q", path, line)
+			return
+		}
+	}
+}
+
+// checkPaths compares the paths found in the output with those expected
+func checkPaths(t *testing.T, got, want []string) {
+	t.Helper()
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("paths out of order or missing:\ngot  %v\nwant %v", got, want)
+	}
+}
+
+// TestConcurrentPrintEntry calls the printer from many goroutines at once,
+// as a callback not serialized by the walker would, and checks that entries
+// are written whole
+func TestConcurrentPrintEntry(t *testing.T) {
+	for _, format := range []string{"text", "markdown", "jsonl", "xml"} {
+		t.Run(format, func(t *testing.T) {
+			formatter, err := NewFormatter(format, formatOptions(t))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out lockedBuffer
+			p := New().WithOutput(&out).WithFormatter(formatter)
+			p.Begin(Document{})
+
+			const writers, perWriter = 16, 20
+			var wg sync.WaitGroup
+			for w := 0; w < writers; w++ {
+				wg.Add(1)
+				go func(w int) {
+					defer wg.Done()
+					for i := 0; i < perWriter; i++ {
+						n := w*perWriter + i
+						content := strings.Repeat(fmt.Sprintf("file %d line\n", n), 50)
+						p.PrintFile(fmt.Sprintf("f%03d.txt", n), []byte(content))
+					}
+				}(w)
+			}
+			wg.Wait()
+			p.Finalize()
+
+			if got := p.GetCount(); got != writers*perWriter {
+				t.Errorf("printed %d entries, want %d", got, writers*perWriter)
+			}
+			// Every file's 50 lines must be contiguous
+			lines := strings.Split(out.String(), "\n")
+			for i := 0; i < len(lines); i++ {
+				if !strings.HasPrefix(lines[i], "file ") {
+					continue
+				}
+				for j := 1; j < 50; j++ {
+					if lines[i+j] != lines[i] {
+						t.Fatalf("entry interleaved at line %d: %q then %q", i+j, lines[i], lines[i+j])
+					}
+				}
+				i += 49
+			}
+		})
+	}
+}
+
+// lockedBuffer is a bytes.Buffer whose writes may race
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+// Write implements io.Writer
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+// String returns the buffered output
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
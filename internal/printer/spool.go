@@ -0,0 +1,65 @@
+package printer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spool stores rendered output until it can be written in place. It uses a
+// temporary file so large dumps do not have to fit in memory, falling back
+// to an in-memory buffer if the file cannot be created.
+type spool struct {
+	file *os.File
+	mem  bytes.Buffer
+	err  error // First write error, reported by copyTo
+}
+
+// newSpool creates a spool backed by a temporary file when possible
+func newSpool() *spool {
+	s := &spool{}
+	file, err := os.CreateTemp("", "dir-dumper-*.spool")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: buffering output in memory (temporary file unavailable: %v)\n", err)
+		return s
+	}
+	s.file = file
+	return s
+}
+
+// Write implements io.Writer
+func (s *spool) Write(b []byte) (int, error) {
+	if s.file == nil {
+		return s.mem.Write(b)
+	}
+	n, err := s.file.Write(b)
+	if err != nil && s.err == nil {
+		s.err = err
+	}
+	return n, err
+}
+
+// copyTo writes everything spooled so far to w
+func (s *spool) copyTo(w io.Writer) error {
+	if s.err != nil {
+		return fmt.Errorf("printer: spooling output: %w", s.err)
+	}
+	if s.file == nil {
+		_, err := w.Write(s.mem.Bytes())
+		return err
+	}
+	if _, err := s.file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("printer: rewinding spool: %w", err)
+	}
+	_, err := io.Copy(w, s.file)
+	return err
+}
+
+// close releases the spool, removing its temporary file
+func (s *spool) close() {
+	if s.file != nil {
+		s.file.Close()
+		os.Remove(s.file.Name())
+	}
+}
@@ -0,0 +1,235 @@
+package printer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// streamBlockSize is the size of the blocks streamed content is read in
+const streamBlockSize = 64 * 1024
+
+// contentScan is what reading streamed content ahead of writing it found
+type contentScan struct {
+	size      int64
+	lines     int    // Lines, counted like countLines does
+	backticks int    // Longest run of backticks
+	validUTF8 bool   // Whether the content is valid UTF-8
+	head      []byte // The start of the content, for language detection
+	sha256    string // Hex-encoded SHA-256 of the content
+}
+
+// scanHeadLen is the length of contentScan.head
+const scanHeadLen = 1024
+
+// scanContent reads r ahead of writing it and returns what it found, along
+// with a reader for the content from its start: r itself, rewound, if it can
+// seek, or else a copy of the content held in memory
+func scanContent(r io.Reader) (contentScan, io.Reader, error) {
+	scan := contentScan{validUTF8: true}
+	hash := sha256.New()
+	run := 0 // Backticks ending the content read so far
+	var last byte
+	utf8Check := runeAligned{fn: func(block []byte) error {
+		scan.validUTF8 = scan.validUTF8 && utf8.Valid(block)
+		return nil
+	}}
+	add := func(block []byte) {
+		hash.Write(block)
+		scan.size += int64(len(block))
+		scan.lines += bytes.Count(block, []byte("\n"))
+		for _, b := range block {
+			if b == '`' {
+				run++
+				scan.backticks = max(scan.backticks, run)
+			} else {
+				run = 0
+			}
+		}
+		if room := scanHeadLen - len(scan.head); room > 0 {
+			scan.head = append(scan.head, block[:min(len(block), room)]...)
+		}
+		utf8Check.Write(block)
+		last = block[len(block)-1]
+	}
+	finish := func(read bool) {
+		if read && last != '\n' {
+			scan.lines++
+		}
+		utf8Check.flush()
+		scan.sha256 = hex.EncodeToString(hash.Sum(nil))
+	}
+
+	seeker, ok := r.(io.Seeker)
+	if !ok {
+		content, err := io.ReadAll(r)
+		if err != nil {
+			return scan, nil, err
+		}
+		if len(content) > 0 {
+			add(content)
+		}
+		finish(len(content) > 0)
+		return scan, bytes.NewReader(content), nil
+	}
+
+	start, err := seeker.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return scan, nil, err
+	}
+	buf := make([]byte, streamBlockSize)
+	read := false
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			add(buf[:n])
+			read = true
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return scan, nil, err
+		}
+	}
+	finish(read)
+	if _, err := seeker.Seek(start, io.SeekStart); err != nil {
+		return scan, nil, err
+	}
+	return scan, r, nil
+}
+
+// runeAligned passes writes on to fn in blocks of whole runes, holding back
+// a rune split across writes until the next write completes it
+type runeAligned struct {
+	fn      func(block []byte) error
+	pending []byte
+}
+
+// Write implements io.Writer
+func (a *runeAligned) Write(p []byte) (int, error) {
+	data := p
+	if len(a.pending) > 0 {
+		a.pending = append(a.pending, p...)
+		data = a.pending
+	}
+	cut := wholeRunes(data)
+	if err := a.fn(data[:cut]); err != nil {
+		return 0, err
+	}
+	a.pending = append(a.pending[:0], data[cut:]...)
+	return len(p), nil
+}
+
+// flush passes on whatever is held back, at the end of the content
+func (a *runeAligned) flush() error {
+	if len(a.pending) == 0 {
+		return nil
+	}
+	err := a.fn(a.pending)
+	a.pending = a.pending[:0]
+	return err
+}
+
+// wholeRunes returns the length of b without an incomplete rune at its end
+func wholeRunes(b []byte) int {
+	for i := 1; i < utf8.UTFMax && i <= len(b); i++ {
+		if utf8.RuneStart(b[len(b)-i]) {
+			if !utf8.FullRune(b[len(b)-i:]) {
+				return len(b) - i
+			}
+			break
+		}
+	}
+	return len(b)
+}
+
+// newXMLTextWriter returns a writer that escapes text like escapeXML as it
+// passes it on to w, and a function to call at the end of the text
+func newXMLTextWriter(w io.Writer) (io.Writer, func() error) {
+	a := &runeAligned{fn: func(block []byte) error {
+		_, err := io.WriteString(w, escapeXML(string(block)))
+		return err
+	}}
+	return a, a.flush
+}
+
+// newCDATAWriter returns a writer that prepares text for a CDATA section like
+// cdataText as it passes it on to w, and a function to call at the end of
+// the text. Closing brackets ending a block are held back, so a "]]>" split
+// across writes is still found.
+func newCDATAWriter(w io.Writer) (io.Writer, func() error) {
+	var brackets []byte
+	a := &runeAligned{fn: func(block []byte) error {
+		text := string(brackets) + string(block)
+		held := len(text) - len(strings.TrimRight(text, "]"))
+		held = min(held, 2)
+		brackets = append(brackets[:0], text[len(text)-held:]...)
+		_, err := io.WriteString(w, cdataText(text[:len(text)-held]))
+		return err
+	}}
+	return a, func() error {
+		if err := a.flush(); err != nil {
+			return err
+		}
+		_, err := w.Write(brackets)
+		return err
+	}
+}
+
+// newJSONStringWriter returns a writer that escapes text for the inside of a
+// JSON string as it passes it on to w, and a function to call at the end of
+// the text. HTML characters are escaped unless escapeHTML is false, as with
+// json.Encoder.SetEscapeHTML.
+func newJSONStringWriter(w io.Writer, escapeHTML bool) (io.Writer, func() error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(escapeHTML)
+	a := &runeAligned{fn: func(block []byte) error {
+		buf.Reset()
+		if err := enc.Encode(string(block)); err != nil {
+			return err
+		}
+		// Drop the quotes and the newline Encode adds
+		_, err := w.Write(buf.Bytes()[1 : buf.Len()-2])
+		return err
+	}}
+	return a, a.flush
+}
+
+// lastByteWriter passes writes on to w, remembering the last byte written
+type lastByteWriter struct {
+	w    io.Writer
+	last byte
+	n    int64
+}
+
+// Write implements io.Writer
+func (l *lastByteWriter) Write(p []byte) (int, error) {
+	n, err := l.w.Write(p)
+	if n > 0 {
+		l.last = p[n-1]
+		l.n += int64(n)
+	}
+	return n, err
+}
+
+// endsLine reports whether nothing was written or the last byte ended a line
+func (l *lastByteWriter) endsLine() bool {
+	return l.n == 0 || l.last == '\n'
+}
+
+// copyContent writes the entry's content, read from r, to w. With numbered
+// set, lines are prefixed with their numbers; lines is the number of lines
+// in the content, which sets the width of the numbers.
+func copyContent(w io.Writer, entry Entry, r io.Reader, numbered bool, lines int) error {
+	if numbered {
+		return writeNumberedStream(w, entry, r, lines)
+	}
+	_, err := io.Copy(w, r)
+	return err
+}
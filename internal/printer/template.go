@@ -0,0 +1,257 @@
+package printer
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/bethropolis/dir-dumper/internal/language"
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func init() {
+	Register("template", func(opts FormatOptions) (Formatter, error) {
+		return newTemplateFormatter(opts.Template, opts.LineNumbers)
+	})
+}
+
+//go:embed templates/*.tmpl
+var builtinTemplates embed.FS
+
+// TemplateOptions configures the template format
+type TemplateOptions struct {
+	Name string // Template name, for error messages
+	Text string // Template source, see LoadTemplate
+}
+
+// TemplateFile is the data passed to the "file" template for each entry.
+// In the document's Files list, Content and Diff are left empty.
+type TemplateFile struct {
+	Index     int
+	Path      string
+	Content   string // Line-numbered with line numbers enabled
+	StartLine int    // Number of the first numbered line
+	Diff      string
+	Language  string
+	Size      int64
+	Lines     int
+	SHA256    string
+	Mode      string
+	ModTime   time.Time
+	Truncated int // Lines left out by a line limit (0 if the file is whole)
+}
+
+// TemplateDocument is the data passed to the "begin" and "end" templates.
+// Files, Skipped and the totals are only filled in for "end".
+type TemplateDocument struct {
+	Root       string // Absolute path of the scanned directory
+	Name       string // Base name of the scanned directory
+	Timestamp  time.Time
+	Files      []TemplateFile
+	Skipped    []walker.SkippedItem
+	TotalFiles int
+	TotalBytes int64 // Sum of the files' Size
+	TotalLines int   // Sum of the files' Lines
+}
+
+// TemplateTree is the data passed to the "tree" template
+type TemplateTree struct {
+	Text string     // The tree rendered as ASCII art
+	Root *tree.Node // The tree itself
+}
+
+// BuiltinTemplates returns the names of the templates shipped in the binary
+func BuiltinTemplates() []string {
+	entries, _ := builtinTemplates.ReadDir("templates")
+	names := make([]string, 0, len(entries))
+	for _, e := range entries {
+		names = append(names, strings.TrimSuffix(e.Name(), ".tmpl"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadTemplate returns the source of a template given either a file path or
+// the name of a built-in template
+func LoadTemplate(spec string) (TemplateOptions, error) {
+	if text, err := os.ReadFile(spec); err == nil {
+		return TemplateOptions{Name: filepath.Base(spec), Text: string(text)}, nil
+	} else if !os.IsNotExist(err) {
+		return TemplateOptions{}, fmt.Errorf("printer: reading template: %w", err)
+	}
+
+	text, err := builtinTemplates.ReadFile(path.Join("templates", spec+".tmpl"))
+	if err != nil {
+		return TemplateOptions{}, fmt.Errorf("printer: template %q is neither a file nor a built-in template (built-in: %s)",
+			spec, strings.Join(BuiltinTemplates(), ", "))
+	}
+	return TemplateOptions{Name: spec, Text: string(text)}, nil
+}
+
+// templateFuncs are the helper functions available to templates
+var templateFuncs = template.FuncMap{
+	"indent":        indentLines,
+	"fence":         fence,
+	"trimLines":     trimLines,
+	"escapeXML":     escapeXML,
+	"escapeXMLAttr": escapeXMLAttr, // Also escapes quotes, for attribute values
+	"hasSuffix":     strings.HasSuffix,
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// templateFormatter renders the document with a user-defined text/template.
+// The template may define "begin", "file", "tree" and "end"; a template
+// without a "file" definition is used for each file as a whole.
+type templateFormatter struct {
+	tmpl        *template.Template
+	file        string // Name of the template executed per file
+	lineNumbers bool
+	doc         TemplateDocument
+}
+
+// newTemplateFormatter parses the template
+func newTemplateFormatter(opts TemplateOptions, lineNumbers bool) (*templateFormatter, error) {
+	if opts.Text == "" {
+		return nil, fmt.Errorf("printer: the template format requires a template (-template)")
+	}
+	tmpl, err := template.New(opts.Name).Funcs(templateFuncs).Parse(opts.Text)
+	if err != nil {
+		return nil, fmt.Errorf("printer: parsing template: %w", err)
+	}
+
+	f := &templateFormatter{tmpl: tmpl, file: opts.Name, lineNumbers: lineNumbers}
+	if tmpl.Lookup("file") != nil {
+		f.file = "file"
+	}
+	return f, nil
+}
+
+// execute runs the named template if it is defined
+func (f *templateFormatter) execute(w io.Writer, name string, data interface{}) error {
+	if f.tmpl.Lookup(name) == nil {
+		return nil
+	}
+	if err := f.tmpl.ExecuteTemplate(w, name, data); err != nil {
+		return fmt.Errorf("printer: executing template: %w", err)
+	}
+	return nil
+}
+
+// Begin implements Formatter
+func (f *templateFormatter) Begin(w io.Writer, doc Document) error {
+	f.doc = TemplateDocument{
+		Root:      doc.Root,
+		Name:      filepath.Base(doc.Root),
+		Timestamp: time.Now(),
+	}
+	return f.execute(w, "begin", f.doc)
+}
+
+// WriteEntry implements Formatter
+func (f *templateFormatter) WriteEntry(w io.Writer, entry Entry) error {
+	sum := sha256.Sum256(entry.Content)
+	file := TemplateFile{
+		Index:     entry.Index,
+		Path:      filepath.ToSlash(entry.Path),
+		Content:   string(entry.Content),
+		StartLine: entry.firstNumber(),
+		Diff:      entry.Diff,
+		Language:  language.Detect(entry.Path, entry.Content),
+		Size:      int64(len(entry.Content)),
+		Lines:     countLines(entry.Content),
+		SHA256:    hex.EncodeToString(sum[:]),
+	}
+	if f.lineNumbers {
+		file.Content = string(numbered(entry))
+	}
+	if meta := entry.Meta; meta != nil {
+		file.Size = meta.Size
+		file.Mode = meta.Mode.String()
+		file.ModTime = meta.ModTime
+		file.Lines = meta.Lines
+		file.Language = meta.Language
+		file.SHA256 = meta.SHA256
+		file.Truncated = meta.Truncation.Omitted
+	}
+
+	if err := f.execute(w, f.file, file); err != nil {
+		return err
+	}
+
+	// The document keeps the file list without contents
+	file.Content, file.Diff = "", ""
+	f.doc.Files = append(f.doc.Files, file)
+	f.doc.TotalFiles++
+	f.doc.TotalBytes += file.Size
+	f.doc.TotalLines += file.Lines
+	return nil
+}
+
+// WriteTree implements Formatter. Without a "tree" definition, the tree is
+// written as ASCII art.
+func (f *templateFormatter) WriteTree(w io.Writer, root *tree.Node) error {
+	data := TemplateTree{Text: root.String(), Root: root}
+	if f.tmpl.Lookup("tree") == nil {
+		_, err := fmt.Fprintf(w, "%s\n", data.Text)
+		return err
+	}
+	return f.execute(w, "tree", data)
+}
+
+// WriteSkipped implements Formatter by recording the items for "end"
+func (f *templateFormatter) WriteSkipped(w io.Writer, items []walker.SkippedItem) error {
+	f.doc.Skipped = append([]walker.SkippedItem{}, items...)
+	sort.Slice(f.doc.Skipped, func(i, j int) bool {
+		return f.doc.Skipped[i].Path < f.doc.Skipped[j].Path
+	})
+	return nil
+}
+
+// End implements Formatter
+func (f *templateFormatter) End(w io.Writer) error {
+	return f.execute(w, "end", f.doc)
+}
+
+// indentLines prefixes every non-empty line of s with n spaces
+func indentLines(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	lines := strings.SplitAfter(s, "\n")
+	for i, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			lines[i] = pad + line
+		}
+	}
+	return strings.Join(lines, "")
+}
+
+// fence wraps content in a collision-safe Markdown code fence tagged with lang
+func fence(lang, content string) string {
+	var buf bytes.Buffer
+	writeFenced(&buf, lang, []byte(content))
+	return strings.TrimSuffix(buf.String(), "\n\n")
+}
+
+// trimLines removes trailing whitespace from every line and drops leading
+// and trailing blank lines
+func trimLines(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t\r")
+	}
+	return strings.Trim(strings.Join(lines, "\n"), "\n")
+}
@@ -0,0 +1,101 @@
+package printer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// renderTemplate renders entries with the named built-in template
+func renderTemplate(t *testing.T, name string, doc Document, entries []Entry) string {
+	t.Helper()
+	opts, err := LoadTemplate(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter, err := NewFormatter("template", FormatOptions{Template: opts})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	p := New().WithOutput(&out).WithFormatter(formatter)
+	p.Begin(doc)
+	for _, entry := range entries {
+		p.PrintEntry(entry)
+	}
+	p.PrintSkipped(nil)
+	p.Finalize()
+	return out.String()
+}
+
+// TestTemplateTotals checks that the document totals agree with the sizes
+// of the files, which describe them as stored when metadata is known
+func TestTemplateTotals(t *testing.T) {
+	entries := []Entry{
+		{Path: "plain.txt", Content: []byte("12345\n")},
+		// Truncated to two lines of a larger file
+		{Path: "big.log", Content: []byte("a\nb\n"), Meta: &walker.FileMeta{Size: 1000, Lines: 200}},
+	}
+	out := renderTemplate(t, "manifest", Document{Root: "/tmp/project"}, entries)
+
+	for _, want := range []string{"       6  plain.txt", "    1000  big.log", "# 2 files, 1006 bytes"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("manifest output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+// TestPromptTemplateIsXML checks that the prompt template escapes names and
+// content, attribute values included
+func TestPromptTemplateIsXML(t *testing.T) {
+	entries := []Entry{
+		{Path: `a "quoted" <name> & more.txt`, Content: []byte("if a < b && c > d {}\n")},
+		{Path: "b.go", Content: []byte("package b\n"), Meta: &walker.FileMeta{Language: `go"`, Size: 10, Lines: 1}},
+	}
+	out := renderTemplate(t, "prompt", Document{Root: `/tmp/my "project"`}, entries)
+
+	var doc struct {
+		Root      string `xml:"root,attr"`
+		Documents []struct {
+			Source   string `xml:"source"`
+			Metadata struct {
+				Language string `xml:"language,attr"`
+			} `xml:"metadata"`
+			Content string `xml:"document_content"`
+		} `xml:"document"`
+	}
+	if err := xml.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("prompt output is not well-formed XML: %v\n%s", err, out)
+	}
+	if doc.Root != `my "project"` {
+		t.Errorf("root = %q", doc.Root)
+	}
+	if len(doc.Documents) != 2 {
+		t.Fatalf("got %d documents, want 2", len(doc.Documents))
+	}
+	if got := doc.Documents[0].Source; got != entries[0].Path {
+		t.Errorf("source = %q, want %q", got, entries[0].Path)
+	}
+	if got := strings.TrimSpace(doc.Documents[0].Content); got != "if a < b && c > d {}" {
+		t.Errorf("content = %q", got)
+	}
+	if got := doc.Documents[1].Metadata.Language; got != `go"` {
+		t.Errorf("language = %q", got)
+	}
+}
+
+func TestEscapeXMLAttr(t *testing.T) {
+	for in, want := range map[string]string{
+		`plain`:       `plain`,
+		`a "b" <c> &`: `a &quot;b&quot; &lt;c&gt; &amp;`,
+		"bad\x00char": fmt.Sprintf("bad%cchar", '\uFFFD'),
+	} {
+		if got := escapeXMLAttr(in); got != want {
+			t.Errorf("escapeXMLAttr(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
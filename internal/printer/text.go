@@ -0,0 +1,112 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func init() {
+	Register("text", func(opts FormatOptions) (Formatter, error) {
+		return &textFormatter{useColors: opts.UseColors, meta: opts.Meta, lineNumbers: opts.LineNumbers}, nil
+	})
+}
+
+// textFormatter writes each file as its path followed by its raw content
+type textFormatter struct {
+	useColors   bool
+	meta        MetaFields
+	lineNumbers bool
+}
+
+// Begin implements Formatter; plain text has no document header
+func (f *textFormatter) Begin(w io.Writer, doc Document) error {
+	return nil
+}
+
+// WriteEntry implements Formatter
+func (f *textFormatter) WriteEntry(w io.Writer, entry Entry) error {
+	f.writeHeader(w, entry)
+
+	// Write the content
+	if f.lineNumbers {
+		if err := writeNumbered(w, entry); err != nil {
+			return err
+		}
+		fmt.Fprint(w, "\n\n")
+	} else {
+		fmt.Fprintf(w, "%s\n\n", entry.Content)
+	}
+	if entry.Diff != "" {
+		fmt.Fprintf(w, "%s\n", entry.Diff)
+	}
+	return nil
+}
+
+// WriteStream implements StreamFormatter
+func (f *textFormatter) WriteStream(w io.Writer, entry Entry) error {
+	f.writeHeader(w, entry)
+
+	// Line numbers are as wide as the last one, so count the lines first
+	r, lines := entry.Reader, 0
+	if f.lineNumbers {
+		scan, rewound, err := scanContent(r)
+		if err != nil {
+			return err
+		}
+		r, lines = rewound, scan.lines
+	}
+	if err := copyContent(w, entry, r, f.lineNumbers, lines); err != nil {
+		return err
+	}
+	fmt.Fprint(w, "\n\n")
+	if entry.Diff != "" {
+		fmt.Fprintf(w, "%s\n", entry.Diff)
+	}
+	return nil
+}
+
+// writeHeader writes the entry's path and selected metadata
+func (f *textFormatter) writeHeader(w io.Writer, entry Entry) {
+	if f.useColors {
+		// Use colors for the filename
+		fmt.Fprintf(w, "\033[1;36m%s\033[0m\n", entry.Path)
+	} else {
+		fmt.Fprintf(w, "%s\n", entry.Path)
+	}
+	if pairs := f.meta.pairs(entry.Meta); len(pairs) > 0 {
+		// Metadata as a single line of name=value pairs below the path
+		fields := make([]string, len(pairs))
+		for i, pair := range pairs {
+			fields[i] = fmt.Sprintf("%s=%s", pair.Name, pair.Value)
+		}
+		if f.useColors {
+			fmt.Fprintf(w, "\033[2m%s\033[0m\n", strings.Join(fields, " "))
+		} else {
+			fmt.Fprintf(w, "%s\n", strings.Join(fields, " "))
+		}
+	}
+}
+
+// WriteTree implements Formatter
+func (f *textFormatter) WriteTree(w io.Writer, root *tree.Node) error {
+	if err := root.Render(w); err != nil {
+		return err
+	}
+	_, err := fmt.Fprint(w, "\n")
+	return err
+}
+
+// WriteSkipped implements Formatter; skipped items are reported on stderr
+// by the summary instead of being mixed into the dump
+func (f *textFormatter) WriteSkipped(w io.Writer, items []walker.SkippedItem) error {
+	return nil
+}
+
+// End implements Formatter
+func (f *textFormatter) End(w io.Writer) error {
+	return nil
+}
@@ -0,0 +1,267 @@
+package printer
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bethropolis/dir-dumper/internal/tree"
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func init() {
+	Register("xml", func(opts FormatOptions) (Formatter, error) {
+		return newXMLFormatter(opts.XML, opts.Meta, opts.LineNumbers)
+	})
+}
+
+// XMLOptions configures the xml format. Empty tag names use the defaults.
+type XMLOptions struct {
+	Root        string // Wrapping root element ("" for none)
+	DocumentTag string // Element wrapping each file (default "document")
+	SourceTag   string // Element holding the file path (default "source")
+	ContentTag  string // Element holding the file content (default "document_content")
+	DiffTag     string // Element holding the file's diff, if any (default "diff")
+	TreeTag     string // Element holding the directory tree (default "tree")
+	CDATA       bool   // Wrap content in CDATA sections instead of escaping it
+}
+
+// DefaultXMLRoot is the root element used when none is configured
+const DefaultXMLRoot = "documents"
+
+// SetTags overrides tag names from a comma-separated list of key=name pairs,
+// e.g. "document=file,content=body". Valid keys are document, source,
+// content, diff and tree.
+func (o *XMLOptions) SetTags(spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		key, name, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("printer: invalid xml tag %q: expected key=name", pair)
+		}
+		name = strings.TrimSpace(name)
+		switch strings.TrimSpace(key) {
+		case "document":
+			o.DocumentTag = name
+		case "source":
+			o.SourceTag = name
+		case "content":
+			o.ContentTag = name
+		case "diff":
+			o.DiffTag = name
+		case "tree":
+			o.TreeTag = name
+		default:
+			return fmt.Errorf("printer: unknown xml tag key %q (valid: document, source, content, diff, tree)", key)
+		}
+	}
+	return nil
+}
+
+// xmlNamePattern matches the XML element names we accept (no namespaces)
+var xmlNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9._-]*$`)
+
+// xmlFormatter writes each file as a tagged document, the structure LLM
+// prompts commonly use to delimit source files
+type xmlFormatter struct {
+	opts        XMLOptions
+	meta        MetaFields
+	lineNumbers bool
+}
+
+// newXMLFormatter fills in default tag names and validates them
+func newXMLFormatter(opts XMLOptions, meta MetaFields, lineNumbers bool) (*xmlFormatter, error) {
+	defaults := []struct {
+		tag *string
+		def string
+	}{
+		{&opts.DocumentTag, "document"},
+		{&opts.SourceTag, "source"},
+		{&opts.ContentTag, "document_content"},
+		{&opts.DiffTag, "diff"},
+		{&opts.TreeTag, "tree"},
+	}
+	for _, d := range defaults {
+		if *d.tag == "" {
+			*d.tag = d.def
+		}
+		if !xmlNamePattern.MatchString(*d.tag) {
+			return nil, fmt.Errorf("printer: invalid xml tag name %q", *d.tag)
+		}
+	}
+	if opts.Root != "" && !xmlNamePattern.MatchString(opts.Root) {
+		return nil, fmt.Errorf("printer: invalid xml root element name %q", opts.Root)
+	}
+	return &xmlFormatter{opts: opts, meta: meta, lineNumbers: lineNumbers}, nil
+}
+
+// Begin implements Formatter by opening the root element, if any
+func (f *xmlFormatter) Begin(w io.Writer, doc Document) error {
+	if f.opts.Root == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "<%s>\n", f.opts.Root)
+	return err
+}
+
+// WriteEntry implements Formatter
+func (f *xmlFormatter) WriteEntry(w io.Writer, entry Entry) error {
+	f.openDocument(w, entry)
+	content := entry.Content
+	if f.lineNumbers {
+		content = numbered(entry)
+	}
+	f.writeText(w, f.opts.ContentTag, string(content))
+	return f.closeDocument(w, entry)
+}
+
+// WriteStream implements StreamFormatter
+func (f *xmlFormatter) WriteStream(w io.Writer, entry Entry) error {
+	f.openDocument(w, entry)
+
+	r, lines := entry.Reader, 0
+	if f.lineNumbers {
+		scan, rewound, err := scanContent(r)
+		if err != nil {
+			return err
+		}
+		r, lines = rewound, scan.lines
+	}
+
+	tag := f.opts.ContentTag
+	if f.opts.CDATA {
+		fmt.Fprintf(w, "<%s><![CDATA[", tag)
+		cw, flush := newCDATAWriter(w)
+		if err := copyContent(cw, entry, r, f.lineNumbers, lines); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		fmt.Fprintf(w, "]]></%s>\n", tag)
+	} else {
+		fmt.Fprintf(w, "<%s>\n", tag)
+		lw := &lastByteWriter{w: w}
+		tw, flush := newXMLTextWriter(lw)
+		if err := copyContent(tw, entry, r, f.lineNumbers, lines); err != nil {
+			return err
+		}
+		if err := flush(); err != nil {
+			return err
+		}
+		if !lw.endsLine() {
+			fmt.Fprint(w, "\n")
+		}
+		fmt.Fprintf(w, "</%s>\n", tag)
+	}
+	return f.closeDocument(w, entry)
+}
+
+// openDocument opens the entry's document element and writes its source
+func (f *xmlFormatter) openDocument(w io.Writer, entry Entry) {
+	// Metadata goes into attributes, so the element structure stays the same
+	fmt.Fprintf(w, "<%s index=\"%d\"", f.opts.DocumentTag, entry.Index)
+	for _, pair := range f.meta.pairs(entry.Meta) {
+		fmt.Fprintf(w, " %s=\"%s\"", pair.Name, escapeXMLAttr(pair.Value))
+	}
+	fmt.Fprint(w, ">\n")
+	fmt.Fprintf(w, "<%s>%s</%s>\n", f.opts.SourceTag, escapeXML(entry.Path), f.opts.SourceTag)
+}
+
+// closeDocument writes the entry's diff, if any, and closes its document
+// element
+func (f *xmlFormatter) closeDocument(w io.Writer, entry Entry) error {
+	if entry.Diff != "" {
+		f.writeText(w, f.opts.DiffTag, entry.Diff)
+	}
+	_, err := fmt.Fprintf(w, "</%s>\n", f.opts.DocumentTag)
+	return err
+}
+
+// writeText writes a text element, either escaped or as CDATA
+func (f *xmlFormatter) writeText(w io.Writer, tag, text string) {
+	if f.opts.CDATA {
+		fmt.Fprintf(w, "<%s><![CDATA[%s]]></%s>\n", tag, cdataText(text), tag)
+		return
+	}
+
+	fmt.Fprintf(w, "<%s>\n%s", tag, escapeXML(text))
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		fmt.Fprint(w, "\n")
+	}
+	fmt.Fprintf(w, "</%s>\n", tag)
+}
+
+// WriteTree implements Formatter
+func (f *xmlFormatter) WriteTree(w io.Writer, root *tree.Node) error {
+	f.writeText(w, f.opts.TreeTag, root.String())
+	return nil
+}
+
+// WriteSkipped implements Formatter; skipped items are left to the summary
+func (f *xmlFormatter) WriteSkipped(w io.Writer, items []walker.SkippedItem) error {
+	return nil
+}
+
+// End implements Formatter by closing the root element, if any
+func (f *xmlFormatter) End(w io.Writer) error {
+	if f.opts.Root == "" {
+		return nil
+	}
+	_, err := fmt.Fprintf(w, "</%s>\n", f.opts.Root)
+	return err
+}
+
+// escapeXML escapes markup characters in text content. Unlike xml.EscapeText
+// it leaves newlines and quotes alone so content stays readable.
+func escapeXML(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		switch {
+		case r == '&':
+			b.WriteString("&amp;")
+		case r == '<':
+			b.WriteString("&lt;")
+		case r == '>':
+			b.WriteString("&gt;")
+		case !isXMLChar(r):
+			b.WriteRune(utf8.RuneError)
+		default:
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// escapeXMLAttr escapes text for a double-quoted attribute value
+func escapeXMLAttr(s string) string {
+	return strings.ReplaceAll(escapeXML(s), `"`, "&quot;")
+}
+
+// cdataText prepares text for a CDATA section: a literal "]]>" is split
+// across two sections and characters XML cannot represent are replaced
+func cdataText(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		if !isXMLChar(r) {
+			r = utf8.RuneError
+		}
+		b.WriteRune(r)
+	}
+	return strings.ReplaceAll(b.String(), "]]>", "]]]]><![CDATA[>")
+}
+
+// isXMLChar reports whether r is allowed in an XML 1.0 document
+func isXMLChar(r rune) bool {
+	return r == 0x09 || r == 0x0A || r == 0x0D ||
+		r >= 0x20 && r <= 0xD7FF ||
+		r >= 0xE000 && r <= 0xFFFD ||
+		r >= 0x10000 && r <= 0x10FFFF
+}
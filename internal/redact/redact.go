@@ -0,0 +1,210 @@
+// Package redact detects common secret shapes in file content, such as
+// cloud keys, private keys and tokens, and replaces them with placeholders
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Finding is a secret found in a file
+type Finding struct {
+	Kind        string // The kind of secret, e.g. "aws-access-key"
+	Line        int    // 1-based line the secret starts on
+	Placeholder string // The text that replaced it
+}
+
+// Redactor replaces secrets with placeholders. The same secret always gets
+// the same placeholder, so references to it stay recognizable across files.
+// A Redactor is safe for concurrent use.
+type Redactor struct {
+	mu           sync.Mutex
+	placeholders map[string]string // Placeholder by kind and secret
+	counts       map[string]int    // Placeholders issued per kind
+}
+
+// New creates a Redactor
+func New() *Redactor {
+	return &Redactor{
+		placeholders: make(map[string]string),
+		counts:       make(map[string]int),
+	}
+}
+
+// span is a secret's position in the content
+type span struct {
+	start, end int
+	kind       string
+	priority   int // Index of the rule that found it
+}
+
+// Redact returns content with every detected secret replaced, and what was
+// found. Content without secrets is returned as is. The language, as the
+// language package detects it, tells configuration files, where short
+// unquoted values are secrets too, from code.
+func (r *Redactor) Redact(content []byte, language string) ([]byte, []Finding) {
+	out, findings, _ := r.RedactWithLines(content, language)
+	return out, findings
+}
+
+// RedactWithLines is Redact, also returning the line in content of each line
+// of the result when a secret spanning several lines, such as a private key,
+// was replaced by its one-line placeholder. Lines are nil otherwise.
+func (r *Redactor) RedactWithLines(content []byte, language string) ([]byte, []Finding, []int) {
+	spans := find(content, configLanguages[language])
+	if len(spans) == 0 {
+		return content, nil, nil
+	}
+
+	var out bytes.Buffer
+	out.Grow(len(content))
+	findings := make([]Finding, 0, len(spans))
+	lines := []int{1} // Line of each line of out, from its first byte
+	collapsed := false
+	pos, line := 0, 1
+	// copyText writes content[pos:end], which starts on line
+	copyText := func(end int) {
+		for _, c := range content[pos:end] {
+			if c == '\n' {
+				line++
+				lines = append(lines, line)
+			}
+		}
+		out.Write(content[pos:end])
+	}
+	for _, s := range spans {
+		copyText(s.start)
+		placeholder := r.placeholder(s.kind, content[s.start:s.end])
+		out.WriteString(placeholder)
+		findings = append(findings, Finding{Kind: s.kind, Line: line, Placeholder: placeholder})
+
+		if n := bytes.Count(content[s.start:s.end], []byte("\n")); n > 0 {
+			line += n
+			collapsed = true
+		}
+		pos = s.end
+	}
+	copyText(len(content))
+	if !collapsed {
+		return out.Bytes(), findings, nil
+	}
+	if out.Len() == 0 || out.Bytes()[out.Len()-1] == '\n' {
+		lines = lines[:len(lines)-1] // No line starts after the final newline
+	}
+	return out.Bytes(), findings, lines
+}
+
+// find returns the non-overlapping secret spans in content, in order, using
+// the rules for configuration files if config is set
+func find(content []byte, config bool) []span {
+	// Collect the hint positions of all rules in a single pass
+	candidates := make([][]int, len(rules))
+	for pos := 0; pos+1 < len(content); pos++ {
+		if !hintPrefixes[int(content[pos])<<8|int(content[pos+1])] {
+			continue
+		}
+		for _, ref := range hintsByFirstByte[content[pos]] {
+			rule := &rules[ref.rule]
+			if !rule.hintAt(content, pos, ref.hint) {
+				continue
+			}
+			if n := len(candidates[ref.rule]); n == 0 || candidates[ref.rule][n-1] != pos {
+				candidates[ref.rule] = append(candidates[ref.rule], pos)
+			}
+		}
+	}
+
+	var spans []span
+	for i, rule := range rules {
+		if len(candidates[i]) == 0 || rule.files == codeFiles && config || rule.files == configFiles && !config {
+			continue
+		}
+		if rule.multiline {
+			spans = rule.match(spans, i, content, 0, rule.pattern.FindAllSubmatchIndex(content, -1))
+			continue
+		}
+		for _, pos := range candidates[i] {
+			if rule.boundary && pos > 0 && isWordByte(content[pos-1]) {
+				continue
+			}
+			if rule.assigns && !assigned(content, pos) {
+				continue
+			}
+			end := bytes.IndexByte(content[pos:], '\n')
+			if end < 0 {
+				end = len(content)
+			} else {
+				end += pos
+			}
+			if m := rule.anchored.FindSubmatchIndex(content[pos:end]); m != nil {
+				spans = rule.match(spans, i, content[:end], pos, [][]int{m})
+			}
+		}
+	}
+
+	// Keep the first rule's match where spans overlap
+	sort.Slice(spans, func(i, j int) bool {
+		if spans[i].priority != spans[j].priority {
+			return spans[i].priority < spans[j].priority
+		}
+		return spans[i].start < spans[j].start
+	})
+	var kept []span
+	for _, s := range spans {
+		overlaps := false
+		for _, k := range kept {
+			if s.start < k.end && k.start < s.end {
+				overlaps = true
+				break
+			}
+		}
+		if !overlaps {
+			kept = append(kept, s)
+		}
+	}
+	sort.Slice(kept, func(i, j int) bool { return kept[i].start < kept[j].start })
+	return kept
+}
+
+// match appends the secrets in matches of the rule's pattern against
+// text[offset:] to spans
+func (r rule) match(spans []span, priority int, text []byte, offset int, matches [][]int) []span {
+	for _, m := range matches {
+		start, end := -1, -1
+		for g := r.group; start < 0 && 2*g < len(m); g++ {
+			start, end = m[2*g], m[2*g+1]
+		}
+		if start < 0 {
+			continue
+		}
+		start, end = start+offset, end+offset
+		if r.check != nil && !r.check(text, start, end) {
+			continue
+		}
+		spans = append(spans, span{start: start, end: end, kind: r.kind, priority: priority})
+	}
+	return spans
+}
+
+// isWordByte reports whether c is an ASCII letter, digit or underscore
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+// placeholder returns the placeholder for a secret, issuing a new one the
+// first time the secret is seen
+func (r *Redactor) placeholder(kind string, secret []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := kind + "\x00" + string(secret)
+	if p, ok := r.placeholders[key]; ok {
+		return p
+	}
+	r.counts[kind]++
+	p := fmt.Sprintf("[REDACTED:%s:%d]", kind, r.counts[kind])
+	r.placeholders[key] = p
+	return p
+}
@@ -0,0 +1,310 @@
+package redact
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+)
+
+// Kinds of secrets the rules detect
+const (
+	KindPrivateKey   = "private-key"
+	KindAWSAccessKey = "aws-access-key"
+	KindAWSSecretKey = "aws-secret-key"
+	KindGitHubToken  = "github-token"
+	KindJWT          = "jwt"
+	KindBearerToken  = "bearer-token"
+	KindSecret       = "secret" // High-entropy value assigned to a secret-sounding name
+)
+
+// scope is the kind of file a rule applies to
+type scope int
+
+const (
+	anyFile     scope = iota
+	codeFiles         // Source code and other files not in configLanguages
+	configFiles       // Files in configLanguages
+)
+
+// configLanguages are the languages, as the language package names them, of
+// files holding settings one per line, where values are often unquoted
+var configLanguages = map[string]bool{
+	"dotenv":     true,
+	"ini":        true,
+	"properties": true,
+}
+
+// rule detects one kind of secret
+type rule struct {
+	kind    string
+	files   scope
+	pattern *regexp.Regexp // Matches the secret, or its context with the secret in group
+	// Submatch holding the secret (0 for the whole match). Patterns with
+	// alternative forms hold it in the first submatch from group on that
+	// took part in the match.
+	group int
+
+	// Every match starts with one of hints, so the pattern is only tried at
+	// their occurrences, up to the end of the line. Multiline rules instead
+	// search all of the content once a hint occurs. With fold, hints are
+	// lowercase and matched ignoring ASCII case.
+	hints     []string
+	fold      bool
+	multiline bool
+	boundary  bool           // Matches must not follow a letter, digit or underscore
+	assigns   bool           // The hint starts a name that is assigned a value
+	anchored  *regexp.Regexp // pattern anchored at the start, set by init
+
+	// check confirms a candidate secret at content[start:end] (nil to accept all)
+	check func(content []byte, start, end int) bool
+}
+
+// rules are tried in order; on overlapping matches the earlier rule wins
+var rules = []rule{
+	{
+		kind:      KindPrivateKey,
+		hints:     []string{"PRIVATE KEY"},
+		multiline: true,
+		pattern:   regexp.MustCompile(`-----BEGIN[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----[\s\S]*?-----END[A-Z0-9 ]*PRIVATE KEY(?: BLOCK)?-----`),
+	},
+	{
+		kind:     KindAWSAccessKey,
+		boundary: true,
+		hints:    []string{"AKIA", "ASIA", "ABIA", "ACCA"},
+		pattern:  regexp.MustCompile(`(?:AKIA|ASIA|ABIA|ACCA)[A-Z0-9]{16}\b`),
+	},
+	{
+		kind:    KindAWSSecretKey,
+		hints:   []string{"aws"},
+		fold:    true,
+		assigns: true,
+		pattern: regexp.MustCompile(`(?i)aws_?secret_?(?:access_?)?key["']?\s*(?::=|=|:)\s*["']?([A-Za-z0-9/+=]{40})\b`),
+		group:   1,
+	},
+	{
+		kind:     KindGitHubToken,
+		boundary: true,
+		hints:    []string{"ghp_", "gho_", "ghu_", "ghs_", "ghr_", "github_pat_"},
+		pattern:  regexp.MustCompile(`(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`),
+	},
+	{
+		kind:     KindJWT,
+		boundary: true,
+		hints:    []string{"eyJ"},
+		pattern:  regexp.MustCompile(`eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`),
+	},
+	{
+		kind:     KindBearerToken,
+		boundary: true,
+		hints:    []string{"bearer"},
+		fold:     true,
+		pattern:  regexp.MustCompile(`(?i)bearer\s+([A-Za-z0-9\-._~+/]{20,}=*)`),
+		group:    1,
+		check: func(content []byte, start, end int) bool {
+			return highEntropy(content[start:end])
+		},
+	},
+	{
+		kind:    KindSecret,
+		files:   codeFiles,
+		hints:   secretHints,
+		fold:    true,
+		assigns: true,
+		// Unquoted values stop at operators and brackets, which end them in code
+		pattern: regexp.MustCompile(secretAssignment + `(?:` + quotedValue + `|([A-Za-z0-9+/=_\-.~!@#%:]{8,}))`),
+		group:   1,
+		check: func(content []byte, start, end int) bool {
+			return secretValue(content, start, end, minUnquotedLength)
+		},
+	},
+	{
+		kind:    KindSecret,
+		files:   configFiles,
+		hints:   secretHints,
+		fold:    true,
+		assigns: true,
+		// Unquoted values run to the end of the line or an inline comment
+		pattern: regexp.MustCompile(secretAssignment + `(?:` + quotedValue + `|([^\s"'` + "`" + `](?:[^\n]*?\S)?)(?:\s+[#;][^\n]*)?\s*$)`),
+		group:   1,
+		check: func(content []byte, start, end int) bool {
+			return secretValue(content, start, end, minValueLength)
+		},
+	},
+}
+
+// secretHints start the names the secret rules look for
+var secretHints = []string{
+	"passw", "pwd", "secret", "token", "credential",
+	"apikey", "api_key", "api-key", "accesskey", "access_key", "access-key",
+	"authkey", "auth_key", "auth-key", "privatekey", "private_key", "private-key",
+}
+
+// secretAssignment matches a secret-sounding name and the assignment after it
+const secretAssignment = `(?i)(?:passw|pwd|secret|token|credential|(?:api|access|auth|private)[_-]?key)` +
+	`[a-z0-9_.-]*["']?\s*(?::=|=|:)\s*`
+
+// quotedValue matches a value of at least minValueLength bytes up to its
+// closing quote, in one of three submatches
+const quotedValue = `"([^"\n]{8,})"|'([^'\n]{8,})'|` + "`([^`\\n]{8,})`"
+
+// assigned reports whether the name starting at content[pos] is followed by
+// an assignment (=, := or :), allowing for a closing quote and spaces. It is
+// a cheap filter run before the pattern.
+func assigned(content []byte, pos int) bool {
+	i := pos
+	for i < len(content) && (isWordByte(content[i]) || content[i] == '.' || content[i] == '-') {
+		i++
+	}
+	if i < len(content) && (content[i] == '"' || content[i] == '\'') {
+		i++
+	}
+	for i < len(content) && (content[i] == ' ' || content[i] == '\t') {
+		i++
+	}
+	return i < len(content) && (content[i] == '=' || content[i] == ':')
+}
+
+// hintRef is a hint of a rule
+type hintRef struct {
+	rule int
+	hint string
+}
+
+// hintsByFirstByte lists the hints starting with each byte, in both cases for
+// rules that fold case
+var hintsByFirstByte [256][]hintRef
+
+// hintPrefixes marks the first two bytes of every hint, in all case variants
+// for rules that fold case, so most positions are ruled out by one lookup
+var hintPrefixes [1 << 16]bool
+
+func init() {
+	for i := range rules {
+		r := &rules[i]
+		r.anchored = regexp.MustCompile(`^(?:` + r.pattern.String() + `)`)
+		for _, hint := range r.hints {
+			firsts, seconds := []byte{hint[0]}, []byte{hint[1]}
+			if r.fold {
+				firsts, seconds = bothCases(hint[0]), bothCases(hint[1])
+			}
+			for _, first := range firsts {
+				hintsByFirstByte[first] = append(hintsByFirstByte[first], hintRef{rule: i, hint: hint})
+				for _, second := range seconds {
+					hintPrefixes[int(first)<<8|int(second)] = true
+				}
+			}
+		}
+	}
+}
+
+// bothCases returns c in lower and upper case if it is an ASCII letter
+func bothCases(c byte) []byte {
+	if 'a' <= c && c <= 'z' {
+		return []byte{c, c - 'a' + 'A'}
+	}
+	return []byte{c}
+}
+
+// hintAt reports whether hint occurs in content at pos
+func (r *rule) hintAt(content []byte, pos int, hint string) bool {
+	if len(content)-pos < len(hint) {
+		return false
+	}
+	text := content[pos : pos+len(hint)]
+	if !r.fold {
+		return string(text) == hint
+	}
+	for i := 0; i < len(hint); i++ {
+		c := text[i]
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		if c != hint[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// placeholderWords mark values that are examples rather than real secrets
+var placeholderWords = [][]byte{
+	[]byte("example"), []byte("changeme"), []byte("placeholder"), []byte("redacted"), []byte("xxxx"), []byte("your"),
+}
+
+// minEntropy is the Shannon entropy in bits per character a value needs to
+// be taken for a secret; English words and identifiers mostly fall below it
+const minEntropy = 3.0
+
+// secretWords are the names the secret rule looks for; a value containing
+// one is most likely an identifier such as passwordField
+var secretWords = [][]byte{
+	[]byte("password"), []byte("passwd"), []byte("secret"), []byte("token"), []byte("key"), []byte("credential"),
+}
+
+// minValueLength is the length a value needs to be taken for a secret
+const minValueLength = 8
+
+// minUnquotedLength is the length an unquoted value in code needs to be taken
+// for a secret, as short unquoted values in code are usually identifiers
+const minUnquotedLength = 16
+
+// referencePattern matches dotted names such as process.env.TOKEN, which
+// refer to a secret rather than contain one
+var referencePattern = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_]*(?:\.[A-Za-z_][A-Za-z0-9_]*)+$`)
+
+// secretValue reports whether the value at content[start:end], assigned to a
+// secret-sounding name, looks like a real secret rather than code. Unquoted
+// values need minUnquoted bytes. Requiring both letters and digits rules out
+// most identifiers.
+func secretValue(content []byte, start, end, minUnquoted int) bool {
+	value := content[start:end]
+	if end < len(content) && bytes.IndexByte([]byte("({["), content[end]) >= 0 {
+		return false // A call, composite literal or index expression
+	}
+	quoted := start > 0 && bytes.IndexByte([]byte("\"'`"), content[start-1]) >= 0
+	if !quoted && len(value) < minUnquoted {
+		return false
+	}
+	if referencePattern.Match(value) {
+		return false
+	}
+	lower := bytes.ToLower(value)
+	for _, word := range secretWords {
+		if bytes.Contains(lower, word) {
+			return false
+		}
+	}
+	hasLetter := bytes.IndexFunc(value, func(r rune) bool { return r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' }) >= 0
+	hasDigit := bytes.IndexFunc(value, func(r rune) bool { return r >= '0' && r <= '9' }) >= 0
+	return hasLetter && hasDigit && highEntropy(value)
+}
+
+// highEntropy reports whether value looks random enough to be a secret
+func highEntropy(value []byte) bool {
+	lower := bytes.ToLower(value)
+	for _, word := range placeholderWords {
+		if bytes.Contains(lower, word) {
+			return false
+		}
+	}
+	return entropy(value) >= minEntropy
+}
+
+// entropy returns the Shannon entropy of s in bits per byte
+func entropy(s []byte) float64 {
+	if len(s) == 0 {
+		return 0
+	}
+	var counts [256]int
+	for _, c := range s {
+		counts[c]++
+	}
+	var h float64
+	for _, n := range counts {
+		if n > 0 {
+			p := float64(n) / float64(len(s))
+			h -= p * math.Log2(p)
+		}
+	}
+	return h
+}
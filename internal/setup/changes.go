@@ -0,0 +1,81 @@
+// Package setup provides initialization and configuration functions
+package setup
+
+import (
+	"path/filepath"
+
+	"github.com/bethropolis/dir-dumper/internal/git"
+)
+
+// GitChanges holds the files changed in a git repository, resolved from the
+// local object database and index, and renders their diffs on demand
+type GitChanges struct {
+	repo    *git.Repository
+	changes map[string]git.Change // Keyed by slash-separated path relative to the root
+	paths   []string
+}
+
+// LoadGitChanges resolves the changed files below rootDir
+func LoadGitChanges(rootDir string, opts git.ChangeOptions) (*GitChanges, error) {
+	repo, err := git.FindRepository(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	changes, err := repo.ChangedFiles(rootDir, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	gc := &GitChanges{
+		repo:    repo,
+		changes: make(map[string]git.Change, len(changes)),
+		paths:   make([]string, 0, len(changes)),
+	}
+	for _, change := range changes {
+		gc.changes[change.Path] = change
+		gc.paths = append(gc.paths, change.Path)
+	}
+	return gc, nil
+}
+
+// Paths returns the changed files, slash-separated and relative to the root
+func (gc *GitChanges) Paths() []string {
+	return gc.paths
+}
+
+// Diff returns the unified diff from the file's base version to content,
+// or "" if the file is unchanged or its base cannot be read
+func (gc *GitChanges) Diff(relativePath string, content []byte) string {
+	change, ok := gc.changes[filepath.ToSlash(relativePath)]
+	if !ok {
+		return ""
+	}
+
+	// Compare line endings as stored, or a CRLF checkout differs on every line
+	content = gc.repo.NormalizeContent(content)
+	name := filepath.ToSlash(relativePath)
+	if change.BaseID == "" {
+		return git.UnifiedDiff("", name, nil, content, git.DefaultDiffContext)
+	}
+	base, err := gc.repo.ReadBlob(change.BaseID)
+	if err != nil {
+		return ""
+	}
+	return git.UnifiedDiff(name, name, base, content, git.DefaultDiffContext)
+}
+
+// BinaryDiff returns the line standing in for the diff of a binary file,
+// whose content as dumped is a placeholder or an encoding rather than text,
+// or "" if the file is unchanged
+func (gc *GitChanges) BinaryDiff(relativePath string) string {
+	change, ok := gc.changes[filepath.ToSlash(relativePath)]
+	if !ok {
+		return ""
+	}
+	name := filepath.ToSlash(relativePath)
+	if change.BaseID == "" {
+		return git.BinaryDiff("", name)
+	}
+	return git.BinaryDiff(name, name)
+}
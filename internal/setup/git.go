@@ -0,0 +1,76 @@
+// Package setup provides initialization and configuration functions
+package setup
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/bethropolis/dir-dumper/internal/git"
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+// gitTrackedPaths lists the files git tracks below rootDir, read directly
+// from the index. If withUntracked is set, untracked files that are not
+// excluded by matcher (which should include .gitignore rules) are added.
+func gitTrackedPaths(rootDir string, withUntracked bool, matcher *ignore.IgnoreMatcher) ([]string, error) {
+	repo, err := git.FindRepository(rootDir)
+	if err != nil {
+		return nil, err
+	}
+
+	paths, err := repo.TrackedFiles(rootDir)
+	if err != nil {
+		return nil, err
+	}
+	if !withUntracked {
+		return paths, nil
+	}
+
+	tracked := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		tracked[p] = struct{}{}
+	}
+
+	walkErr := filepath.WalkDir(rootDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil // Unreadable entries are reported by the main walk if tracked
+		}
+		rel, relErr := filepath.Rel(rootDir, path)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		slashRel := filepath.ToSlash(rel)
+
+		if d.IsDir() {
+			// Never descend into git dirs or nested repositories
+			if d.Name() == ".git" || isNestedRepository(path) {
+				return filepath.SkipDir
+			}
+			if matcher.ShouldIgnore(rel, true) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if _, ok := tracked[slashRel]; ok {
+			return nil
+		}
+		if !matcher.ShouldIgnore(rel, false) {
+			paths = append(paths, slashRel)
+		}
+		return nil
+	})
+	if walkErr != nil {
+		return nil, fmt.Errorf("failed to list untracked files: %w", walkErr)
+	}
+
+	return paths, nil
+}
+
+// isNestedRepository reports whether dir is the root of another git repository
+func isNestedRepository(dir string) bool {
+	_, err := os.Stat(filepath.Join(dir, ".git"))
+	return err == nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 
 	"github.com/bethropolis/dir-dumper/internal/ignore"
+	"github.com/bethropolis/dir-dumper/internal/outline"
 	"github.com/bethropolis/dir-dumper/internal/utils"
 	"github.com/bethropolis/dir-dumper/internal/walker"
 )
@@ -23,18 +24,33 @@ type InfoLogger func(format string, args ...interface{})
 
 // WalkerConfig holds all parameters needed to configure a directory walker
 type WalkerConfig struct {
-	RootDir       string
-	Concurrent    bool
-	MaxWorkers    int
-	MaxFileSizeMB int64
-	Extensions    string
-	IgnoreHidden  bool
-	IgnoreGit     bool
-	CustomIgnore  string
-	ShowProgress  bool
-	Timeout       context.Context
-	Quiet         bool
-	Logger        Logger
+	RootDir        string
+	Concurrent     bool
+	MaxWorkers     int
+	MaxFileSizeMB  int64
+	MaxInFlightMB  int64 // Content concurrent workers may read ahead, in MB (0 = no limit)
+	MaxDepth       int
+	MaxFilesDir    int
+	BinaryPolicy   string
+	Extensions     string
+	FollowSymlinks bool
+	SymlinkTargets bool
+	Include        string
+	Outline        string // Extensions of files to reduce to an outline (comma-separated)
+	Head           int    // Lines kept from the start of each text file (0 = all)
+	Tail           int    // Lines kept from the end of each text file (0 = all)
+	Truncate       string // Per-file line limits (comma-separated pattern=limit)
+	IgnoreHidden   bool
+	IgnoreGit      bool
+	DumperIgnore   bool
+	CustomIgnore   string
+	GitTracked     bool
+	GitUntracked   bool
+	Paths          []string // Files to visit instead of walking the tree (nil = walk)
+	ShowProgress   bool
+	Timeout        context.Context
+	Quiet          bool
+	Logger         Logger
 }
 
 // ConfigureWalker sets up an ignore matcher and walker options based on the config
@@ -71,6 +87,78 @@ func ConfigureWalker(cfg WalkerConfig, infoLog InfoLogger) (
 		infoLog("No extension filtering (including all file types).")
 	}
 
+	// --- Parse include patterns ---
+	var includePatterns []string
+	if cfg.Include != "" {
+		for _, pattern := range strings.Split(cfg.Include, ",") {
+			if pattern = strings.TrimSpace(pattern); pattern != "" {
+				includePatterns = append(includePatterns, pattern)
+			}
+		}
+		infoLog("Only including files matching patterns: %v", includePatterns)
+	}
+
+	// --- Parse outline extensions ---
+	var outlineOptions []walker.Option
+	if cfg.Outline != "" {
+		var outlined []string
+		seen := make(map[string]bool)
+		for _, ext := range strings.Split(cfg.Outline, ",") {
+			ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+			if ext == "" || seen[ext] {
+				continue
+			}
+			seen[ext] = true
+			fn, ok := outline.For(ext)
+			if !ok {
+				return nil, nil, fmt.Errorf("invalid -outline value: no outline for .%s files (supported: %s)",
+					ext, strings.Join(outline.Extensions(), ", "))
+			}
+			outlineOptions = append(outlineOptions, walker.WithTransform(ext, func(_ string, content []byte) ([]byte, []int, error) {
+				return fn(content)
+			}))
+			outlined = append(outlined, "."+ext)
+		}
+		infoLog("Reducing %s files to an outline without function bodies.", strings.Join(outlined, ", "))
+	}
+
+	// --- Parse line limits ---
+	if cfg.Head < 0 {
+		return nil, nil, fmt.Errorf("invalid -head value: %d lines", cfg.Head)
+	}
+	if cfg.Tail < 0 {
+		return nil, nil, fmt.Errorf("invalid -tail value: %d lines", cfg.Tail)
+	}
+	lineLimit := walker.LineLimit{Head: cfg.Head, Tail: cfg.Tail}
+	truncateRules, err := walker.ParseTruncateRules(cfg.Truncate)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid -truncate value: %w", err)
+	}
+	if !lineLimit.IsZero() {
+		infoLog("Truncating text files to %s lines.", lineLimit)
+	}
+	for _, rule := range truncateRules {
+		if rule.Limit.IsZero() {
+			infoLog("Keeping files matching %s whole.", rule.Pattern)
+		} else {
+			infoLog("Truncating files matching %s to %s lines.", rule.Pattern, rule.Limit)
+		}
+	}
+
+	// --- Check the read-ahead limit ---
+	if cfg.MaxInFlightMB < 0 {
+		return nil, nil, fmt.Errorf("invalid -max-inflight value: %d MB", cfg.MaxInFlightMB)
+	}
+
+	// --- Parse binary handling policy ---
+	binaryPolicy, err := walker.ParseBinaryPolicy(cfg.BinaryPolicy)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid -binary value: %w", err)
+	}
+	if binaryPolicy != walker.BinarySkip {
+		infoLog("Binary files will be emitted as: %s", binaryPolicy)
+	}
+
 	// Print effective settings
 	if cfg.IgnoreHidden {
 		infoLog("Ignoring hidden files/directories (starting with '.').")
@@ -78,16 +166,52 @@ func ConfigureWalker(cfg WalkerConfig, infoLog InfoLogger) (
 		infoLog("Including hidden files/directories.")
 	}
 
+	if !cfg.DumperIgnore {
+		infoLog("Not honoring .dumperignore/.dirdumperignore files.")
+	}
+
+	if cfg.SymlinkTargets {
+		infoLog("Emitting symlinks as 'path -> target' entries.")
+	} else if cfg.FollowSymlinks {
+		infoLog("Following symlinks.")
+	}
+
 	// --- Initialize ignore matcher ---
 	ignoreOptions := []ignore.Option{
 		ignore.WithLogger(cfg.Logger),
 		ignore.WithHiddenIgnore(cfg.IgnoreHidden),
 		ignore.WithGitIgnore(cfg.IgnoreGit),
+		ignore.WithDumperIgnore(cfg.DumperIgnore),
 	}
 	if len(customPatterns) > 0 {
 		ignoreOptions = append(ignoreOptions, ignore.WithCustomRules(customPatterns))
 	}
 
+	// --- Collect paths from the git index if requested ---
+	gitPaths := cfg.Paths
+	if gitPaths != nil {
+		// Changed files are tracked, so .gitignore rules do not apply to them
+		ignoreOptions = append(ignoreOptions, ignore.WithGitignoreRules(false))
+	} else if cfg.GitTracked || cfg.GitUntracked {
+		// Untracked files are filtered with the full rules, including .gitignore
+		untrackedMatcher, err := ignore.New(cfg.RootDir, ignoreOptions...)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error initializing ignore rules: %w", err)
+		}
+		gitPaths, err = gitTrackedPaths(cfg.RootDir, cfg.GitUntracked, untrackedMatcher)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading git index: %w", err)
+		}
+		if cfg.GitUntracked {
+			infoLog("Using %d git tracked and untracked (not ignored) files.", len(gitPaths))
+		} else {
+			infoLog("Using %d git tracked files.", len(gitPaths))
+		}
+
+		// Tracked files are included even if they match a .gitignore pattern
+		ignoreOptions = append(ignoreOptions, ignore.WithGitignoreRules(false))
+	}
+
 	matcher, err := ignore.New(cfg.RootDir, ignoreOptions...)
 	if err != nil {
 		return nil, nil, fmt.Errorf("error initializing ignore rules: %w", err)
@@ -101,6 +225,9 @@ func ConfigureWalker(cfg WalkerConfig, infoLog InfoLogger) (
 		walker.WithLogger(cfg.Logger),
 		walker.WithConcurrency(cfg.Concurrent),
 		walker.WithMaxWorkers(cfg.MaxWorkers),
+		walker.WithBinaryPolicy(binaryPolicy),
+		walker.WithFollowSymlinks(cfg.FollowSymlinks),
+		walker.WithSymlinkTargets(cfg.SymlinkTargets),
 	)
 
 	// Add progress option if enabled
@@ -138,6 +265,11 @@ func ConfigureWalker(cfg WalkerConfig, infoLog InfoLogger) (
 		}))
 	}
 
+	// Visit only the files from the git index
+	if gitPaths != nil {
+		walkOptions = append(walkOptions, walker.WithPaths(gitPaths))
+	}
+
 	// Add extension filtering if specified
 	if len(fileExtensions) > 0 {
 		var extList []string
@@ -147,6 +279,19 @@ func ConfigureWalker(cfg WalkerConfig, infoLog InfoLogger) (
 		walkOptions = append(walkOptions, walker.WithExtensions(extList))
 	}
 
+	// Add outline transforms if specified
+	walkOptions = append(walkOptions, outlineOptions...)
+
+	// Add line limits if specified
+	if !lineLimit.IsZero() || len(truncateRules) > 0 {
+		walkOptions = append(walkOptions, walker.WithTruncation(lineLimit, truncateRules))
+	}
+
+	// Add include pattern filtering if specified
+	if len(includePatterns) > 0 {
+		walkOptions = append(walkOptions, walker.WithIncludePatterns(includePatterns))
+	}
+
 	// Convert MB to bytes for MaxFileSize if specified
 	if cfg.MaxFileSizeMB > 0 {
 		maxSizeBytes := cfg.MaxFileSizeMB * 1024 * 1024
@@ -154,6 +299,22 @@ func ConfigureWalker(cfg WalkerConfig, infoLog InfoLogger) (
 		infoLog("Ignoring files larger than %d MB.", cfg.MaxFileSizeMB)
 	}
 
+	// Bound the content concurrent workers read ahead of the output
+	if cfg.Concurrent && cfg.MaxInFlightMB > 0 {
+		walkOptions = append(walkOptions, walker.WithMaxInFlightBytes(cfg.MaxInFlightMB*1024*1024))
+		cfg.Logger.Debug("Limiting content read ahead by workers to %d MB", cfg.MaxInFlightMB)
+	}
+
+	// Bound the walk by depth and files per directory if specified
+	if cfg.MaxDepth > 0 {
+		walkOptions = append(walkOptions, walker.WithMaxDepth(cfg.MaxDepth))
+		infoLog("Only including files up to %d level(s) deep.", cfg.MaxDepth)
+	}
+	if cfg.MaxFilesDir > 0 {
+		walkOptions = append(walkOptions, walker.WithMaxFilesPerDir(cfg.MaxFilesDir))
+		infoLog("Including at most %d file(s) per directory.", cfg.MaxFilesDir)
+	}
+
 	// Add walk context option if timeout is specified
 	if cfg.Timeout != nil {
 		walkOptions = append(walkOptions, walker.WithContext(cfg.Timeout))
@@ -0,0 +1,73 @@
+package strip
+
+import (
+	"bytes"
+	"go/scanner"
+	"go/token"
+)
+
+// goSpans returns the comments and raw strings of Go source, scanned with
+// go/scanner. Directives and the cgo preamble before import "C" are kept.
+// It reports false if the source does not scan cleanly.
+func goSpans(content []byte) ([]span, bool) {
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(content))
+	ok := true
+	var s scanner.Scanner
+	s.Init(file, content, func(token.Position, string) { ok = false }, scanner.ScanComments)
+
+	var spans []span
+	since := 0    // First of the comments since the last token
+	preamble := 0 // First of the comments before the last import
+	var prev token.Token
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+		start := file.Offset(pos)
+		if tok == token.COMMENT {
+			end := goCommentEnd(content, start)
+			spans = append(spans, span{start: start, end: end, comment: true, keep: goDirective(content[start:end])})
+			continue
+		}
+
+		switch tok {
+		case token.IMPORT:
+			preamble = since
+		case token.STRING:
+			if prev == token.IMPORT && lit == `"C"` {
+				for k := preamble; k < len(spans); k++ {
+					spans[k].keep = true
+				}
+			}
+			if content[start] == '`' {
+				// Raw strings may span lines
+				end := len(content)
+				if n := bytes.IndexByte(content[start+1:], '`'); n >= 0 {
+					end = start + 1 + n + 1
+				}
+				spans = append(spans, span{start: start, end: end})
+			}
+		}
+		prev = tok
+		since = len(spans)
+	}
+	return spans, ok
+}
+
+// goCommentEnd returns the end of the Go comment at content[start]. Line
+// comments end before the line break, like those of the generic lexer.
+func goCommentEnd(content []byte, start int) int {
+	if bytes.HasPrefix(content[start:], []byte("//")) {
+		end := lineEnd(content, start)
+		for end > start && (content[end-1] == '\n' || content[end-1] == '\r') {
+			end--
+		}
+		return end
+	}
+	if n := bytes.Index(content[start+2:], []byte("*/")); n >= 0 {
+		return start + 2 + n + 2
+	}
+	return len(content)
+}
@@ -0,0 +1,104 @@
+package strip
+
+import (
+	"bytes"
+)
+
+// rawElements are the elements whose text is not markup: script and style
+// hold code scanned in its own language, pre and textarea keep their text
+var rawElements = map[string]string{
+	"script":   "javascript",
+	"style":    "css",
+	"pre":      "",
+	"textarea": "",
+}
+
+// markupSpans returns the <!-- --> comments of HTML or XML content. CDATA
+// sections are literals, and so is the text of pre and textarea elements;
+// script and style elements are scanned as JavaScript and CSS. Conditional
+// comments are kept.
+func markupSpans(content []byte) []span {
+	var spans []span
+	lower := asciiLower(content)
+	for i := 0; i < len(content); {
+		n := bytes.IndexByte(content[i:], '<')
+		if n < 0 {
+			break
+		}
+		i += n
+		rest := content[i:]
+
+		switch {
+		case bytes.HasPrefix(rest, []byte("<!--")):
+			end := len(content)
+			if n := bytes.Index(rest[4:], []byte("-->")); n >= 0 {
+				end = i + 4 + n + 3
+			}
+			conditional := bytes.HasPrefix(rest, []byte("<!--[if")) || bytes.HasPrefix(rest, []byte("<!--<![endif]"))
+			spans = append(spans, span{start: i, end: end, comment: true, keep: conditional})
+			i = end
+		case bytes.HasPrefix(rest, []byte("<![CDATA[")):
+			end := len(content)
+			if n := bytes.Index(rest, []byte("]]>")); n >= 0 {
+				end = i + n + 3
+			}
+			spans = append(spans, span{start: i, end: end})
+			i = end
+		default:
+			name := rawElementAt(lower, i)
+			if name == "" {
+				i++
+				continue
+			}
+			open := bytes.IndexByte(content[i:], '>')
+			if open < 0 {
+				return spans
+			}
+			body := i + open + 1
+			end := len(content)
+			if n := bytes.Index(lower[body:], []byte("</"+name)); n >= 0 {
+				end = body + n
+			}
+			if language := rawElements[name]; language != "" {
+				for _, s := range syntaxes[language].spans(content[body:end]) {
+					s.start += body
+					s.end += body
+					spans = append(spans, s)
+				}
+			} else if end > body {
+				spans = append(spans, span{start: body, end: end})
+			}
+			i = end
+		}
+	}
+	return spans
+}
+
+// rawElementAt returns the name of the raw element whose start tag is at
+// lower[i], or "" if there is none
+func rawElementAt(lower []byte, i int) string {
+	for name := range rawElements {
+		rest := lower[i+1:]
+		if !bytes.HasPrefix(rest, []byte(name)) || len(rest) == len(name) {
+			continue
+		}
+		switch rest[len(name)] {
+		case '>', ' ', '\t', '\r', '\n', '/':
+			return name
+		}
+	}
+	return ""
+}
+
+// asciiLower returns a copy of b with ASCII letters in lower case, keeping
+// every other byte so offsets stay the same
+func asciiLower(b []byte) []byte {
+	lower := make([]byte, len(b))
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			c += 'a' - 'A'
+		}
+		lower[i] = c
+	}
+	return lower
+}
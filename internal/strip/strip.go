@@ -0,0 +1,313 @@
+// Package strip removes comments and redundant blank lines from source code
+// to make dumps smaller. Each language is scanned by a lexer that knows its
+// comments and literals, so strings are left intact.
+package strip
+
+import (
+	"bytes"
+)
+
+// Options configures stripping
+type Options struct {
+	KeepLicense bool // Keep comments with a license at the start of a file
+}
+
+// span is a comment or a literal in the content
+type span struct {
+	start, end int
+	comment    bool // A comment rather than a literal
+	keep       bool // A comment that must not be removed, such as a directive
+}
+
+// markup lists the languages scanned for <!-- --> comments
+var markup = map[string]bool{
+	"html":   true,
+	"xml":    true,
+	"vue":    true,
+	"svelte": true,
+}
+
+// Supported reports whether comments can be stripped from files in language
+func Supported(language string) bool {
+	_, ok := syntaxes[language]
+	return ok || markup[language]
+}
+
+// Comments returns content without comments, with trailing whitespace removed
+// and runs of blank lines collapsed into one. Literals such as strings are
+// left as they are. Content in a language without a lexer is returned
+// unchanged.
+func Comments(content []byte, language string, opts Options) []byte {
+	stripped, _ := CommentsWithLines(content, language, opts)
+	return stripped
+}
+
+// CommentsWithLines is Comments that also returns the number of the line of
+// content each line of the result starts in, so line numbers can refer to
+// the original. Lines are nil if content is returned unchanged.
+func CommentsWithLines(content []byte, language string, opts Options) ([]byte, []int) {
+	var spans []span
+	switch {
+	case language == "go":
+		var ok bool
+		if spans, ok = goSpans(content); !ok {
+			// Not valid Go, but most likely close enough for the generic lexer
+			spans = syntaxes["go"].spans(content)
+		}
+	case markup[language]:
+		spans = markupSpans(content)
+	default:
+		s, ok := syntaxes[language]
+		if !ok {
+			return content, nil
+		}
+		spans = s.spans(content)
+	}
+
+	if opts.KeepLicense {
+		keepLicense(content, spans)
+	}
+	return rewrite(content, spans)
+}
+
+// licenseWords mark a comment as a license
+var licenseWords = [][]byte{
+	[]byte("copyright"), []byte("license"), []byte("licence"), []byte("spdx-license-identifier"), []byte("all rights reserved"),
+}
+
+// keepLicense marks the blocks of comments at the start of content that hold
+// a license as kept. Blocks are separated by blank lines, and may follow a
+// first line such as a shebang or <?php.
+func keepLicense(content []byte, spans []span) {
+	pos := 0
+	if bytes.HasPrefix(content, []byte("#!")) || bytes.HasPrefix(content, []byte("<?")) {
+		pos = lineEnd(content, 0)
+	}
+
+	block := -1 // First span of the current block
+	flush := func(end int) {
+		if block < 0 {
+			return
+		}
+		for k := block; k < end; k++ {
+			if isLicense(content[spans[k].start:spans[k].end]) {
+				for j := block; j < end; j++ {
+					spans[j].keep = true
+				}
+				break
+			}
+		}
+		block = -1
+	}
+
+	k := 0
+	for ; k < len(spans) && spans[k].comment; k++ {
+		gap := content[pos:spans[k].start]
+		if len(bytes.TrimSpace(gap)) > 0 {
+			break
+		}
+		if bytes.Count(gap, []byte("\n")) > 1 {
+			flush(k)
+		}
+		if block < 0 {
+			block = k
+		}
+		pos = spans[k].end
+	}
+	flush(k)
+}
+
+// isLicense reports whether a comment holds a license or copyright notice
+func isLicense(comment []byte) bool {
+	lower := bytes.ToLower(comment)
+	for _, word := range licenseWords {
+		if bytes.Contains(lower, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// rewrite returns content without the comments in spans that are not kept,
+// and the line of content each line of the result starts in. Outside
+// literals, trailing whitespace is removed, lines left empty by a removed
+// comment are dropped and runs of blank lines are collapsed.
+func rewrite(content []byte, spans []span) ([]byte, []int) {
+	w := rewriter{out: make([]byte, 0, len(content)), blank: true, src: 1}
+	pos := 0
+	for _, s := range spans {
+		w.code(content[pos:s.start])
+		if s.comment && !s.keep {
+			w.remove(content, s)
+		} else {
+			w.literal(content[s.start:s.end])
+		}
+		pos = s.end
+	}
+	w.code(content[pos:])
+	w.finish()
+	return w.out, w.lines
+}
+
+// rewriter builds the stripped content line by line
+type rewriter struct {
+	out       []byte
+	lineStart int  // Start of the current line in out
+	protected int  // out[:protected] ends with a literal, which must not change
+	removed   bool // A comment was removed from the current line
+	blank     bool // The last line written is blank, or nothing was written yet
+	skipSpace bool // Drop the spaces following a comment removed from the start of a line
+
+	src   int   // Line of content being read
+	first int   // Line of content the current line's first code is on (0 until some is written)
+	lines []int // Line of content each line written starts in
+}
+
+// code appends code, ending every line it completes
+func (w *rewriter) code(text []byte) {
+	if w.skipSpace {
+		text = bytes.TrimLeft(text, " \t")
+		w.skipSpace = false
+	}
+	for {
+		nl := bytes.IndexByte(text, '\n')
+		if nl < 0 {
+			w.note(text)
+			w.out = append(w.out, text...)
+			return
+		}
+		w.note(text[:nl])
+		w.out = append(w.out, text[:nl]...)
+		w.endLine()
+		w.src++
+		text = text[nl+1:]
+	}
+}
+
+// note records the current source line as the start of the line being
+// written, if text is its first code
+func (w *rewriter) note(text []byte) {
+	if w.first == 0 && len(bytes.TrimLeft(text, " \t\r")) > 0 {
+		w.first = w.src
+	}
+}
+
+// start returns the line of content the line being written starts in
+func (w *rewriter) start() int {
+	if w.first > 0 {
+		return w.first
+	}
+	return w.src
+}
+
+// remove drops the comment s, keeping the code on both sides of it apart
+func (w *rewriter) remove(content []byte, s span) {
+	w.removed = true
+	w.src += bytes.Count(content[s.start:s.end], []byte("\n"))
+	line := w.out[max(w.lineStart, w.protected):]
+	if len(bytes.TrimLeft(line, " \t")) == 0 && w.protected <= w.lineStart {
+		w.skipSpace = true // Only indentation precedes the comment
+		return
+	}
+	if s.end < len(content) && !isSpace(content[s.end]) && len(w.out) > 0 && !isSpace(w.out[len(w.out)-1]) {
+		w.out = append(w.out, ' ')
+	}
+}
+
+// literal appends text that is kept exactly as it is
+func (w *rewriter) literal(text []byte) {
+	w.skipSpace = false
+	if w.first == 0 {
+		w.first = w.src
+	}
+	// Lines ending within the literal are kept as they are
+	for i := bytes.Count(text, []byte("\n")); i > 0; i-- {
+		w.lines = append(w.lines, w.first)
+		w.src++
+		w.first = w.src
+	}
+	w.out = append(w.out, text...)
+	w.protected = len(w.out)
+	if nl := bytes.LastIndexByte(text, '\n'); nl >= 0 {
+		w.lineStart = len(w.out) - len(text) + nl + 1
+		w.removed = false
+	}
+	w.blank = false
+}
+
+// endLine ends the current line, trimming its trailing whitespace. A blank
+// line is dropped if it held only comments or follows another blank line.
+func (w *rewriter) endLine() {
+	floor := max(w.lineStart, w.protected)
+	end := len(w.out)
+	cr := end > floor && w.out[end-1] == '\r'
+	if cr {
+		end--
+	}
+	for end > floor && (w.out[end-1] == ' ' || w.out[end-1] == '\t') {
+		end--
+	}
+	blank := end == w.lineStart
+	w.out = w.out[:end]
+
+	if !blank || !(w.removed || w.blank) {
+		if cr {
+			w.out = append(w.out, '\r')
+		}
+		w.out = append(w.out, '\n')
+		w.blank = blank
+		w.lines = append(w.lines, w.start())
+	}
+	w.lineStart = len(w.out)
+	w.removed = false
+	w.skipSpace = false
+	w.first = 0
+}
+
+// finish trims the last line and drops blank lines at the end, along with
+// their line numbers
+func (w *rewriter) finish() {
+	end := len(w.out)
+	for end > max(w.lineStart, w.protected) && isSpace(w.out[end-1]) {
+		end--
+	}
+	w.out = w.out[:end]
+
+	for {
+		n := len(w.out)
+		switch {
+		case n >= 2 && n-1 >= w.protected && string(w.out[n-2:]) == "\n\n":
+			w.out = w.out[:n-1]
+		case n >= 3 && n-2 >= w.protected && string(w.out[n-3:]) == "\n\r\n":
+			w.out = w.out[:n-2]
+		default:
+			// Blank lines dropped from the end have no line left to map
+			lines := bytes.Count(w.out, []byte("\n"))
+			if n > 0 && w.out[n-1] != '\n' {
+				w.lines = append(w.lines[:lines], w.start())
+			} else {
+				w.lines = w.lines[:lines]
+			}
+			return
+		}
+	}
+}
+
+// isSpace reports whether c is ASCII whitespace
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+// isWordByte reports whether c is an ASCII letter, digit or underscore
+func isWordByte(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '_'
+}
+
+// lineEnd returns the index just past the newline ending the line at
+// content[i], or the length of content for the last line
+func lineEnd(content []byte, i int) int {
+	if nl := bytes.IndexByte(content[i:], '\n'); nl >= 0 {
+		return i + nl + 1
+	}
+	return len(content)
+}
@@ -0,0 +1,117 @@
+package strip
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCommentsWithLines(t *testing.T) {
+	tests := []struct {
+		name     string
+		language string
+		content  string
+		want     string
+		lines    []int
+	}{
+		{
+			name: "line comments", language: "go",
+			content: "package a\n\n// Doc comment\n// more\nfunc f() {} // trailing\n",
+			want:    "package a\n\nfunc f() {}\n",
+			lines:   []int{1, 2, 5},
+		},
+		{
+			name: "block comment before code", language: "c",
+			content: "int a;\n/* one\n   two */ int b;\nint c;\n",
+			want:    "int a;\nint b;\nint c;\n",
+			lines:   []int{1, 3, 4},
+		},
+		{
+			name: "block comment inside a line", language: "c",
+			content: "int a = /* x\n y */1;\nint b;\n",
+			want:    "int a = 1;\nint b;\n",
+			lines:   []int{1, 3},
+		},
+		{
+			name: "multi-line literal", language: "go",
+			content: "var s = `a\n// not a comment\nb`\n// gone\nvar t = 1\n",
+			want:    "var s = `a\n// not a comment\nb`\nvar t = 1\n",
+			lines:   []int{1, 2, 3, 5},
+		},
+		{
+			name: "collapsed blank lines", language: "python",
+			content: "a = 1\n\n\n# gone\n\nb = 2\n\n\n",
+			want:    "a = 1\n\nb = 2\n",
+			lines:   []int{1, 2, 6},
+		},
+		{
+			name: "no final newline", language: "python",
+			content: "# gone\na = 1  # gone too",
+			want:    "a = 1",
+			lines:   []int{2},
+		},
+		{
+			name: "crlf", language: "c",
+			content: "// gone\r\nint a;\r\n\r\nint b; // gone\r\n",
+			want:    "int a;\r\n\r\nint b;\r\n",
+			lines:   []int{2, 3, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, lines := CommentsWithLines([]byte(tt.content), tt.language, Options{})
+			if string(got) != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(lines, tt.lines) {
+				t.Errorf("lines = %v, want %v", lines, tt.lines)
+			}
+		})
+	}
+}
+
+// TestCommentsWithLinesPointAtSource checks on a larger file that every
+// line of the result is found on the source line it is mapped to
+func TestCommentsWithLinesPointAtSource(t *testing.T) {
+	source := `// Package demo is a demo.
+package demo
+
+import "fmt" // for printing
+
+/*
+Block comment
+*/
+type T struct {
+	A int // field
+	/* inline */ B string
+}
+
+// F does things.
+func F() {
+	s := "// not a comment"
+	r := ` + "`raw\n/* kept */\nstring`" + `
+
+
+	fmt.Println(s, r) /* done */
+}
+`
+	got, lines := CommentsWithLines([]byte(source), "go", Options{})
+	sourceLines := strings.Split(source, "\n")
+	gotLines := strings.Split(strings.TrimSuffix(string(got), "\n"), "\n")
+	if len(gotLines) != len(lines) {
+		t.Fatalf("%d lines mapped for %d lines of output:\n%s", len(lines), len(gotLines), got)
+	}
+	for i, line := range gotLines {
+		if !strings.Contains(sourceLines[lines[i]-1], strings.TrimSpace(line)) {
+			t.Errorf("output line %d %q is mapped to source line %d %q", i+1, line, lines[i], sourceLines[lines[i]-1])
+		}
+	}
+}
+
+func TestCommentsWithLinesUnsupported(t *testing.T) {
+	content := []byte("# not stripped\n")
+	got, lines := CommentsWithLines(content, "unknown", Options{})
+	if string(got) != string(content) || lines != nil {
+		t.Errorf("CommentsWithLines = %q, %v, want the content unchanged and nil lines", got, lines)
+	}
+}
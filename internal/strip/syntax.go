@@ -0,0 +1,667 @@
+package strip
+
+import (
+	"bytes"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// lineRule says where a line comment marker starts a comment
+type lineRule int
+
+const (
+	anywhere   lineRule = iota // Anywhere outside a literal
+	afterSpace                 // Only at the start of a word, as in shell
+	lineStart                  // Only as the first thing on a line
+)
+
+// heredocStyle is the here-document syntax of a language
+type heredocStyle int
+
+const (
+	noHeredoc    heredocStyle = iota
+	shellHeredoc              // <<EOF, <<-EOF, << 'EOF'
+	rubyHeredoc               // <<EOF, <<~EOF, <<-EOF, without a space
+)
+
+// syntax describes the comments and literals of a language for the generic
+// lexer. Everything that is neither is code.
+type syntax struct {
+	line      []string  // Markers starting a comment that runs to the end of the line
+	rule      lineRule  // Where line markers start a comment
+	block     [2]string // Block comment delimiters, or empty
+	nested    bool      // Block comments nest
+	quotes    string    // Bytes delimiting strings with backslash escapes
+	raw       string    // Bytes delimiting strings without escapes
+	multiline bool      // Strings may span lines
+	triple    bool      // Tripled quotes delimit strings that may span lines
+	chars     bool      // ' delimits character literals, told apart from Rust lifetimes
+	template  bool      // ` delimits strings with ${} interpolation
+	regex     bool      // / may start a regular expression literal
+	verbatim  bool      // @"..." strings with "" escapes, as in C#
+	cppRaw    bool      // R"x(...)x" raw strings
+	rustRaw   bool      // r#"..."# raw strings
+	subst     bool      // $(...) in double-quoted strings holds code, as in shell
+	heredoc   heredocStyle
+	yaml      bool                      // Quotes only start a scalar; | and > start block scalars
+	php       bool                      // Only code between <?php and ?> is scanned
+	url       bool                      // url(...) is a literal, as in CSS
+	keep      func(comment []byte) bool // Comments that must be kept, such as directives
+}
+
+var (
+	slashes  = []string{"//"}
+	hashes   = []string{"#"}
+	cComment = [2]string{"/*", "*/"}
+
+	javascript = &syntax{line: slashes, block: cComment, quotes: `"'`, template: true, regex: true}
+	shell      = &syntax{line: hashes, rule: afterSpace, quotes: `"`, raw: "'`", multiline: true, subst: true, heredoc: shellHeredoc}
+	scss       = &syntax{line: slashes, block: cComment, quotes: `"'`, url: true}
+	ignoreFile = &syntax{line: hashes, rule: lineStart}
+)
+
+// syntaxes holds the generic lexer's syntax of each language, by the names
+// of the language package. Go is scanned by go/scanner and only falls back
+// to its entry here.
+var syntaxes = map[string]*syntax{
+	"c":          {line: slashes, block: cComment, quotes: `"`, chars: true},
+	"cpp":        {line: slashes, block: cComment, quotes: `"`, chars: true, cppRaw: true},
+	"objectivec": {line: slashes, block: cComment, quotes: `"`, chars: true},
+	"csharp":     {line: slashes, block: cComment, quotes: `"`, chars: true, triple: true, verbatim: true},
+	"java":       {line: slashes, block: cComment, quotes: `"`, chars: true, triple: true},
+	"kotlin":     {line: slashes, block: cComment, nested: true, quotes: `"`, chars: true, triple: true},
+	"scala":      {line: slashes, block: cComment, nested: true, quotes: `"`, chars: true, triple: true},
+	"groovy":     {line: slashes, block: cComment, quotes: `"'`, triple: true},
+	"swift":      {line: slashes, block: cComment, nested: true, quotes: `"`, triple: true},
+	"dart":       {line: slashes, block: cComment, nested: true, quotes: `"'`, triple: true},
+	"rust":       {line: slashes, block: cComment, nested: true, quotes: `"`, multiline: true, chars: true, rustRaw: true},
+	"go":         {line: slashes, block: cComment, quotes: `"`, raw: "`", multiline: true, chars: true, keep: goDirective},
+	"protobuf":   {line: slashes, block: cComment, quotes: `"'`},
+	"jsonc":      {line: slashes, block: cComment, quotes: `"`},
+	"javascript": javascript,
+	"jsx":        javascript,
+	"typescript": javascript,
+	"tsx":        javascript,
+	"php":        {line: []string{"//", "#"}, block: cComment, quotes: `"'`, multiline: true, php: true},
+	"css":        {block: cComment, quotes: `"'`, url: true},
+	"scss":       scss,
+	"less":       scss,
+	"sql":        {line: []string{"--"}, block: cComment, raw: `'"`, multiline: true},
+
+	"python":        {line: hashes, quotes: `"'`, triple: true, keep: magicComment},
+	"ruby":          {line: hashes, quotes: `"'`, multiline: true, regex: true, heredoc: rubyHeredoc, keep: magicComment},
+	"r":             {line: hashes, quotes: `"'`, multiline: true},
+	"sh":            shell,
+	"bash":          shell,
+	"zsh":           shell,
+	"fish":          shell,
+	"cmake":         {line: hashes, quotes: `"`, multiline: true},
+	"toml":          {line: hashes, quotes: `"`, raw: "'", triple: true},
+	"yaml":          {line: hashes, rule: afterSpace, quotes: `"`, raw: "'", multiline: true, yaml: true},
+	"dockerfile":    {line: hashes, rule: lineStart, heredoc: shellHeredoc, keep: dockerDirective},
+	"gitignore":     ignoreFile,
+	"gitattributes": ignoreFile,
+	"ini":           {line: []string{";", "#"}, rule: lineStart},
+}
+
+// goDirective reports whether a Go comment is a directive for the toolchain
+func goDirective(comment []byte) bool {
+	for _, prefix := range []string{"//go:", "//line ", "//export ", "//extern ", "// +build"} {
+		if bytes.HasPrefix(comment, []byte(prefix)) {
+			return true
+		}
+	}
+	return false
+}
+
+// magicCommentPattern matches Python and Ruby comments that change how a
+// file is read
+var magicCommentPattern = regexp.MustCompile(`^#.*\b(?:coding[:=]|frozen_string_literal:)`)
+
+// magicComment reports whether a comment is a Python or Ruby magic comment
+func magicComment(comment []byte) bool {
+	return magicCommentPattern.Match(comment)
+}
+
+// dockerDirectivePattern matches Dockerfile parser directives
+var dockerDirectivePattern = regexp.MustCompile(`^#\s*(?i:syntax|escape|check)\s*=`)
+
+// dockerDirective reports whether a comment is a Dockerfile parser directive
+func dockerDirective(comment []byte) bool {
+	return dockerDirectivePattern.Match(comment)
+}
+
+// heredoc is a here-document whose body starts on the next line
+type heredoc struct {
+	delim    []byte
+	indented bool // The closing delimiter may be indented
+}
+
+// spans returns the comments and literals of content, in order
+func (s *syntax) spans(content []byte) []span {
+	var spans []span
+	var pending []heredoc
+	yamlIndent := -1 // Indentation of the line starting a YAML block scalar
+	last := -1       // Index of the last byte of code, to tell regexes from division
+
+	i := 0
+	if bytes.HasPrefix(content, []byte("#!")) {
+		i = lineEnd(content, 0) // A shebang is not a comment
+	}
+	if s.php {
+		i = phpOpen(content, i)
+	}
+	for i < len(content) {
+		c := content[i]
+		if c == '\n' && (len(pending) > 0 || yamlIndent >= 0) {
+			// Here-document bodies and block scalars start on the next line
+			end := i + 1
+			if len(pending) > 0 {
+				end = heredocEnd(content, end, pending)
+			} else {
+				end = yamlBlockEnd(content, end, yamlIndent)
+			}
+			spans = append(spans, span{start: i + 1, end: end})
+			pending, yamlIndent = nil, -1
+			i = end
+			continue
+		}
+		if isSpace(c) {
+			i++
+			continue
+		}
+		if s.php && bytes.HasPrefix(content[i:], []byte("?>")) {
+			i = phpOpen(content, i+2)
+			last = -1
+			continue
+		}
+
+		if end := s.comment(content, i); end > i {
+			comment := span{start: i, end: end, comment: true}
+			comment.keep = s.keep != nil && s.keep(content[i:end])
+			spans = append(spans, comment)
+			i = end
+			continue
+		}
+		if end := s.literal(content, i, last); end > i {
+			spans = append(spans, span{start: i, end: end})
+			last = end - 1
+			i = end
+			continue
+		}
+		if s.yaml && (c == '|' || c == '>') && yamlBlockStart(content, i) {
+			yamlIndent = indentWidth(content, i)
+		}
+		if s.heredoc != noHeredoc && c == '<' {
+			if h, end, ok := s.heredocAt(content, i); ok {
+				pending = append(pending, h)
+				last = end - 1
+				i = end
+				continue
+			}
+		}
+		last = i
+		i++
+	}
+	return spans
+}
+
+// comment returns the end of the comment starting at content[i], or i if
+// none does. Line comments end before the line break.
+func (s *syntax) comment(content []byte, i int) int {
+	rest := content[i:]
+	if s.block[0] != "" && bytes.HasPrefix(rest, []byte(s.block[0])) {
+		return s.blockEnd(content, i)
+	}
+	for _, marker := range s.line {
+		if !bytes.HasPrefix(rest, []byte(marker)) || !s.lineMarkerAt(content, i) {
+			continue
+		}
+		if s.php && bytes.HasPrefix(rest, []byte("#[")) {
+			continue // An attribute
+		}
+		end := lineEnd(content, i)
+		for end > i && (content[end-1] == '\n' || content[end-1] == '\r') {
+			end--
+		}
+		if s.php {
+			// ?> ends PHP code even in a line comment
+			if j := bytes.Index(content[i:end], []byte("?>")); j >= 0 {
+				end = i + j
+			}
+		}
+		return end
+	}
+	return i
+}
+
+// blockEnd returns the end of the block comment starting at content[i]
+func (s *syntax) blockEnd(content []byte, i int) int {
+	open, close := []byte(s.block[0]), []byte(s.block[1])
+	depth := 0
+	for j := i; j < len(content); {
+		switch {
+		case bytes.HasPrefix(content[j:], open) && (depth == 0 || s.nested):
+			depth++
+			j += len(open)
+		case bytes.HasPrefix(content[j:], close):
+			depth--
+			j += len(close)
+			if depth == 0 {
+				return j
+			}
+		default:
+			j++
+		}
+	}
+	return len(content)
+}
+
+// lineMarkerAt reports whether a line comment marker at content[i] starts a
+// comment under the syntax's rule
+func (s *syntax) lineMarkerAt(content []byte, i int) bool {
+	switch s.rule {
+	case afterSpace:
+		return i == 0 || bytes.IndexByte([]byte(" \t\r\n;"), content[i-1]) >= 0
+	case lineStart:
+		return len(bytes.TrimLeft(content[lineBegin(content, i):i], " \t")) == 0
+	}
+	return true
+}
+
+// literal returns the end of the literal starting at content[i], or i if none
+// does. last is the index of the last byte of code before it, or -1.
+func (s *syntax) literal(content []byte, i, last int) int {
+	c := content[i]
+	rest := content[i:]
+	quote := strings.IndexByte(s.quotes, c) >= 0
+	raw := strings.IndexByte(s.raw, c) >= 0
+
+	switch {
+	case s.yaml && (quote || raw) && !yamlScalarStart(content, i):
+		return i
+	case s.triple && (quote || raw) && bytes.HasPrefix(rest, []byte{c, c, c}):
+		return tripleEnd(content, i+3, rest[:3], quote)
+	case quote:
+		return s.stringEnd(content, i+1, c, true)
+	case raw:
+		return s.stringEnd(content, i+1, c, false)
+	case s.template && c == '`':
+		return s.templateEnd(content, i+1)
+	case s.chars && c == '\'':
+		return charEnd(content, i)
+	case s.verbatim && c == '@':
+		if bytes.HasPrefix(rest, []byte(`@"`)) {
+			return verbatimEnd(content, i+2)
+		}
+		if bytes.HasPrefix(rest, []byte(`@$"`)) {
+			return verbatimEnd(content, i+3)
+		}
+	case s.cppRaw && c == 'R' && bytes.HasPrefix(rest, []byte(`R"`)):
+		switch wordBefore(content, i) {
+		case "", "u8", "L", "u", "U":
+			return cppRawEnd(content, i)
+		}
+	case s.rustRaw && c == 'r':
+		switch wordBefore(content, i) {
+		case "", "b", "c":
+			return rustRawEnd(content, i)
+		}
+	case s.regex && c == '/' && regexAllowed(content, last):
+		return regexEnd(content, i)
+	case s.url && (c == 'u' || c == 'U') && wordBefore(content, i) == "" && bytes.EqualFold(rest[:min(4, len(rest))], []byte("url(")):
+		if end := bytes.IndexAny(rest, ")\n"); end >= 0 && rest[end] == ')' {
+			return i + end + 1
+		}
+	}
+	return i
+}
+
+// stringEnd returns the end of the string whose content starts at
+// content[j], closed by quote
+func (s *syntax) stringEnd(content []byte, j int, quote byte, escapes bool) int {
+	for j < len(content) {
+		switch c := content[j]; {
+		case c == '\\' && escapes:
+			j += 2
+			continue
+		case c == quote:
+			return j + 1
+		case c == '\n' && !s.multiline:
+			return j // Unterminated; the line break is code
+		case c == '$' && s.subst && escapes && j+1 < len(content) && content[j+1] == '(':
+			j = s.nestedEnd(content, j+2, '(', ')')
+			continue
+		}
+		j++
+	}
+	return len(content)
+}
+
+// templateEnd returns the end of the template string whose content starts at
+// content[j], skipping the code in ${} interpolations
+func (s *syntax) templateEnd(content []byte, j int) int {
+	for j < len(content) {
+		switch content[j] {
+		case '\\':
+			j += 2
+			continue
+		case '`':
+			return j + 1
+		case '$':
+			if j+1 < len(content) && content[j+1] == '{' {
+				j = s.nestedEnd(content, j+2, '{', '}')
+				continue
+			}
+		}
+		j++
+	}
+	return len(content)
+}
+
+// nestedEnd returns the index just past the close byte ending code nested in
+// a literal, such as an interpolation, skipping literals and nested pairs
+func (s *syntax) nestedEnd(content []byte, j int, open, close byte) int {
+	depth := 0
+	for j < len(content) {
+		if end := s.literal(content, j, j-1); end > j {
+			j = end
+			continue
+		}
+		switch content[j] {
+		case open:
+			depth++
+		case close:
+			if depth == 0 {
+				return j + 1
+			}
+			depth--
+		}
+		j++
+	}
+	return len(content)
+}
+
+// tripleEnd returns the end of the triple-quoted string whose content starts
+// at content[j], closed by delim
+func tripleEnd(content []byte, j int, delim []byte, escapes bool) int {
+	for j < len(content) {
+		if content[j] == '\\' && escapes {
+			j += 2
+			continue
+		}
+		if bytes.HasPrefix(content[j:], delim) {
+			return j + len(delim)
+		}
+		j++
+	}
+	return len(content)
+}
+
+// charEnd returns the end of the character literal at content[i], or i for
+// a ' that does not start one, such as a Rust lifetime
+func charEnd(content []byte, i int) int {
+	j := i + 1
+	if j >= len(content) || content[j] == '\n' {
+		return i
+	}
+	if content[j] == '\\' {
+		// An escape sequence, up to the closing quote on the same line
+		for k := j + 2; k < len(content) && k < j+12; k++ {
+			switch content[k] {
+			case '\'':
+				return k + 1
+			case '\n':
+				return i
+			}
+		}
+		return i
+	}
+	_, size := utf8.DecodeRune(content[j:])
+	if j+size < len(content) && content[j+size] == '\'' {
+		return j + size + 1
+	}
+	return i
+}
+
+// verbatimEnd returns the end of the C# verbatim string whose content starts
+// at content[j]; a doubled quote is an escaped one
+func verbatimEnd(content []byte, j int) int {
+	for j < len(content) {
+		if content[j] == '"' {
+			if j+1 < len(content) && content[j+1] == '"' {
+				j += 2
+				continue
+			}
+			return j + 1
+		}
+		j++
+	}
+	return len(content)
+}
+
+// cppRawEnd returns the end of the C++ raw string R"delim(...)delim" at
+// content[i], or i if it is malformed
+func cppRawEnd(content []byte, i int) int {
+	open := bytes.IndexByte(content[i+2:], '(')
+	if open < 0 || open > 16 {
+		return i
+	}
+	delim := content[i+2 : i+2+open]
+	if bytes.ContainsAny(delim, " \t\r\n\\)") {
+		return i
+	}
+	closing := append(append([]byte(")"), delim...), '"')
+	start := i + 2 + open + 1
+	if end := bytes.Index(content[start:], closing); end >= 0 {
+		return start + end + len(closing)
+	}
+	return len(content)
+}
+
+// rustRawEnd returns the end of the Rust raw string r#"..."# at content[i],
+// or i if there is none
+func rustRawEnd(content []byte, i int) int {
+	j := i + 1
+	for j < len(content) && content[j] == '#' {
+		j++
+	}
+	if j >= len(content) || content[j] != '"' {
+		return i
+	}
+	closing := append([]byte(`"`), content[i+1:j]...)
+	if end := bytes.Index(content[j+1:], closing); end >= 0 {
+		return j + 1 + end + len(closing)
+	}
+	return len(content)
+}
+
+// regexKeywords are the keywords after which / starts a regular expression
+// rather than a division
+var regexKeywords = map[string]bool{
+	"return": true, "typeof": true, "instanceof": true, "case": true, "do": true, "else": true,
+	"in": true, "of": true, "new": true, "delete": true, "void": true, "throw": true, "yield": true,
+	"await": true, "if": true, "unless": true, "when": true, "and": true, "or": true, "not": true,
+	"while": true, "until": true, "then": true,
+}
+
+// regexAllowed reports whether a / after the code byte at content[last]
+// starts a regular expression literal
+func regexAllowed(content []byte, last int) bool {
+	if last < 0 {
+		return true
+	}
+	c := content[last]
+	if isWordByte(c) || c == '$' {
+		return regexKeywords[wordBefore(content, last+1)]
+	}
+	return bytes.IndexByte([]byte("(,=:[!&|?{};+-*%<>~^"), c) >= 0
+}
+
+// regexEnd returns the end of the regular expression literal at content[i],
+// or i if the line ends before it does
+func regexEnd(content []byte, i int) int {
+	class := false
+	for j := i + 1; j < len(content); j++ {
+		switch content[j] {
+		case '\\':
+			j++
+		case '[':
+			class = true
+		case ']':
+			class = false
+		case '/':
+			if !class {
+				return j + 1
+			}
+		case '\n':
+			return i
+		}
+	}
+	return i
+}
+
+// wordBefore returns the identifier ending just before content[i]
+func wordBefore(content []byte, i int) string {
+	j := i
+	for j > 0 && (isWordByte(content[j-1]) || content[j-1] == '$') {
+		j--
+	}
+	return string(content[j:i])
+}
+
+// heredocAt parses the here-document operator at content[i], returning the
+// here-document and the end of the operator
+func (s *syntax) heredocAt(content []byte, i int) (heredoc, int, bool) {
+	rest := content[i:]
+	if !bytes.HasPrefix(rest, []byte("<<")) || bytes.HasPrefix(rest, []byte("<<<")) {
+		return heredoc{}, i, false
+	}
+	var h heredoc
+	j := i + 2
+	if j < len(content) && (content[j] == '-' || content[j] == '~' && s.heredoc == rubyHeredoc) {
+		h.indented = true
+		j++
+	}
+	if s.heredoc == shellHeredoc {
+		for j < len(content) && (content[j] == ' ' || content[j] == '\t') {
+			j++
+		}
+	}
+	var quote byte
+	if j < len(content) && (content[j] == '\'' || content[j] == '"') {
+		quote = content[j]
+		j++
+	}
+	start := j
+	for j < len(content) && isWordByte(content[j]) {
+		j++
+	}
+	if j == start || '0' <= content[start] && content[start] <= '9' {
+		return heredoc{}, i, false
+	}
+	if s.heredoc == rubyHeredoc && quote == 0 && !h.indented && !('A' <= content[start] && content[start] <= 'Z') {
+		return heredoc{}, i, false // A shift such as list <<item
+	}
+	h.delim = content[start:j]
+	if quote != 0 {
+		if j >= len(content) || content[j] != quote {
+			return heredoc{}, i, false
+		}
+		j++
+	}
+	return h, j, true
+}
+
+// heredocEnd returns the end of the bodies of the pending here-documents,
+// which start at content[start], including each closing delimiter line
+func heredocEnd(content []byte, start int, pending []heredoc) int {
+	j := start
+	for _, h := range pending {
+		for j < len(content) {
+			end := lineEnd(content, j)
+			line := bytes.TrimRight(content[j:end], "\r\n")
+			if h.indented {
+				line = bytes.TrimLeft(line, " \t")
+			}
+			j = end
+			if bytes.Equal(line, h.delim) {
+				break
+			}
+		}
+	}
+	return j
+}
+
+// phpOpen returns the index just past the next <?php, <?= or <? tag from
+// content[i], or the length of content if there is none
+func phpOpen(content []byte, i int) int {
+	j := bytes.Index(content[i:], []byte("<?"))
+	if j < 0 {
+		return len(content)
+	}
+	j += i + 2
+	if bytes.HasPrefix(content[j:], []byte("php")) {
+		j += 3
+	} else if bytes.HasPrefix(content[j:], []byte("=")) {
+		j++
+	}
+	return j
+}
+
+// yamlScalarStart reports whether a quote at content[i] starts a YAML scalar,
+// rather than being part of a plain one such as don't
+func yamlScalarStart(content []byte, i int) bool {
+	j := i
+	for j > 0 && (content[j-1] == ' ' || content[j-1] == '\t') {
+		j--
+	}
+	return j == 0 || bytes.IndexByte([]byte("\n:-[{,?"), content[j-1]) >= 0
+}
+
+// yamlBlockStart reports whether the | or > at content[i] starts a block
+// scalar: it starts a value and only modifiers or a comment follow it
+func yamlBlockStart(content []byte, i int) bool {
+	if !yamlScalarStart(content, i) {
+		return false
+	}
+	j := i + 1
+	for j < len(content) && bytes.IndexByte([]byte("+-0123456789"), content[j]) >= 0 {
+		j++
+	}
+	for j < len(content) && (content[j] == ' ' || content[j] == '\t') {
+		j++
+	}
+	return j == len(content) || content[j] == '\n' || content[j] == '\r' || content[j] == '#' && j > i+1 && isSpace(content[j-1])
+}
+
+// yamlBlockEnd returns the end of the block scalar starting at content[start],
+// which holds the following lines indented deeper than indent, and blank lines
+func yamlBlockEnd(content []byte, start, indent int) int {
+	j := start
+	for j < len(content) {
+		end := lineEnd(content, j)
+		if len(bytes.TrimSpace(content[j:end])) > 0 && indentWidth(content, j) <= indent {
+			break
+		}
+		j = end
+	}
+	return j
+}
+
+// lineBegin returns the index of the start of the line holding content[i]
+func lineBegin(content []byte, i int) int {
+	return bytes.LastIndexByte(content[:i], '\n') + 1
+}
+
+// indentWidth returns the number of spaces and tabs indenting the line
+// holding content[i]
+func indentWidth(content []byte, i int) int {
+	start := lineBegin(content, i)
+	j := start
+	for j < len(content) && (content[j] == ' ' || content[j] == '\t') {
+		j++
+	}
+	return j - start
+}
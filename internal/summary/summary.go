@@ -5,8 +5,10 @@ import (
 	"fmt"
 	"io"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/bethropolis/dir-dumper/internal/redact"
 	"github.com/bethropolis/dir-dumper/internal/walker"
 )
 
@@ -65,3 +67,130 @@ func DisplaySkippedItems(
 	}
 	infoLog("--- End Skipped Items ---")
 }
+
+// FileTokens is the estimated token count of a single dumped file
+type FileTokens struct {
+	Path   string
+	Tokens int
+}
+
+// DisplayTokenCounts prints the per-file token counts, largest first, and the total
+func DisplayTokenCounts(
+	logger Logger,
+	counts []FileTokens,
+	output io.Writer,
+	quiet bool,
+) {
+	total := 0
+	for _, c := range counts {
+		total += c.Tokens
+	}
+
+	if !quiet {
+		logger.Info("--- Token Counts (%d files, %d tokens) ---", len(counts), total)
+	}
+
+	// Sort for consistent output, largest files first
+	sorted := append([]FileTokens{}, counts...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].Tokens != sorted[j].Tokens {
+			return sorted[i].Tokens > sorted[j].Tokens
+		}
+		return sorted[i].Path < sorted[j].Path
+	})
+	for _, c := range sorted {
+		fmt.Fprintf(output, "%8d  %s\n", c.Tokens, c.Path)
+	}
+
+	if !quiet {
+		logger.Info("--- End Token Counts ---")
+	}
+}
+
+// FileRedactions is the secrets found in a single file
+type FileRedactions struct {
+	Path     string
+	Findings []redact.Finding // Findings in a diff have no line
+}
+
+// DisplayRedactions prints the number of secrets found per file, with their
+// kinds and lines
+func DisplayRedactions(
+	logger Logger,
+	redactions []FileRedactions,
+	output io.Writer,
+	quiet bool,
+) {
+	total := 0
+	for _, r := range redactions {
+		total += len(r.Findings)
+	}
+
+	if !quiet {
+		logger.Info("--- Secrets (%d in %d files) ---", total, len(redactions))
+	}
+
+	// Sort for consistent output
+	sorted := append([]FileRedactions{}, redactions...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Path < sorted[j].Path
+	})
+	for _, r := range sorted {
+		found := make([]string, len(r.Findings))
+		for i, f := range r.Findings {
+			if f.Line > 0 {
+				found[i] = fmt.Sprintf("%s:%d", f.Kind, f.Line)
+			} else {
+				found[i] = f.Kind + " (diff)"
+			}
+		}
+		fmt.Fprintf(output, "%4d  %s  [%s]\n", len(r.Findings), r.Path, strings.Join(found, ", "))
+	}
+
+	if !quiet {
+		logger.Info("--- End Secrets ---")
+	}
+}
+
+// Stripped is the size of the files comments were stripped from, before and
+// after stripping
+type Stripped struct {
+	Files        int // Files that had something stripped
+	BytesBefore  int
+	BytesAfter   int
+	TokensBefore int
+	TokensAfter  int
+}
+
+// Add records a file that had something stripped
+func (s *Stripped) Add(bytesBefore, bytesAfter, tokensBefore, tokensAfter int) {
+	s.Files++
+	s.BytesBefore += bytesBefore
+	s.BytesAfter += bytesAfter
+	s.TokensBefore += tokensBefore
+	s.TokensAfter += tokensAfter
+}
+
+// DisplayStripped shows how much stripping comments saved
+func DisplayStripped(logger Logger, stripped Stripped, quiet bool) {
+	if quiet {
+		return
+	}
+	if stripped.Files == 0 {
+		logger.Info("Stripping comments saved nothing.")
+		return
+	}
+	logger.Info("Stripped comments from %d files: saved %d bytes (%s) and ~%d tokens (%s).",
+		stripped.Files,
+		stripped.BytesBefore-stripped.BytesAfter, percentSaved(stripped.BytesBefore, stripped.BytesAfter),
+		stripped.TokensBefore-stripped.TokensAfter, percentSaved(stripped.TokensBefore, stripped.TokensAfter),
+	)
+}
+
+// percentSaved formats the reduction from before to after as a percentage
+func percentSaved(before, after int) string {
+	if before == 0 {
+		return "0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(before-after)/float64(before))
+}
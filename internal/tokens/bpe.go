@@ -0,0 +1,216 @@
+package tokens
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"strconv"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// vocabulary holds the embedded BPE tokens, one Go-quoted token per line in
+// merge order; lines starting with '#' are comments. It is produced by
+// gen_vocab.go.
+//
+//go:embed vocab.txt
+var vocabulary []byte
+
+// maxPieceLen bounds the pieces encoded with BPE; longer pieces (minified
+// code, base64 blobs) use the chars/4 estimate, as merging is quadratic
+const maxPieceLen = 256
+
+// maxCacheEntries bounds the per-piece count cache
+const maxCacheEntries = 1 << 16
+
+// BPECounter counts tokens with byte-pair encoding: text is split into
+// pieces, and each piece starts as single bytes that are merged pairwise,
+// lowest rank first, while the merged bytes form a vocabulary token.
+// It is safe for concurrent use.
+type BPECounter struct {
+	ranks map[string]int // Token bytes to merge rank
+
+	mu    sync.Mutex
+	cache map[string]int // Token counts of recently seen pieces
+}
+
+var (
+	bpeOnce    sync.Once
+	bpeDefault *BPECounter
+	bpeErr     error
+)
+
+// defaultBPE returns the counter for the embedded vocabulary, parsed once
+func defaultBPE() (*BPECounter, error) {
+	bpeOnce.Do(func() {
+		bpeDefault, bpeErr = NewBPECounter(vocabulary)
+	})
+	return bpeDefault, bpeErr
+}
+
+// NewBPECounter creates a counter from a vocabulary in the embedded format
+func NewBPECounter(vocab []byte) (*BPECounter, error) {
+	c := &BPECounter{
+		ranks: make(map[string]int),
+		cache: make(map[string]int),
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(vocab))
+	for line := 1; scanner.Scan(); line++ {
+		text := scanner.Text()
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		token, err := strconv.Unquote(text)
+		if err != nil {
+			return nil, fmt.Errorf("tokens: vocabulary line %d: %w", line, err)
+		}
+		if _, exists := c.ranks[token]; !exists {
+			c.ranks[token] = len(c.ranks)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("tokens: reading vocabulary: %w", err)
+	}
+	return c, nil
+}
+
+// Count implements Counter
+func (c *BPECounter) Count(content []byte) int {
+	total := 0
+	SplitPieces(content, func(piece []byte) {
+		total += c.countPiece(piece)
+	})
+	return total
+}
+
+// countPiece returns the number of tokens a single piece encodes to
+func (c *BPECounter) countPiece(piece []byte) int {
+	if len(piece) > maxPieceLen {
+		return charEstimate(len(piece))
+	}
+	if len(piece) <= 1 {
+		return len(piece)
+	}
+	if _, ok := c.ranks[string(piece)]; ok {
+		return 1
+	}
+
+	c.mu.Lock()
+	n, ok := c.cache[string(piece)]
+	c.mu.Unlock()
+	if ok {
+		return n
+	}
+
+	n = c.encode(piece)
+
+	c.mu.Lock()
+	if len(c.cache) >= maxCacheEntries {
+		c.cache = make(map[string]int)
+	}
+	c.cache[string(piece)] = n
+	c.mu.Unlock()
+	return n
+}
+
+// encode merges the bytes of piece and returns the resulting token count
+func (c *BPECounter) encode(piece []byte) int {
+	// parts[i] is the start offset of the i-th part; the part ends where
+	// the next one starts
+	parts := make([]int, len(piece)+1)
+	for i := range parts {
+		parts[i] = i
+	}
+
+	for len(parts) > 2 {
+		best, bestRank := -1, -1
+		for i := 0; i+2 < len(parts); i++ {
+			rank, ok := c.ranks[string(piece[parts[i]:parts[i+2]])]
+			if ok && (bestRank < 0 || rank < bestRank) {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		parts = append(parts[:best+1], parts[best+2:]...)
+	}
+	return len(parts) - 1
+}
+
+// SplitPieces splits text into the pieces BPE merges within: runs of
+// letters with one optional leading space or symbol, numbers of up to three
+// digits, symbol runs with an optional leading space, newline runs, and
+// whitespace runs. A whitespace run leaves its last space to the piece that
+// follows, as most tokens begin with a space.
+func SplitPieces(text []byte, fn func(piece []byte)) {
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRune(text[i:])
+		next, _ := utf8.DecodeRune(text[i+size:])
+		start := i
+
+		switch {
+		case isLetter(r):
+			i = scanWhile(text, i, isLetter)
+		case isDigit(r):
+			i = scanDigits(text, i)
+		case r == '\r' || r == '\n':
+			i = scanWhile(text, i, isNewline)
+		case r == ' ' && isLetter(next):
+			i = scanWhile(text, i+size, isLetter)
+		case r == ' ' && isSymbol(next):
+			i = scanWhile(text, i+size, isSymbol)
+		case unicode.IsSpace(r):
+			i = scanWhile(text, i, isBlank)
+			// Leave one space for a following word or symbol run
+			if i < len(text) && i-start > 1 && text[i-1] == ' ' {
+				if follow, _ := utf8.DecodeRune(text[i:]); isLetter(follow) || isSymbol(follow) {
+					i--
+				}
+			}
+		case isSymbol(r) && isLetter(next):
+			// A single symbol attaches to the word that follows, e.g. ".Println"
+			i = scanWhile(text, i+size, isLetter)
+		default:
+			i = scanWhile(text, i, isSymbol)
+		}
+
+		if i == start {
+			i = start + size
+		}
+		fn(text[start:i])
+	}
+}
+
+// scanWhile returns the offset of the first rune at or after i not matching fn
+func scanWhile(text []byte, i int, fn func(rune) bool) int {
+	for i < len(text) {
+		r, size := utf8.DecodeRune(text[i:])
+		if !fn(r) {
+			break
+		}
+		i += size
+	}
+	return i
+}
+
+// scanDigits returns the offset after at most three digits starting at i
+func scanDigits(text []byte, i int) int {
+	for n := 0; n < 3 && i < len(text) && isDigit(rune(text[i])); n++ {
+		i++
+	}
+	return i
+}
+
+func isLetter(r rune) bool  { return unicode.IsLetter(r) || unicode.IsMark(r) }
+func isDigit(r rune) bool   { return r >= '0' && r <= '9' || unicode.IsDigit(r) && r > utf8.RuneSelf }
+func isNewline(r rune) bool { return r == '\r' || r == '\n' }
+func isBlank(r rune) bool   { return unicode.IsSpace(r) && !isNewline(r) }
+
+// isSymbol reports whether r is neither a letter, a digit nor whitespace
+func isSymbol(r rune) bool {
+	return !isLetter(r) && !isDigit(r) && !unicode.IsSpace(r)
+}
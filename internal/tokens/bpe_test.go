@@ -0,0 +1,136 @@
+package tokens
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSplitPieces(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"", nil},
+		{"hello world", []string{"hello", " world"}},
+		{"héllo wörld", []string{"héllo", " wörld"}},
+		{"fmt.Println(x)", []string{"fmt", ".Println", "(x", ")"}},
+		{"x := 12345", []string{"x", " :=", " ", "123", "45"}},
+		{"a   b", []string{"a", "  ", " b"}},
+		{"foo!!  bar", []string{"foo", "!!", " ", " bar"}},
+		{"  // comment", []string{" ", " //", " comment"}},
+		{"\n\n\tif", []string{"\n\n", "\t", "if"}},
+		{"a\r\n\r\nb", []string{"a", "\r\n\r\n", "b"}},
+		{"a\xffb", []string{"a", "\xffb"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		SplitPieces([]byte(tt.text), func(piece []byte) {
+			got = append(got, string(piece))
+		})
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SplitPieces(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+// TestSplitPiecesCoversText checks that pieces are never empty and join
+// back into the text
+func TestSplitPiecesCoversText(t *testing.T) {
+	text := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfor i := 0; i < 1000; i++ {\n\t\tfmt.Printf(\"%d: héllo\\n\", i) // 日本語\n\t}\n}\n\x00\xfe"
+	var joined strings.Builder
+	SplitPieces([]byte(text), func(piece []byte) {
+		if len(piece) == 0 {
+			t.Fatal("empty piece")
+		}
+		joined.Write(piece)
+	})
+	if joined.String() != text {
+		t.Errorf("pieces join to %q, want %q", joined.String(), text)
+	}
+}
+
+func TestBPECounter(t *testing.T) {
+	// "bc" ranks before "ab", so "abc" merges to "a" + "bc" and stops there,
+	// as "abc" is only reachable through "ab"
+	vocab := "# test vocabulary\n\"bc\"\n\"ab\"\n\"abd\"\n\"\\t\\t\"\n"
+	c, err := NewBPECounter([]byte(vocab))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"ab", 1},
+		{"abd", 1},
+		{"abc", 2},
+		{"abab", 2},
+		{"xyz", 3},
+		{"ab bc", 3}, // "ab" and " bc", which merges to " " + "bc"
+		{"\t\t", 1},
+		{strings.Repeat("ab", maxPieceLen), maxPieceLen / 2}, // Too long to merge: chars/4
+	}
+	for _, tt := range tests {
+		if got := c.Count([]byte(tt.text)); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+		// A second count is served from the cache
+		if got := c.Count([]byte(tt.text)); got != tt.want {
+			t.Errorf("cached Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestNewBPECounterInvalid(t *testing.T) {
+	_, err := NewBPECounter([]byte("\"ok\"\nnot quoted\n"))
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("NewBPECounter error = %v, want one naming line 2", err)
+	}
+}
+
+// TestDefaultBPE checks the embedded vocabulary against estimates that any
+// reasonable tokenizer stays between
+func TestDefaultBPE(t *testing.T) {
+	c, err := defaultBPE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, word := range []string{"the", " the", "func", " return"} {
+		if got := c.Count([]byte(word)); got != 1 {
+			t.Errorf("Count(%q) = %d, want a single token", word, got)
+		}
+	}
+
+	source := []byte("func main() {\n\tfmt.Println(\"hello, world\")\n}\n")
+	pieces := 0
+	SplitPieces(source, func([]byte) { pieces++ })
+	if got := c.Count(source); got < pieces || got > len(source) {
+		t.Errorf("Count = %d, want between %d pieces and %d bytes", got, pieces, len(source))
+	}
+}
+
+func TestBPECounterConcurrent(t *testing.T) {
+	c, err := defaultBPE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	text := []byte(strings.Repeat("for i := range items { total += weights[i] }\n", 50))
+	want := c.Count(text)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got := c.Count(text); got != want {
+				t.Errorf("concurrent Count = %d, want %d", got, want)
+			}
+		}()
+	}
+	wg.Wait()
+}
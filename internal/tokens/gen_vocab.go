@@ -0,0 +1,174 @@
+//go:build ignore
+
+// gen_vocab trains the embedded BPE vocabulary on a corpus of source files:
+//
+//	go run gen_vocab.go -merges 8192 -o vocab.txt DIR...
+//
+// Pieces are split with tokens.SplitPieces, so training and counting agree.
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/bethropolis/dir-dumper/internal/tokens"
+)
+
+// corpusExtensions are the file types sampled from the corpus directories
+var corpusExtensions = map[string]bool{
+	".c": true, ".css": true, ".go": true, ".h": true, ".html": true, ".java": true,
+	".js": true, ".json": true, ".md": true, ".py": true, ".rs": true, ".sh": true,
+	".sql": true, ".ts": true, ".txt": true, ".xml": true, ".yaml": true, ".yml": true,
+}
+
+// word is a distinct piece being merged, with its corpus frequency
+type word struct {
+	parts []string
+	count int
+}
+
+type pair [2]string
+
+func main() {
+	merges := flag.Int("merges", 8192, "Number of merges to learn")
+	maxWords := flag.Int("words", 60000, "Number of most frequent pieces to train on")
+	output := flag.String("o", "vocab.txt", "Output file")
+	flag.Parse()
+
+	freq := make(map[string]int)
+	for _, dir := range flag.Args() {
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() || !corpusExtensions[filepath.Ext(path)] {
+				return nil
+			}
+			content, err := os.ReadFile(path)
+			if err != nil || len(content) > 1<<20 {
+				return nil
+			}
+			tokens.SplitPieces(content, func(piece []byte) {
+				if len(piece) > 1 && len(piece) <= 32 {
+					freq[string(piece)]++
+				}
+			})
+			return nil
+		})
+	}
+	if len(freq) == 0 {
+		log.Fatal("no corpus files found")
+	}
+
+	words := topWords(freq, *maxWords)
+	log.Printf("training on %d pieces", len(words))
+
+	// Pair counts and the words each pair occurs in
+	counts := make(map[pair]int)
+	where := make(map[pair]map[int]bool)
+	for i, w := range words {
+		addPairs(w, i, 1, counts, where)
+	}
+
+	var learned []string
+	for len(learned) < *merges {
+		best, bestCount := pair{}, 0
+		for p, n := range counts {
+			if n > bestCount || n == bestCount && p[0]+p[1] < best[0]+best[1] {
+				best, bestCount = p, n
+			}
+		}
+		if bestCount < 2 {
+			break
+		}
+		learned = append(learned, best[0]+best[1])
+
+		for i := range where[best] {
+			w := &words[i]
+			addPairs(*w, i, -1, counts, where)
+			w.parts = merge(w.parts, best)
+			addPairs(*w, i, 1, counts, where)
+		}
+		delete(where, best)
+	}
+
+	file, err := os.Create(*output)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+
+	out := bufio.NewWriter(file)
+	fmt.Fprintf(out, "# BPE vocabulary for dir-dumper token estimates: %d merged tokens in rank order.\n", len(learned))
+	fmt.Fprintf(out, "# Generated by gen_vocab.go; single bytes are implicit.\n")
+	for _, token := range learned {
+		fmt.Fprintln(out, strconv.Quote(token))
+	}
+	if err := out.Flush(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %d tokens to %s", len(learned), *output)
+}
+
+// topWords returns the most frequent pieces split into single bytes
+func topWords(freq map[string]int, limit int) []word {
+	pieces := make([]string, 0, len(freq))
+	for piece := range freq {
+		pieces = append(pieces, piece)
+	}
+	sort.Slice(pieces, func(i, j int) bool {
+		if freq[pieces[i]] != freq[pieces[j]] {
+			return freq[pieces[i]] > freq[pieces[j]]
+		}
+		return pieces[i] < pieces[j]
+	})
+	if len(pieces) > limit {
+		pieces = pieces[:limit]
+	}
+
+	words := make([]word, len(pieces))
+	for i, piece := range pieces {
+		parts := make([]string, len(piece))
+		for j := range piece {
+			parts[j] = piece[j : j+1]
+		}
+		words[i] = word{parts: parts, count: freq[piece]}
+	}
+	return words
+}
+
+// addPairs adds (sign 1) or removes (sign -1) the pairs of w from the counts
+func addPairs(w word, i, sign int, counts map[pair]int, where map[pair]map[int]bool) {
+	for j := 0; j+1 < len(w.parts); j++ {
+		p := pair{w.parts[j], w.parts[j+1]}
+		counts[p] += sign * w.count
+		if counts[p] <= 0 {
+			delete(counts, p)
+		}
+		if sign > 0 {
+			if where[p] == nil {
+				where[p] = make(map[int]bool)
+			}
+			where[p][i] = true
+		}
+	}
+}
+
+// merge joins every occurrence of p in parts
+func merge(parts []string, p pair) []string {
+	merged := parts[:0:0]
+	for j := 0; j < len(parts); j++ {
+		if j+1 < len(parts) && parts[j] == p[0] && parts[j+1] == p[1] {
+			merged = append(merged, strings.Join(p[:], ""))
+			j++
+			continue
+		}
+		merged = append(merged, parts[j])
+	}
+	return merged
+}
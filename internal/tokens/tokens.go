@@ -0,0 +1,44 @@
+// Package tokens estimates how many LLM tokens a piece of text will use,
+// entirely offline
+package tokens
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Counter estimates the number of tokens in content
+type Counter interface {
+	Count(content []byte) int
+}
+
+// Tokenizer names accepted by New
+const (
+	TokenizerBPE   = "bpe"   // Byte-pair encoding with the embedded vocabulary
+	TokenizerChars = "chars" // One token per four bytes
+)
+
+// New returns the counter for the named tokenizer
+func New(name string) (Counter, error) {
+	switch strings.ToLower(name) {
+	case TokenizerBPE, "":
+		return defaultBPE()
+	case TokenizerChars:
+		return CharCounter{}, nil
+	default:
+		return nil, fmt.Errorf("tokens: unknown tokenizer %q (available: %s, %s)", name, TokenizerBPE, TokenizerChars)
+	}
+}
+
+// CharCounter is the cheap estimate of one token per four bytes, rounded up
+type CharCounter struct{}
+
+// Count implements Counter
+func (CharCounter) Count(content []byte) int {
+	return charEstimate(len(content))
+}
+
+// charEstimate returns the chars/4 estimate for n bytes
+func charEstimate(n int) int {
+	return (n + 3) / 4
+}
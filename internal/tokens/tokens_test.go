@@ -0,0 +1,49 @@
+package tokens
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    string
+		wantErr bool
+	}{
+		{"", "*tokens.BPECounter", false},
+		{"bpe", "*tokens.BPECounter", false},
+		{"BPE", "*tokens.BPECounter", false},
+		{"chars", "tokens.CharCounter", false},
+		{"words", "", true},
+	}
+	for _, tt := range tests {
+		counter, err := New(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("New(%q) error = %v, want error: %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if got := fmt.Sprintf("%T", counter); err == nil && got != tt.want {
+			t.Errorf("New(%q) = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCharCounter(t *testing.T) {
+	tests := []struct {
+		content string
+		want    int
+	}{
+		{"", 0},
+		{"a", 1},
+		{"abcd", 1},
+		{"abcde", 2},
+		{strings.Repeat("x", 400), 100},
+	}
+	for _, tt := range tests {
+		if got := (CharCounter{}).Count([]byte(tt.content)); got != tt.want {
+			t.Errorf("Count(%d bytes) = %d, want %d", len(tt.content), got, tt.want)
+		}
+	}
+}
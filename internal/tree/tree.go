@@ -0,0 +1,125 @@
+// Package tree builds a directory tree from the paths a walk included and
+// skipped, for rendering ahead of the dumped contents
+package tree
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+// Node is a file or directory in the tree
+type Node struct {
+	Name     string               `json:"name"`
+	IsDir    bool                 `json:"is_dir"`
+	Skipped  walker.SkippedReason `json:"skipped,omitempty"` // Why the path was skipped, if it was
+	Children []*Node              `json:"children,omitempty"`
+
+	index map[string]*Node // Children by name, while building
+}
+
+// Build returns the tree rooted at rootName containing the included file
+// paths and the skipped items, with children sorted by name
+func Build(rootName string, included []string, skipped []walker.SkippedItem) *Node {
+	root := &Node{Name: rootName, IsDir: true}
+
+	for _, p := range included {
+		root.insert(p, false)
+	}
+	for _, item := range skipped {
+		if node := root.insert(item.Path, item.IsDir); node != nil && node != root {
+			node.Skipped = item.Reason
+		}
+	}
+
+	root.finish()
+	return root
+}
+
+// insert adds a slash- or OS-separated path below n, creating parent
+// directories as needed, and returns the node for the path itself
+func (n *Node) insert(relativePath string, isDir bool) *Node {
+	rel := strings.Trim(filepath.ToSlash(relativePath), "/")
+	if rel == "" || rel == "." {
+		return n
+	}
+
+	parts := strings.Split(rel, "/")
+	node := n
+	for i, part := range parts {
+		last := i == len(parts)-1
+		child, ok := node.index[part]
+		if !ok {
+			child = &Node{Name: part, IsDir: !last || isDir}
+			if node.index == nil {
+				node.index = make(map[string]*Node)
+			}
+			node.index[part] = child
+			node.Children = append(node.Children, child)
+		} else if !last {
+			child.IsDir = true
+		}
+		node = child
+	}
+	return node
+}
+
+// finish sorts children and drops the build index
+func (n *Node) finish() {
+	sort.Slice(n.Children, func(i, j int) bool {
+		return n.Children[i].Name < n.Children[j].Name
+	})
+	n.index = nil
+	for _, child := range n.Children {
+		child.finish()
+	}
+}
+
+// Render writes the tree as ASCII art in the style of the tree command,
+// marking directories with a trailing slash and skipped paths with their reason
+func (n *Node) Render(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, n.label()); err != nil {
+		return err
+	}
+	return n.renderChildren(w, "")
+}
+
+// renderChildren writes the children of n, each line starting with prefix
+func (n *Node) renderChildren(w io.Writer, prefix string) error {
+	for i, child := range n.Children {
+		connector, indent := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%s\n", prefix, connector, child.label()); err != nil {
+			return err
+		}
+		if err := child.renderChildren(w, prefix+indent); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// label returns the display text for a node
+func (n *Node) label() string {
+	label := n.Name
+	if n.IsDir {
+		label += "/"
+	}
+	if n.Skipped != "" {
+		label += " [" + string(n.Skipped) + "]"
+	}
+	return label
+}
+
+// String returns the rendered tree
+func (n *Node) String() string {
+	var b strings.Builder
+	n.Render(&b)
+	return b.String()
+}
@@ -0,0 +1,107 @@
+package tree
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/walker"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		included []string
+		skipped  []walker.SkippedItem
+		want     string
+	}{
+		{
+			name: "empty",
+			want: "project/\n",
+		},
+		{
+			name:     "sorted with nested directories",
+			included: []string{"main.go", "internal/b/b.go", "README.md", "internal/a/a.go", "internal/a/a_test.go"},
+			want: `project/
+├── README.md
+├── internal/
+│   ├── a/
+│   │   ├── a.go
+│   │   └── a_test.go
+│   └── b/
+│       └── b.go
+└── main.go
+`,
+		},
+		{
+			name:     "skipped paths",
+			included: []string{"src/app.go"},
+			skipped: []walker.SkippedItem{
+				{Path: "node_modules", Reason: walker.ReasonIgnoredRule, IsDir: true},
+				{Path: "src/logo.png", Reason: walker.ReasonSkippedBinary},
+				{Path: "deep/er/big.log", Reason: walker.ReasonSkippedSizeLimit},
+			},
+			want: `project/
+├── deep/
+│   └── er/
+│       └── big.log [Skipped (Size Limit Exceeded)]
+├── node_modules/ [Ignored (Gitignore/Custom Rule)]
+└── src/
+    ├── app.go
+    └── logo.png [Skipped (Binary File)]
+`,
+		},
+		{
+			name:     "os separators and the root itself",
+			included: []string{"a/b.txt", "/c.txt/"},
+			skipped:  []walker.SkippedItem{{Path: ".", Reason: walker.ReasonSkippedPathError, IsDir: true}},
+			want: `project/
+├── a/
+│   └── b.txt
+└── c.txt
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Build("project", tt.included, tt.skipped).String(); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestBuildDirectoryPromotion checks that a path seen as a file becomes a
+// directory once a path below it is added
+func TestBuildDirectoryPromotion(t *testing.T) {
+	root := Build("root", []string{"docs", "docs/guide.md"}, nil)
+	if len(root.Children) != 1 || !root.Children[0].IsDir || len(root.Children[0].Children) != 1 {
+		t.Errorf("docs is not a directory holding guide.md:\n%s", root)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	root := Build("root", []string{"a/b.go"}, []walker.SkippedItem{{Path: "c.bin", Reason: walker.ReasonSkippedBinary}})
+	got, err := json.Marshal(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name":"root","is_dir":true,"children":[` +
+		`{"name":"a","is_dir":true,"children":[{"name":"b.go","is_dir":false}]},` +
+		`{"name":"c.bin","is_dir":false,"skipped":"Skipped (Binary File)"}]}`
+	if string(got) != want {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
+
+// failingWriter fails every write
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("write failed") }
+
+func TestRenderError(t *testing.T) {
+	root := Build("root", []string{"a/b.go"}, nil)
+	if err := root.Render(failingWriter{}); err == nil {
+		t.Error("Render did not report the write error")
+	}
+}
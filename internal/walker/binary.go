@@ -0,0 +1,95 @@
+package walker
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+)
+
+// BinaryPolicy controls how files detected as binary are handled
+type BinaryPolicy string
+
+const (
+	BinarySkip        BinaryPolicy = "skip"        // Skip binary files entirely
+	BinaryPlaceholder BinaryPolicy = "placeholder" // Emit a one-line placeholder with size and MIME type
+	BinaryBase64      BinaryPolicy = "base64"      // Emit base64-encoded content
+)
+
+// binarySniffLen is the number of leading bytes inspected for binary detection
+const binarySniffLen = 8192
+
+// ParseBinaryPolicy converts a policy name into a BinaryPolicy
+func ParseBinaryPolicy(name string) (BinaryPolicy, error) {
+	switch policy := BinaryPolicy(strings.ToLower(strings.TrimSpace(name))); policy {
+	case BinarySkip, BinaryPlaceholder, BinaryBase64:
+		return policy, nil
+	case "":
+		return BinarySkip, nil
+	default:
+		return "", fmt.Errorf("unknown binary policy %q (expected skip, placeholder or base64)", name)
+	}
+}
+
+// detectBinary inspects the start of content and reports whether it looks
+// binary, along with the sniffed MIME type
+func detectBinary(content []byte) (bool, string) {
+	sample := content
+	if len(sample) > binarySniffLen {
+		sample = sample[:binarySniffLen]
+	}
+	mimeType := http.DetectContentType(sample)
+	if len(sample) == 0 {
+		return false, mimeType
+	}
+
+	// NUL bytes practically never appear in text files
+	if bytes.IndexByte(sample, 0) >= 0 {
+		return true, mimeType
+	}
+
+	// Tolerate a multi-byte rune cut off by the sample boundary
+	if len(sample) < len(content) {
+		for i := 0; i < utf8.UTFMax-1 && len(sample) > 0 && !utf8.Valid(sample); i++ {
+			sample = sample[:len(sample)-1]
+		}
+	}
+	if !utf8.Valid(sample) {
+		return true, mimeType
+	}
+
+	return !isTextMIME(mimeType), mimeType
+}
+
+// isTextMIME reports whether a sniffed MIME type denotes textual content
+func isTextMIME(mimeType string) bool {
+	mediaType, _, _ := strings.Cut(mimeType, ";")
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case mediaType == "application/json", mediaType == "application/xml",
+		mediaType == "application/javascript", mediaType == "image/svg+xml":
+		return true
+	default:
+		return false
+	}
+}
+
+// binaryContent renders binary content according to policy
+func binaryContent(content []byte, mimeType string, policy BinaryPolicy) []byte {
+	switch policy {
+	case BinaryBase64:
+		encoded := make([]byte, base64.StdEncoding.EncodedLen(len(content)))
+		base64.StdEncoding.Encode(encoded, content)
+		return encoded
+	default:
+		return binaryPlaceholder(int64(len(content)), mimeType)
+	}
+}
+
+// binaryPlaceholder returns the placeholder for a binary file of size bytes
+func binaryPlaceholder(size int64, mimeType string) []byte {
+	return []byte(fmt.Sprintf("[binary file: %d bytes, %s]", size, mimeType))
+}
@@ -0,0 +1,117 @@
+package walker
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+func TestDetectBinary(t *testing.T) {
+	// A multi-byte rune straddling the end of the sniffed sample
+	cutRune := strings.Repeat("a", binarySniffLen-1) + "é" + "rest"
+
+	tests := []struct {
+		name    string
+		content string
+		want    bool
+	}{
+		{"empty", "", false},
+		{"ascii text", "package main\n\nfunc main() {}\n", false},
+		{"utf-8 text", "héllo wörld, 你好\n", false},
+		{"json", `{"key": [1, 2, 3]}`, false},
+		{"xml", `<?xml version="1.0"?><root/>`, false},
+		{"svg", `<svg xmlns="http://www.w3.org/2000/svg"></svg>`, false},
+		{"nul byte", "text\x00more text", true},
+		{"invalid utf-8", "caf\xe9 au lait\n", true},
+		{"png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR", true},
+		{"pdf", "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n", true},
+		{"gzip", "\x1f\x8b\x08\x00\x00\x00\x00\x00", true},
+		{"rune cut by the sample", cutRune, false},
+		{"nul past the sample", strings.Repeat("a", binarySniffLen) + "\x00", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, mimeType := detectBinary([]byte(tt.content))
+			if got != tt.want {
+				t.Errorf("detectBinary = %v (%s), want %v", got, mimeType, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseBinaryPolicy(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    BinaryPolicy
+		wantErr bool
+	}{
+		{"skip", BinarySkip, false},
+		{"placeholder", BinaryPlaceholder, false},
+		{" Base64 ", BinaryBase64, false},
+		{"", BinarySkip, false},
+		{"hex", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseBinaryPolicy(tt.name)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("ParseBinaryPolicy(%q) = %q, %v, want %q (error: %v)", tt.name, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestBinaryContent(t *testing.T) {
+	content := []byte("\x00\x01\x02\x03")
+	if got := string(binaryContent(content, "application/octet-stream", BinaryPlaceholder)); got != "[binary file: 4 bytes, application/octet-stream]" {
+		t.Errorf("placeholder = %q", got)
+	}
+	if got := string(binaryContent(content, "application/octet-stream", BinaryBase64)); got != "AAECAw==" {
+		t.Errorf("base64 = %q", got)
+	}
+}
+
+// TestBinarySkip checks that binary files are skipped by default, read or
+// streamed, and that text files are not
+func TestBinarySkip(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "image.png", "\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	writeFile(t, root, "text.txt", "text\n")
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(mode string, delivered []string, skipped []SkippedItem) {
+		t.Helper()
+		if len(delivered) != 1 || delivered[0] != "text.txt" {
+			t.Errorf("%s: delivered %q, want only text.txt", mode, delivered)
+		}
+		if len(skipped) != 1 || skipped[0].Path != "image.png" || skipped[0].Reason != ReasonSkippedBinary {
+			t.Errorf("%s: skipped %+v, want image.png as binary", mode, skipped)
+		}
+	}
+
+	var delivered []string
+	skipped, err := WalkFiles(root, matcher, func(file File, err error) error {
+		if err == nil {
+			delivered = append(delivered, file.RelativePath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("read", delivered, skipped)
+
+	delivered = nil
+	skipped, err = WalkStreams(root, matcher, func(file StreamFile, err error) error {
+		if err == nil {
+			delivered = append(delivered, file.RelativePath)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("streamed", delivered, skipped)
+}
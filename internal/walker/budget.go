@@ -0,0 +1,74 @@
+package walker
+
+import (
+	"context"
+	"sync"
+)
+
+// byteBudget is a semaphore over bytes of file content. It bounds how much
+// concurrent workers read ahead of the files being delivered, so memory use
+// stays predictable however large the files are. A nil byteBudget never
+// blocks.
+type byteBudget struct {
+	mu    sync.Mutex
+	freed *sync.Cond
+	limit int64
+	used  int64
+}
+
+// newByteBudget creates a budget of limit bytes, or returns nil if limit is
+// not positive
+func newByteBudget(limit int64) *byteBudget {
+	if limit <= 0 {
+		return nil
+	}
+	b := &byteBudget{limit: limit}
+	b.freed = sync.NewCond(&b.mu)
+	return b
+}
+
+// acquire reserves n bytes and returns how many were reserved. A file larger
+// than the whole budget reserves all of it, so it is read once everything
+// else has been released. acquire blocks until the bytes are free or ctx is
+// done; callers must arrange for wake to be called when ctx is done.
+func (b *byteBudget) acquire(ctx context.Context, n int64) (int64, error) {
+	if b == nil || n <= 0 {
+		return 0, nil
+	}
+	n = min(n, b.limit)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for b.used+n > b.limit {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		b.freed.Wait()
+	}
+	b.used += n
+	return n, nil
+}
+
+// release returns n reserved bytes to the budget
+func (b *byteBudget) release(n int64) {
+	if b == nil || n <= 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.freed.Broadcast()
+}
+
+// wake wakes every goroutine waiting in acquire, so they notice that their
+// context is done
+func (b *byteBudget) wake() {
+	if b == nil {
+		return
+	}
+	// Broadcast under the lock, so a waiter is either still to check its
+	// context or already waiting
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.freed.Broadcast()
+}
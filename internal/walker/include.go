@@ -0,0 +1,70 @@
+package walker
+
+import (
+	"path"
+	"path/filepath"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+// includeFilter is a whitelist of gitignore-style patterns. A file is
+// included when the last pattern matching it (or one of its parent
+// directories) is not negated.
+type includeFilter struct {
+	patterns []*ignore.Pattern
+}
+
+// newIncludeFilter compiles include patterns, returning nil if there are none
+func newIncludeFilter(patterns []string) *includeFilter {
+	f := &includeFilter{}
+	for _, line := range patterns {
+		if p := ignore.ParsePattern(line, ""); p != nil {
+			f.patterns = append(f.patterns, p)
+		}
+	}
+	if len(f.patterns) == 0 {
+		return nil
+	}
+	return f
+}
+
+// allowsFile reports whether a file passes the include whitelist
+func (f *includeFilter) allowsFile(relativePath string) bool {
+	if f == nil {
+		return true
+	}
+
+	rel := filepath.ToSlash(relativePath)
+	included := false
+	for _, p := range f.patterns {
+		if p.Match(rel, false) || matchesParentDir(p, rel) {
+			included = !p.Negated()
+		}
+	}
+	return included
+}
+
+// mayContain reports whether any include pattern could match inside dir,
+// so directories that cannot contain an included file are pruned
+func (f *includeFilter) mayContain(relativeDir string) bool {
+	if f == nil {
+		return true
+	}
+
+	for _, p := range f.patterns {
+		if !p.Negated() && p.MayMatchBelow(relativeDir) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesParentDir reports whether p matches any parent directory of rel
+func matchesParentDir(p *ignore.Pattern, rel string) bool {
+	for dir := path.Dir(rel); dir != "." && dir != "/"; dir = path.Dir(dir) {
+		if p.Match(dir, true) {
+			return true
+		}
+	}
+	return false
+}
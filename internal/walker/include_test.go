@@ -0,0 +1,125 @@
+package walker
+
+import (
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+func TestIncludeFilterAllowsFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		want     bool
+	}{
+		{"extension at root", []string{"*.go"}, "main.go", true},
+		{"extension in subdirectory", []string{"*.go"}, "cmd/app/main.go", true},
+		{"extension mismatch", []string{"*.go"}, "README.md", false},
+		{"anchored at root", []string{"/main.go"}, "main.go", true},
+		{"anchored not in subdirectory", []string{"/main.go"}, "cmd/main.go", false},
+		{"path with directory", []string{"cmd/*.go"}, "cmd/main.go", true},
+		{"path with directory, other directory", []string{"cmd/*.go"}, "internal/cmd.go", false},
+		{"double star", []string{"src/**/*.ts"}, "src/a/b/c.ts", true},
+		{"double star, directly inside", []string{"src/**/*.ts"}, "src/c.ts", true},
+		{"double star, outside", []string{"src/**/*.ts"}, "lib/c.ts", false},
+		{"whole directory", []string{"docs/"}, "docs/guide/intro.md", true},
+		{"whole directory, not a prefix", []string{"docs/"}, "docsite/index.md", false},
+		{"negated after match", []string{"*.go", "!*_test.go"}, "walker_test.go", false},
+		{"negated keeps others", []string{"*.go", "!*_test.go"}, "walker.go", true},
+		{"last pattern wins", []string{"!*_test.go", "*.go"}, "walker_test.go", true},
+		{"negated directory", []string{"*.go", "!vendor/"}, "vendor/lib/lib.go", false},
+		{"only negations", []string{"!*.md"}, "main.go", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newIncludeFilter(tt.patterns).allowsFile(filepath.FromSlash(tt.path)); got != tt.want {
+				t.Errorf("allowsFile(%q) with %q = %v, want %v", tt.path, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeFilterMayContain(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		dir      string
+		want     bool
+	}{
+		{"unanchored pattern", []string{"*.go"}, "any/dir", true},
+		{"parent of path", []string{"src/app/*.ts"}, "src", true},
+		{"path's own directory", []string{"src/app/*.ts"}, "src/app", true},
+		{"sibling of path", []string{"src/app/*.ts"}, "lib", false},
+		{"below path's directory", []string{"src/app/*.ts"}, "src/app/deep", false},
+		{"double star", []string{"src/**/*.ts"}, "src/a/b", true},
+		{"double star, outside", []string{"src/**/*.ts"}, "test", false},
+		{"whole directory", []string{"docs/"}, "docs/guide", true},
+		{"negations never add", []string{"!vendor/"}, "vendor", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newIncludeFilter(tt.patterns).mayContain(tt.dir); got != tt.want {
+				t.Errorf("mayContain(%q) with %q = %v, want %v", tt.dir, tt.patterns, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncludeFilterEmpty(t *testing.T) {
+	f := newIncludeFilter([]string{"", "# comment"})
+	if f != nil {
+		t.Fatalf("newIncludeFilter without patterns = %+v, want nil", f)
+	}
+	if !f.allowsFile("any/file.txt") || !f.mayContain("any") {
+		t.Error("a nil filter must include everything")
+	}
+}
+
+// TestIncludePrunesDirectories checks that the walk delivers only included
+// files and does not descend into directories no pattern can reach
+func TestIncludePrunesDirectories(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"main.go", "README.md", "src/app/app.ts", "src/app/app_test.ts", "src/util.ts", "lib/lib.ts", "lib/deep/x.ts"} {
+		writeFile(t, root, name, "content\n")
+	}
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var delivered []string
+	skipped, err := WalkFiles(root, matcher, func(file File, err error) error {
+		if err == nil {
+			delivered = append(delivered, file.RelativePath)
+		}
+		return nil
+	}, WithIncludePatterns([]string{"src/**/*.ts", "!*_test.ts", "/main.go"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sort.Strings(delivered)
+	want := []string{"main.go", filepath.FromSlash("src/app/app.ts"), filepath.FromSlash("src/util.ts")}
+	if !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %q, want %q", delivered, want)
+	}
+	reasons := make(map[string]SkippedReason)
+	for _, item := range skipped {
+		reasons[item.Path] = item.Reason
+	}
+	if reasons["lib"] != ReasonFilteredInclude {
+		t.Errorf("lib was not pruned: %v", reasons)
+	}
+	if _, ok := reasons[filepath.FromSlash("lib/deep/x.ts")]; ok {
+		t.Error("the walk descended into the pruned lib directory")
+	}
+	for _, path := range []string{"README.md", filepath.FromSlash("src/app/app_test.ts")} {
+		if reasons[path] != ReasonFilteredInclude {
+			t.Errorf("%s: reason %q, want %q", path, reasons[path], ReasonFilteredInclude)
+		}
+	}
+}
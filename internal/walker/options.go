@@ -14,10 +14,31 @@ type WalkOptions struct {
 	Concurrent   bool
 	MaxWorkers   int
 	MaxFileSize  int64
+	MaxDepth     int   // Maximum depth of files below the root (0 = no limit)
+	MaxFilesDir  int   // Maximum files processed per directory (0 = no limit)
+	MaxInFlight  int64 // Bytes of content concurrent workers may read ahead of delivery (0 = no limit)
 	ExtensionMap map[string]struct{}
+	Include      *includeFilter // Compiled include patterns (nil = include all)
 	Context      context.Context
+	BinaryPolicy BinaryPolicy
+	// FollowSymlinks resolves symlinks and descends into linked directories
+	FollowSymlinks bool
+	// EmitSymlinks emits symlinks as "path -> target" entries instead of content
+	EmitSymlinks bool
+	// Paths, when non-nil, replaces the directory walk with a fixed list of
+	// files (slash-separated, relative to the root)
+	Paths        []string
 	ignoreHidden bool
 	ProgressFn   ProgressCallback // Add progress callback function
+	// Transforms rewrite the content of text files by extension (without
+	// the dot); a file whose transform fails is delivered unchanged
+	Transforms map[string]Transform
+	// Truncation picks the line limit of each text file (nil = keep whole)
+	Truncation *truncation
+	stream     bool // Leave content on disk for WalkStreams to deliver as readers
+	// ScanStreams reads streamed files once ahead to fill in Meta.Lines and
+	// Meta.SHA256; otherwise only their start is read, to detect binaries
+	ScanStreams bool
 }
 
 // ProgressCallback is a function that receives progress updates
@@ -40,8 +61,12 @@ func defaultOptions() WalkOptions {
 		Concurrent:   false,
 		MaxWorkers:   10,
 		MaxFileSize:  0,   // No limit
+		MaxDepth:     0,   // No limit
+		MaxFilesDir:  0,   // No limit
 		ExtensionMap: nil, // No extension filtering by default
+		Include:      nil, // No include patterns by default
 		Context:      context.Background(),
+		BinaryPolicy: BinarySkip,
 		ignoreHidden: false,
 		ProgressFn:   nil,
 	}
@@ -80,6 +105,37 @@ func WithMaxFileSize(maxBytes int64) Option {
 	}
 }
 
+// WithMaxDepth limits how deep below the root files are processed.
+// Files directly in the root are at depth 1; 0 means no limit.
+func WithMaxDepth(depth int) Option {
+	return func(opts *WalkOptions) {
+		if depth >= 0 {
+			opts.MaxDepth = depth
+		}
+	}
+}
+
+// WithMaxFilesPerDir limits the number of files processed in each directory (0 = no limit)
+func WithMaxFilesPerDir(limit int) Option {
+	return func(opts *WalkOptions) {
+		if limit >= 0 {
+			opts.MaxFilesDir = limit
+		}
+	}
+}
+
+// WithMaxInFlightBytes bounds the bytes of file content that concurrent
+// workers may read ahead of the files being delivered (0 = no limit). Files
+// are admitted in walk order, and a file larger than the limit is read once
+// all others are delivered, so memory use stays near the limit.
+func WithMaxInFlightBytes(limit int64) Option {
+	return func(opts *WalkOptions) {
+		if limit >= 0 {
+			opts.MaxInFlight = limit
+		}
+	}
+}
+
 // WithExtensions sets the file extensions to include (without the dot)
 func WithExtensions(extensions []string) Option {
 	return func(opts *WalkOptions) {
@@ -106,6 +162,52 @@ func WithExtensionMap(extMap map[string]struct{}) Option {
 	}
 }
 
+// WithIncludePatterns restricts processing to files matching at least one
+// gitignore-style pattern (e.g. "cmd/**/*.go", "Dockerfile*", "Makefile").
+// Patterns prefixed with '!' exclude files matched by earlier patterns.
+func WithIncludePatterns(patterns []string) Option {
+	return func(opts *WalkOptions) {
+		opts.Include = newIncludeFilter(patterns)
+	}
+}
+
+// WithBinaryPolicy sets how files detected as binary are handled
+func WithBinaryPolicy(policy BinaryPolicy) Option {
+	return func(opts *WalkOptions) {
+		if policy != "" {
+			opts.BinaryPolicy = policy
+		}
+	}
+}
+
+// WithFollowSymlinks enables resolving symlinks and descending into linked
+// directories, skipping links that loop or point outside the root
+func WithFollowSymlinks(enabled bool) Option {
+	return func(opts *WalkOptions) {
+		opts.FollowSymlinks = enabled
+	}
+}
+
+// WithSymlinkTargets emits symlinks as "path -> target" entries instead of
+// their content. Links emitted this way are never followed.
+func WithSymlinkTargets(enabled bool) Option {
+	return func(opts *WalkOptions) {
+		opts.EmitSymlinks = enabled
+	}
+}
+
+// WithPaths makes the walker visit only the listed files (slash-separated,
+// relative to the root) instead of walking the directory tree. The listed
+// files still pass through the ignore matcher and all other filters.
+func WithPaths(paths []string) Option {
+	return func(opts *WalkOptions) {
+		if paths == nil {
+			paths = []string{}
+		}
+		opts.Paths = paths
+	}
+}
+
 // WithContext sets the context for cancellation
 func WithContext(ctx context.Context) Option {
 	return func(opts *WalkOptions) {
@@ -128,3 +230,33 @@ func WithProgress(fn ProgressCallback) Option {
 		o.ProgressFn = fn
 	}
 }
+
+// WithTransform rewrites the content of text files with the given extension
+// (without the dot) before they are delivered. If fn fails, the file is
+// delivered unchanged.
+func WithTransform(extension string, fn Transform) Option {
+	return func(opts *WalkOptions) {
+		if opts.Transforms == nil {
+			opts.Transforms = make(map[string]Transform)
+		}
+		opts.Transforms[strings.ToLower(strings.TrimPrefix(extension, "."))] = fn
+	}
+}
+
+// WithStreamScan sets whether WalkStreams reads each streamed file once
+// ahead to count its lines and hash it, for callers that show them
+func WithStreamScan(enabled bool) Option {
+	return func(opts *WalkOptions) {
+		opts.ScanStreams = enabled
+	}
+}
+
+// WithTruncation keeps only the first and last lines of text files, as set by
+// limit, or by the last of rules whose gitignore-style pattern matches the
+// file. Truncated files are streamed from disk, and the lines left out are
+// replaced with a marker saying how many there were.
+func WithTruncation(limit LineLimit, rules []TruncateRule) Option {
+	return func(opts *WalkOptions) {
+		opts.Truncation = newTruncation(limit, rules)
+	}
+}
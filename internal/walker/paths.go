@@ -0,0 +1,103 @@
+package walker
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// walkPaths drives fn over a fixed list of files, as if walking the tree
+// with filepath.WalkDir: the root and each parent directory are visited
+// once before the files inside them, in lexical order, and returning
+// filepath.SkipDir for a directory skips every listed path below it.
+// Paths are slash-separated and relative to root.
+func walkPaths(root string, paths []string, fn fs.WalkDirFunc) error {
+	info, err := os.Stat(root)
+	var rootEntry fs.DirEntry
+	if err == nil {
+		rootEntry = fs.FileInfoToDirEntry(info)
+	}
+	if err := fn(root, rootEntry, err); err != nil {
+		if err == filepath.SkipDir || err == filepath.SkipAll {
+			return nil
+		}
+		return err
+	}
+
+	sorted := append([]string(nil), paths...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return comparePaths(sorted[i], sorted[j]) < 0
+	})
+
+	visited := make(map[string]bool)
+	skipped := make(map[string]bool)
+
+	for _, rel := range sorted {
+		parts := strings.Split(strings.Trim(rel, "/"), "/")
+		skip := false
+
+		// Visit parent directories first, honoring SkipDir
+		for i := 1; i < len(parts) && !skip; i++ {
+			dir := strings.Join(parts[:i], "/")
+			if skipped[dir] {
+				skip = true
+				break
+			}
+			if visited[dir] {
+				continue
+			}
+			visited[dir] = true
+
+			if err := visitPath(root, dir, fn); err != nil {
+				if err == filepath.SkipAll {
+					return nil
+				}
+				if err != filepath.SkipDir {
+					return err
+				}
+				skipped[dir] = true
+				skip = true
+			}
+		}
+		if skip {
+			continue
+		}
+
+		if err := visitPath(root, strings.Join(parts, "/"), fn); err != nil {
+			if err == filepath.SkipAll {
+				return nil
+			}
+			if err != filepath.SkipDir {
+				return err
+			}
+			// SkipDir on a file skips the remaining files in its directory
+			skipped[strings.Join(parts[:len(parts)-1], "/")] = true
+		}
+	}
+	return nil
+}
+
+// visitPath stats a listed path and passes it to fn. Missing files (for
+// example deleted but still tracked) are reported to fn as errors.
+func visitPath(root, rel string, fn fs.WalkDirFunc) error {
+	path := filepath.Join(root, filepath.FromSlash(rel))
+	info, err := os.Lstat(path)
+	if err != nil {
+		return fn(path, nil, err)
+	}
+	return fn(path, fs.FileInfoToDirEntry(info), nil)
+}
+
+// comparePaths orders slash-separated paths component by component, which
+// matches the order in which filepath.WalkDir visits them
+func comparePaths(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
@@ -2,14 +2,81 @@
 package walker
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"sync"
+
+	"github.com/bethropolis/dir-dumper/internal/language"
 )
 
+// fileResult is the outcome of reading a single file, ready to be delivered
+type fileResult struct {
+	seq          int       // Position in walk order (concurrent mode)
+	relativePath string    // Path relative to the root
+	content      []byte    // File content (nil on error or when streamed)
+	stream       io.Reader // Content streamed from disk (WalkStreams only)
+	size         int64     // Bytes stream yields
+	closer       io.Closer // Closed once the result is delivered or dropped
+	meta         FileMeta  // Metadata delivered with the content
+	err          error     // Error to report to the walkFn
+	deliver      bool      // Whether walkFn should be called at all
+	reserved     int64     // Bytes of the in-flight budget held (concurrent mode)
+}
+
+// close releases the file a streamed result reads from
+func (r fileResult) close() {
+	if r.closer != nil {
+		r.closer.Close()
+	}
+}
+
+// deliverFunc passes a result on to the caller's callback, as a File or a
+// StreamFile. On error, only the result's relative path is passed.
+type deliverFunc func(result fileResult) error
+
 // processFile handles reading a file and calling the walkFn with its content
-func processFile(path, relativePath string, options WalkOptions, walkFn WalkFunc, tracker *SkippedTracker) {
+func processFile(path, relativePath string, options WalkOptions, deliver deliverFunc, tracker *SkippedTracker) {
+	deliverFile(readFile(path, relativePath, options, tracker), options, deliver, tracker)
+}
+
+// deliverFile passes a read result on to the walkFn
+func deliverFile(result fileResult, options WalkOptions, deliver deliverFunc, tracker *SkippedTracker) {
+	defer result.close()
+	if !result.deliver {
+		return
+	}
+	if result.err != nil {
+		deliver(result)
+		return
+	}
+
+	// Call the walk function with the content
+	if result.stream != nil {
+		options.Logger.Debug("processFile Success [%s]: Streaming %d bytes. Calling walkFn.", result.relativePath, result.size)
+	} else {
+		options.Logger.Debug("processFile Success [%s]: Read %d bytes. Calling walkFn.", result.relativePath, len(result.content))
+	}
+	if err := deliver(result); err != nil {
+		if reason, ok := asSkipFile(err); ok {
+			options.Logger.Debug("processFile Skipping [%s]: Declined by callback (%s)", result.relativePath, reason)
+			tracker.Track(result.relativePath, reason, false)
+			return
+		}
+		options.Logger.Error("processFile Error [%s]: Callback function returned error: %v", result.relativePath, err)
+	}
+}
+
+// readFile reads a file and applies per-file checks, tracking skipped files.
+// It does not call the walkFn, so it is safe to run from worker goroutines.
+func readFile(path, relativePath string, options WalkOptions, tracker *SkippedTracker) fileResult {
 	options.Logger.Debug("processFile: Reading [%s]", relativePath)
+	result := fileResult{relativePath: relativePath}
 
 	// Update progress info with current file if progress reporting is enabled
 	if options.ProgressFn != nil {
@@ -18,69 +85,207 @@ func processFile(path, relativePath string, options WalkOptions, walkFn WalkFunc
 		})
 	}
 
-	// Only perform file stats if we have a size limit configured
-	if options.MaxFileSize > 0 {
-		info, err := os.Lstat(path)
-		if err != nil {
-			options.Logger.Error("processFile Error [%s]: Failed to get file info: %v", relativePath, err)
-			tracker.Track(relativePath, ReasonSkippedInfoError, false)
-			walkFn(relativePath, nil, fmt.Errorf("failed to get file info: %w", err))
-			return
+	// Stat the file itself, or its target when following symlinks
+	stat := os.Lstat
+	if options.FollowSymlinks && !options.EmitSymlinks {
+		stat = os.Stat
+	}
+	info, err := stat(path)
+	if err != nil {
+		options.Logger.Error("processFile Error [%s]: Failed to get file info: %v", relativePath, err)
+		tracker.Track(relativePath, ReasonSkippedInfoError, false)
+		result.err, result.deliver = fmt.Errorf("failed to get file info: %w", err), true
+		return result
+	}
+
+	if info.Mode()&fs.ModeSymlink != 0 {
+		if !options.EmitSymlinks {
+			options.Logger.Debug("processFile Skipping [%s]: Symlink not followed.", relativePath)
+			tracker.Track(relativePath, ReasonSkippedSymlink, false)
+			return result
 		}
 
-		if !info.Mode().IsRegular() {
-			options.Logger.Debug("processFile Skipping [%s]: Not a regular file.", relativePath)
-			tracker.Track(relativePath, ReasonSkippedNotRegular, false)
-			return
+		target, err := os.Readlink(path)
+		if err != nil {
+			options.Logger.Error("processFile Error [%s]: Failed to read symlink: %v", relativePath, err)
+			tracker.Track(relativePath, ReasonSkippedReadError, false)
+			result.err, result.deliver = fmt.Errorf("failed to read symlink: %w", err), true
+			return result
 		}
+		result.content = []byte(filepath.ToSlash(relativePath) + " -> " + target)
+		result.meta = fileMeta(relativePath, info, result.content)
+		result.deliver = true
+		return result
+	}
+
+	if !info.Mode().IsRegular() {
+		options.Logger.Debug("processFile Skipping [%s]: Not a regular file.", relativePath)
+		tracker.Track(relativePath, ReasonSkippedNotRegular, false)
+		return result
+	}
 
-		if info.Size() > options.MaxFileSize {
+	// Read file content. Text files with a line limit are streamed under it
+	// whatever their size, since only the kept lines are held in memory.
+	limit := options.Truncation.limitFor(relativePath)
+	transform := options.Transforms[fileExtension(relativePath)]
+	var content []byte
+	var text *truncatedFile
+	if !limit.IsZero() {
+		text, err = readTruncated(path, relativePath, limit)
+	}
+	if err == nil && text == nil {
+		// No line limit, or a binary file it does not apply to
+		if options.MaxFileSize > 0 && info.Size() > options.MaxFileSize {
 			options.Logger.Debug("processFile Skipping [%s]: Exceeds size limit (%d > %d bytes)",
 				relativePath, info.Size(), options.MaxFileSize)
 			tracker.Track(relativePath, ReasonSkippedSizeLimit, false)
-			walkFn(relativePath, nil, fmt.Errorf("file size %d exceeds limit %d bytes", info.Size(), options.MaxFileSize))
-			return
+			result.err = fmt.Errorf("file size %d exceeds limit %d bytes", info.Size(), options.MaxFileSize)
+			result.deliver = true
+			return result
+		}
+		if options.stream && transform == nil {
+			// Content read as is can be left on disk until it is delivered
+			return openStream(path, relativePath, info, options, tracker)
 		}
+		content, err = os.ReadFile(path)
 	}
-
-	// Read file content
-	content, err := os.ReadFile(path)
 	if err != nil {
 		options.Logger.Error("processFile Error [%s]: Failed to read file: %v", relativePath, err)
 		tracker.Track(relativePath, ReasonSkippedReadError, false)
-		walkFn(relativePath, nil, fmt.Errorf("failed to read file: %w", err))
-		return
+		result.err, result.deliver = fmt.Errorf("failed to read file: %w", err), true
+		return result
 	}
 
-	// Call the walk function with the content
-	options.Logger.Debug("processFile Success [%s]: Read %d bytes. Calling walkFn.", relativePath, len(content))
-	if err := walkFn(relativePath, content, nil); err != nil {
-		options.Logger.Error("processFile Error [%s]: Callback function returned error: %v", relativePath, err)
+	if text != nil {
+		// Text read under a line limit, described as stored
+		result.meta = text.meta(info)
+		content = text.content
+		if text.truncation.Omitted > 0 {
+			options.Logger.Debug("processFile [%s]: Truncated, %d of %d lines omitted",
+				relativePath, text.truncation.Omitted, text.lines)
+		}
+	} else {
+		// Describe the file as stored, before the binary policy applies
+		result.meta = fileMeta(relativePath, info, content)
+
+		// Detect binary content and apply the binary policy
+		if isBinary, mimeType := detectBinary(content); isBinary {
+			if options.BinaryPolicy == BinarySkip {
+				options.Logger.Debug("processFile Skipping [%s]: Binary content (%s)", relativePath, mimeType)
+				tracker.Track(relativePath, ReasonSkippedBinary, false)
+				return result
+			}
+			options.Logger.Debug("processFile [%s]: Binary content (%s), applying %q policy",
+				relativePath, mimeType, options.BinaryPolicy)
+			result.meta.Binary = true
+			result.content, result.deliver = binaryContent(content, mimeType, options.BinaryPolicy), true
+			return result
+		}
+	}
+
+	// Transforms need the whole file, so truncated files are left as they are
+	if transform != nil && result.meta.Truncation.Omitted == 0 {
+		if transformed, lines, err := transform(relativePath, content); err != nil {
+			options.Logger.Warn("Keeping full content of %s: %v", relativePath, err)
+		} else {
+			options.Logger.Debug("processFile [%s]: Transformed %d bytes into %d", relativePath, len(content), len(transformed))
+			content, result.meta.SourceLines = transformed, lines
+			result.meta.Transformed = true
+		}
 	}
+
+	result.content, result.deliver = content, true
+	return result
 }
 
-// fileProcessorWorker is the goroutine function for concurrent processing.
-func fileProcessorWorker(
+// fileMeta collects the metadata delivered with a file's content
+func fileMeta(relativePath string, info fs.FileInfo, content []byte) FileMeta {
+	sum := sha256.Sum256(content)
+	lines := bytes.Count(content, []byte("\n"))
+	if len(content) > 0 && content[len(content)-1] != '\n' {
+		lines++
+	}
+	return FileMeta{
+		Size:     info.Size(),
+		Mode:     info.Mode(),
+		ModTime:  info.ModTime(),
+		Lines:    lines,
+		Language: language.Detect(relativePath, content),
+		SHA256:   hex.EncodeToString(sum[:]),
+	}
+}
+
+// fileJob is a file queued for a worker, tagged with its walk-order position
+type fileJob struct {
+	seq          int
+	path         string
+	relativePath string
+	reserved     int64 // Bytes reserved from the in-flight budget
+}
+
+// fileReaderWorker is the goroutine function for concurrent processing.
+// It only reads files; results are delivered in walk order by the caller.
+func fileReaderWorker(
 	id int,
-	filesChan <-chan struct{ path, relativePath string },
+	jobs <-chan fileJob,
+	results chan<- fileResult,
 	wg *sync.WaitGroup,
 	options WalkOptions,
-	walkFn WalkFunc,
 	tracker *SkippedTracker,
 ) {
 	defer wg.Done()
 	options.Logger.Debug("Worker %d: Started", id)
 
-	for item := range filesChan {
+	for job := range jobs {
 		select {
 		case <-options.Context.Done():
 			options.Logger.Debug("Worker %d: Received cancellation signal", id)
 			return
 		default:
-			options.Logger.Debug("Worker %d: Processing file [%s]", id, item.relativePath)
-			processFile(item.path, item.relativePath, options, walkFn, tracker)
+			options.Logger.Debug("Worker %d: Processing file [%s]", id, job.relativePath)
+			result := readFile(job.path, job.relativePath, options, tracker)
+			result.seq, result.reserved = job.seq, job.reserved
+			results <- result
 		}
 	}
 
 	options.Logger.Debug("Worker %d: Finished", id)
 }
+
+// deliverInOrder receives results from workers and delivers them to walkFn
+// in walk order, buffering results that arrive early. Each delivered result
+// releases one slot of the in-flight window, which bounds the buffer size,
+// and the bytes it reserved from the budget.
+func deliverInOrder(
+	results <-chan fileResult,
+	window <-chan struct{},
+	budget *byteBudget,
+	options WalkOptions,
+	deliver deliverFunc,
+	tracker *SkippedTracker,
+) {
+	pending := make(map[int]fileResult)
+	next := 0
+
+	for result := range results {
+		pending[result.seq] = result
+		for {
+			ready, ok := pending[next]
+			if !ok {
+				break
+			}
+			delete(pending, next)
+			deliverFile(ready, options, deliver, tracker)
+			budget.release(ready.reserved)
+			<-window
+			next++
+		}
+	}
+
+	if len(pending) > 0 {
+		options.Logger.Debug("Walker: Dropped %d out-of-order results after cancellation", len(pending))
+		for _, result := range pending {
+			result.close()
+		}
+	}
+}
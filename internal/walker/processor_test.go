@@ -0,0 +1,81 @@
+package walker
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+// walkAll walks root with WalkFiles and returns every file delivered
+func walkAll(t *testing.T, root string, opts ...Option) map[string]File {
+	t.Helper()
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]File)
+	_, err = WalkFiles(root, matcher, func(file File, err error) error {
+		if err != nil {
+			t.Errorf("%s: %v", file.RelativePath, err)
+			return nil
+		}
+		files[file.RelativePath] = file
+		return nil
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// TestTransformMeta checks that transformed files say so and carry their
+// line mapping, and that files whose transform fails are left as they are
+func TestTransformMeta(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "a.src", "keep\ndrop\nkeep\n")
+	writeFile(t, root, "bad.src", "fail\n")
+	writeFile(t, root, "plain.txt", "drop\n")
+
+	dropLines := func(_ string, content []byte) ([]byte, []int, error) {
+		if bytes.HasPrefix(content, []byte("fail")) {
+			return nil, nil, fmt.Errorf("cannot transform")
+		}
+		return []byte("keep\nkeep\n"), []int{1, 3}, nil
+	}
+	files := walkAll(t, root, WithTransform(".src", dropLines))
+
+	a := files["a.src"]
+	if string(a.Content) != "keep\nkeep\n" || !a.Meta.Transformed || !reflect.DeepEqual(a.Meta.SourceLines, []int{1, 3}) {
+		t.Errorf("a.src = %q, meta %+v", a.Content, a.Meta)
+	}
+	if a.Meta.Lines != 3 || a.Meta.SourceLine(2) != 3 {
+		t.Errorf("a.src is not described as stored: %+v", a.Meta)
+	}
+	for _, path := range []string{"bad.src", "plain.txt"} {
+		if file := files[path]; file.Meta.Transformed || file.Meta.SourceLines != nil {
+			t.Errorf("%s marked as transformed: %+v", path, file.Meta)
+		}
+	}
+}
+
+// TestBinaryMeta checks that files replaced by the binary policy say so,
+// whether they are read or streamed
+func TestBinaryMeta(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "data.bin", "a\x00b\n")
+	writeFile(t, root, "text.txt", "text\n")
+
+	for _, policy := range []BinaryPolicy{BinaryPlaceholder, BinaryBase64} {
+		files := walkAll(t, root, WithBinaryPolicy(policy))
+		streamed := streamAll(t, root, WithBinaryPolicy(policy))
+		if !files["data.bin"].Meta.Binary || !streamed["data.bin"].Meta.Binary {
+			t.Errorf("%s: data.bin not marked as binary", policy)
+		}
+		if files["text.txt"].Meta.Binary || streamed["text.txt"].Meta.Binary {
+			t.Errorf("%s: text.txt marked as binary", policy)
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package walker
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"unicode/utf8"
+
+	"github.com/bethropolis/dir-dumper/internal/language"
+)
+
+// streamBlockSize is the size of the blocks files are scanned and encoded in
+const streamBlockSize = 64 * 1024
+
+// fileScan is what reading a file ahead of streaming it found
+type fileScan struct {
+	size   int64  // Bytes read
+	lines  int    // Lines, counted like fileMeta does (0 if only sniffed)
+	sha256 string // Hex-encoded SHA-256 of the content ("" if only sniffed)
+	sample []byte // The first bytes, for binary and language detection
+}
+
+// sniffFile reads only the start of r, enough to detect binary content and
+// the language, for a file of the given size
+func sniffFile(r io.Reader, size int64) (fileScan, error) {
+	sample := make([]byte, min(size, binarySniffLen+utf8.UTFMax))
+	n, err := io.ReadFull(r, sample)
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		err = nil // The file shrank since it was listed
+	}
+	return fileScan{size: size, sample: sample[:n]}, err
+}
+
+// scanFile reads r to its end, hashing it and counting its lines without
+// holding more than a block of it in memory
+func scanFile(r io.Reader) (fileScan, error) {
+	var scan fileScan
+	hash := sha256.New()
+	buf := make([]byte, streamBlockSize)
+	var last byte
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			block := buf[:n]
+			hash.Write(block)
+			scan.lines += bytes.Count(block, []byte("\n"))
+			if room := binarySniffLen + utf8.UTFMax - len(scan.sample); room > 0 {
+				scan.sample = append(scan.sample, block[:min(n, room)]...)
+			}
+			scan.size += int64(n)
+			last = block[n-1]
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return scan, err
+		}
+	}
+	if scan.size > 0 && last != '\n' {
+		scan.lines++
+	}
+	scan.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return scan, nil
+}
+
+// openStream prepares a file to be streamed to the walkFn instead of being
+// read into memory. The start of the file is read ahead to detect binary
+// content, or the whole file with ScanStreams to count its lines and hash it,
+// and the file is left open for the walkFn to read it from the start.
+func openStream(path, relativePath string, info fs.FileInfo, options WalkOptions, tracker *SkippedTracker) fileResult {
+	result := fileResult{relativePath: relativePath}
+	fail := func(err error) fileResult {
+		options.Logger.Error("processFile Error [%s]: Failed to read file: %v", relativePath, err)
+		tracker.Track(relativePath, ReasonSkippedReadError, false)
+		result.err, result.deliver = fmt.Errorf("failed to read file: %w", err), true
+		return result
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fail(err)
+	}
+	var scan fileScan
+	if options.ScanStreams {
+		scan, err = scanFile(f)
+	} else {
+		scan, err = sniffFile(f, info.Size())
+	}
+	if err != nil {
+		f.Close()
+		return fail(err)
+	}
+	result.meta = FileMeta{
+		Size:     info.Size(),
+		Mode:     info.Mode(),
+		ModTime:  info.ModTime(),
+		Lines:    scan.lines,
+		Language: language.Detect(relativePath, scan.sample),
+		SHA256:   scan.sha256,
+	}
+	content := io.NewSectionReader(f, 0, scan.size)
+
+	// Detect binary content and apply the binary policy
+	if isBinary, mimeType := detectBinary(scan.sample); isBinary {
+		result.meta.Binary = true
+		switch options.BinaryPolicy {
+		case BinarySkip:
+			f.Close()
+			options.Logger.Debug("processFile Skipping [%s]: Binary content (%s)", relativePath, mimeType)
+			tracker.Track(relativePath, ReasonSkippedBinary, false)
+			return result
+		case BinaryBase64:
+			options.Logger.Debug("processFile [%s]: Binary content (%s), streaming as base64", relativePath, mimeType)
+			result.stream = &base64Reader{src: content}
+			result.size = int64(base64.StdEncoding.EncodedLen(int(scan.size)))
+		default:
+			f.Close()
+			options.Logger.Debug("processFile [%s]: Binary content (%s), applying %q policy",
+				relativePath, mimeType, options.BinaryPolicy)
+			result.content, result.deliver = binaryPlaceholder(scan.size, mimeType), true
+			return result
+		}
+	} else {
+		result.stream, result.size = content, scan.size
+	}
+
+	result.closer, result.deliver = f, true
+	return result
+}
+
+// base64Reader reads the standard base64 encoding of src
+type base64Reader struct {
+	src io.ReadSeeker
+	in  [3 * streamBlockSize / 4]byte // A multiple of 3, so padding only ends the output
+	out [streamBlockSize]byte
+	buf []byte // Encoded bytes not read yet
+	err error  // Error to return once buf is drained
+	pos int64  // Encoded bytes read
+}
+
+// Read implements io.Reader
+func (r *base64Reader) Read(p []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		n, err := io.ReadFull(r.src, r.in[:])
+		if n > 0 {
+			m := base64.StdEncoding.EncodedLen(n)
+			base64.StdEncoding.Encode(r.out[:m], r.in[:n])
+			r.buf = r.out[:m]
+		}
+		switch err {
+		case nil:
+		case io.EOF, io.ErrUnexpectedEOF:
+			r.err = io.EOF
+		default:
+			r.err = err
+		}
+	}
+	n := copy(p, r.buf)
+	r.buf = r.buf[n:]
+	r.pos += int64(n)
+	return n, nil
+}
+
+// Seek implements io.Seeker for rewinding: it reports the current offset
+// and seeks back to the start, but cannot seek anywhere else
+func (r *base64Reader) Seek(offset int64, whence int) (int64, error) {
+	switch {
+	case whence == io.SeekCurrent && offset == 0:
+		return r.pos, nil
+	case whence == io.SeekStart && offset == 0:
+		if _, err := r.src.Seek(0, io.SeekStart); err != nil {
+			return r.pos, err
+		}
+		r.buf, r.err, r.pos = nil, nil, 0
+		return 0, nil
+	}
+	return r.pos, fmt.Errorf("walker: base64 content can only be rewound")
+}
@@ -0,0 +1,78 @@
+package walker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+// streamAll walks root with WalkStreams and returns every file delivered,
+// with its content read
+func streamAll(t *testing.T, root string, opts ...Option) map[string]StreamFile {
+	t.Helper()
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := make(map[string]StreamFile)
+	_, err = WalkStreams(root, matcher, func(file StreamFile, err error) error {
+		if err != nil {
+			t.Errorf("%s: %v", file.RelativePath, err)
+			return nil
+		}
+		content, err := io.ReadAll(file.Content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		file.Content = strings.NewReader(string(content))
+		files[file.RelativePath] = file
+		return nil
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+// TestWalkStreamsScan checks that streamed files are only counted and hashed
+// when asked to, and that binary files are still detected from their start
+func TestWalkStreamsScan(t *testing.T) {
+	root := t.TempDir()
+	text := strings.Repeat("a line of text\n", 10000) + "no newline"
+	writeFile(t, root, "big.txt", text)
+	writeFile(t, root, "empty.txt", "")
+	writeFile(t, root, "data.bin", "\x00\x01\x02"+text)
+	sum := sha256.Sum256([]byte(text))
+
+	for _, scan := range []bool{false, true} {
+		files := streamAll(t, root, WithStreamScan(scan))
+		if _, ok := files["data.bin"]; ok {
+			t.Errorf("scan %v: binary file streamed", scan)
+		}
+		file, ok := files["big.txt"]
+		if !ok {
+			t.Fatalf("scan %v: big.txt not delivered", scan)
+		}
+		if content, _ := io.ReadAll(file.Content); string(content) != text || file.Size != int64(len(text)) {
+			t.Errorf("scan %v: streamed %d bytes, size %d, want %d", scan, len(content), file.Size, len(text))
+		}
+		if file.Meta.Size != int64(len(text)) || file.Meta.Language == "" {
+			t.Errorf("scan %v: meta = %+v", scan, file.Meta)
+		}
+
+		wantLines, wantSHA := 0, ""
+		if scan {
+			wantLines, wantSHA = 10001, hex.EncodeToString(sum[:])
+		}
+		if file.Meta.Lines != wantLines || file.Meta.SHA256 != wantSHA {
+			t.Errorf("scan %v: lines %d, sha256 %q, want %d and %q", scan, file.Meta.Lines, file.Meta.SHA256, wantLines, wantSHA)
+		}
+		if _, ok := files["empty.txt"]; !ok {
+			t.Errorf("scan %v: empty file not delivered", scan)
+		}
+	}
+}
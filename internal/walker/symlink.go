@@ -0,0 +1,132 @@
+package walker
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// errSymlinkLoop is reported for symlinks that lead back to a directory
+	// already being walked
+	errSymlinkLoop = errors.New("symlink loop detected")
+
+	// errSymlinkEscapesRoot is reported for symlinks whose target lies
+	// outside the root directory
+	errSymlinkEscapesRoot = errors.New("symlink target is outside the root directory")
+)
+
+// symlinkWalker walks a directory tree like filepath.WalkDir, but resolves
+// symlinks and descends into symlinked directories. Paths passed to fn are
+// the logical paths under root, not the link targets.
+type symlinkWalker struct {
+	realRoot string
+	fn       fs.WalkDirFunc
+}
+
+// walkFollowingSymlinks is a drop-in replacement for filepath.WalkDir that
+// follows symlinks. Loops are detected by comparing each linked directory
+// against the directories currently being walked with os.SameFile, which
+// compares device and inode numbers on Unix systems.
+func walkFollowingSymlinks(root string, fn fs.WalkDirFunc) error {
+	info, err := os.Stat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		realRoot, evalErr := filepath.EvalSymlinks(root)
+		if evalErr != nil {
+			realRoot = root
+		}
+		w := &symlinkWalker{realRoot: realRoot, fn: fn}
+		err = w.walk(root, fs.FileInfoToDirEntry(info), []fs.FileInfo{info})
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walk visits path and, for directories, its children. ancestors holds the
+// resolved info of every directory from the root down to path.
+func (w *symlinkWalker) walk(path string, d fs.DirEntry, ancestors []fs.FileInfo) error {
+	if err := w.fn(path, d, nil); err != nil || !d.IsDir() {
+		if err == filepath.SkipDir && d.IsDir() {
+			return nil
+		}
+		return err
+	}
+
+	entries, readErr := os.ReadDir(path)
+	if readErr != nil {
+		// Second call for the same directory, as filepath.WalkDir does
+		if err := w.fn(path, d, readErr); err != nil {
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		child := filepath.Join(path, entry.Name())
+		childAncestors := ancestors
+
+		if entry.Type()&fs.ModeSymlink != 0 {
+			resolved, info, linkErr := w.resolve(child, entry, ancestors)
+			if linkErr != nil {
+				if err := w.fn(child, resolved, linkErr); err != nil && err != filepath.SkipDir {
+					return err
+				}
+				continue
+			}
+			entry = resolved
+			if info.IsDir() {
+				childAncestors = append(ancestors[:len(ancestors):len(ancestors)], info)
+			}
+		} else if entry.IsDir() {
+			info, err := entry.Info()
+			if err == nil {
+				childAncestors = append(ancestors[:len(ancestors):len(ancestors)], info)
+			}
+		}
+
+		if err := w.walk(child, entry, childAncestors); err != nil {
+			if err == filepath.SkipDir {
+				break
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve follows a symlink and checks that its target stays inside the root
+// and does not lead back to a directory on the current path
+func (w *symlinkWalker) resolve(path string, link fs.DirEntry, ancestors []fs.FileInfo) (fs.DirEntry, fs.FileInfo, error) {
+	// os.Stat keeps the link's own name, so the entry reports the logical name
+	info, err := os.Stat(path)
+	if err != nil {
+		return link, nil, err
+	}
+	resolved := fs.FileInfoToDirEntry(info)
+
+	target, err := filepath.EvalSymlinks(path)
+	if err != nil {
+		return resolved, info, err
+	}
+	rel, err := filepath.Rel(w.realRoot, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return resolved, info, errSymlinkEscapesRoot
+	}
+
+	if info.IsDir() {
+		for _, ancestor := range ancestors {
+			if os.SameFile(ancestor, info) {
+				return resolved, info, errSymlinkLoop
+			}
+		}
+	}
+	return resolved, info, nil
+}
@@ -0,0 +1,111 @@
+package walker
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// symlink creates a symlink at name in dir pointing to target, skipping the
+// test where symlinks cannot be created
+func symlink(t *testing.T, dir, name, target string) {
+	t.Helper()
+	if err := os.Symlink(target, filepath.Join(dir, name)); err != nil {
+		t.Skipf("cannot create symlinks: %v", err)
+	}
+}
+
+// symlinkTree builds a root with a linked directory and file, two loops, two
+// links escaping the root and a dangling link
+func symlinkTree(t *testing.T) string {
+	root := t.TempDir()
+	outside := t.TempDir()
+	writeFile(t, root, "real/a.txt", "a\n")
+	writeFile(t, outside, "secret.txt", "outside\n")
+
+	symlink(t, root, "linkdir", "real")
+	symlink(t, root, "linkfile.txt", filepath.Join("real", "a.txt"))
+	symlink(t, root, "self", ".")
+	symlink(t, filepath.Join(root, "real"), "parent", "..")
+	symlink(t, root, "outdir", outside)
+	symlink(t, root, "outfile.txt", filepath.Join(outside, "secret.txt"))
+	symlink(t, root, "dangling.txt", "missing.txt")
+	return root
+}
+
+func TestFollowSymlinks(t *testing.T) {
+	root := symlinkTree(t)
+	for _, concurrent := range []bool{false, true} {
+		delivered, reasons := walkResults(t, root, WithFollowSymlinks(true), WithConcurrency(concurrent))
+
+		want := []string{"linkdir/a.txt", "linkfile.txt", "real/a.txt"}
+		if !reflect.DeepEqual(delivered, want) {
+			t.Errorf("concurrent=%v: delivered %q, want %q", concurrent, delivered, want)
+		}
+		wantReasons := map[string]SkippedReason{
+			"self":           ReasonSymlinkLoop,
+			"real/parent":    ReasonSymlinkLoop,
+			"linkdir/parent": ReasonSymlinkLoop,
+			"outdir":         ReasonSymlinkEscapes,
+			"outfile.txt":    ReasonSymlinkEscapes,
+			"dangling.txt":   ReasonSkippedWalkError,
+		}
+		for path, want := range wantReasons {
+			if reasons[path] != want {
+				t.Errorf("concurrent=%v: %s skipped as %q, want %q", concurrent, path, reasons[path], want)
+			}
+		}
+	}
+}
+
+// TestFollowSymlinksLinkedRoot checks that a root reached through a symlink
+// does not make links inside it look like they escape
+func TestFollowSymlinksLinkedRoot(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "tree/real/a.txt", "a\n")
+	symlink(t, filepath.Join(root, "tree"), "linkdir", "real")
+	symlink(t, root, "rootlink", "tree")
+
+	delivered, reasons := walkResults(t, filepath.Join(root, "rootlink"), WithFollowSymlinks(true))
+	if want := []string{"linkdir/a.txt", "real/a.txt"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %q, want %q (skipped: %v)", delivered, want, reasons)
+	}
+}
+
+func TestSymlinksNotFollowed(t *testing.T) {
+	root := symlinkTree(t)
+	delivered, reasons := walkResults(t, root)
+
+	if want := []string{"real/a.txt"}; !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %q, want %q", delivered, want)
+	}
+	for _, path := range []string{"linkdir", "linkfile.txt", "self", "real/parent", "outdir", "outfile.txt", "dangling.txt"} {
+		if reasons[path] != ReasonSkippedSymlink {
+			t.Errorf("%s skipped as %q, want %q", path, reasons[path], ReasonSkippedSymlink)
+		}
+	}
+}
+
+func TestSymlinkTargets(t *testing.T) {
+	root := t.TempDir()
+	writeFile(t, root, "real/a.txt", "a\n")
+	symlink(t, root, "linkdir", "real")
+	symlink(t, root, "self", ".")
+
+	// Links emitted as targets are never followed, so loops cannot arise
+	files := walkAll(t, root, WithSymlinkTargets(true), WithFollowSymlinks(true))
+	want := map[string]string{
+		"real/a.txt": "a\n",
+		"linkdir":    "linkdir -> real",
+		"self":       "self -> .",
+	}
+	if len(files) != len(want) {
+		t.Errorf("delivered %d files, want %d", len(files), len(want))
+	}
+	for path, content := range want {
+		if got := string(files[filepath.FromSlash(path)].Content); got != content {
+			t.Errorf("%s = %q, want %q", path, got, content)
+		}
+	}
+}
@@ -0,0 +1,273 @@
+package walker
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+	"github.com/bethropolis/dir-dumper/internal/language"
+)
+
+// LineLimit keeps the first Head and the last Tail lines of a text file,
+// replacing the lines between them with an elision marker. A zero LineLimit
+// keeps files whole.
+type LineLimit struct {
+	Head int
+	Tail int
+}
+
+// IsZero reports whether the limit keeps files whole
+func (l LineLimit) IsZero() bool {
+	return l.Head <= 0 && l.Tail <= 0
+}
+
+// String formats the limit the way ParseLineLimit accepts it
+func (l LineLimit) String() string {
+	var parts []string
+	if l.Head > 0 {
+		parts = append(parts, "head:"+strconv.Itoa(l.Head))
+	}
+	if l.Tail > 0 {
+		parts = append(parts, "tail:"+strconv.Itoa(l.Tail))
+	}
+	if len(parts) == 0 {
+		return "none"
+	}
+	return strings.Join(parts, "+")
+}
+
+// ParseLineLimit parses a line limit: "head:N", "tail:N", "head:N+tail:N",
+// or "none" to keep files whole
+func ParseLineLimit(spec string) (LineLimit, error) {
+	var limit LineLimit
+	spec = strings.ToLower(strings.TrimSpace(spec))
+	if spec == "none" {
+		return limit, nil
+	}
+	for _, part := range strings.Split(spec, "+") {
+		end, value, ok := strings.Cut(strings.TrimSpace(part), ":")
+		n, err := strconv.Atoi(value)
+		if !ok || err != nil || n <= 0 {
+			return limit, fmt.Errorf("invalid line limit %q (want head:N, tail:N, head:N+tail:N or none)", spec)
+		}
+		switch end {
+		case "head":
+			limit.Head = n
+		case "tail":
+			limit.Tail = n
+		default:
+			return limit, fmt.Errorf("invalid line limit %q (want head:N, tail:N, head:N+tail:N or none)", spec)
+		}
+	}
+	return limit, nil
+}
+
+// TruncateRule sets the line limit of files matching a gitignore-style
+// pattern
+type TruncateRule struct {
+	Pattern string
+	Limit   LineLimit
+}
+
+// ParseTruncateRules parses comma-separated pattern=limit rules, such as
+// "*.log=tail:200,*.go=head:100,*.md=none"
+func ParseTruncateRules(spec string) ([]TruncateRule, error) {
+	var rules []TruncateRule
+	for _, rule := range strings.Split(spec, ",") {
+		if rule = strings.TrimSpace(rule); rule == "" {
+			continue
+		}
+		i := strings.LastIndex(rule, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid truncate rule %q (want pattern=limit)", rule)
+		}
+		pattern := strings.TrimSpace(rule[:i])
+		if strings.HasPrefix(pattern, "!") {
+			return nil, fmt.Errorf("invalid truncate rule %q: patterns cannot be negated", rule)
+		}
+		limit, err := ParseLineLimit(rule[i+1:])
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, TruncateRule{Pattern: pattern, Limit: limit})
+	}
+	return rules, nil
+}
+
+// Truncation describes the lines of a file left out of its content
+type Truncation struct {
+	Head    int // Lines kept before the elision marker
+	Omitted int // Lines replaced by the marker (0 if the content is complete)
+}
+
+// elisionMarker returns the line that replaces the lines left out of a
+// truncated file
+func elisionMarker(omitted int) string {
+	if omitted == 1 {
+		return "[... 1 line omitted ...]\n"
+	}
+	return fmt.Sprintf("[... %d lines omitted ...]\n", omitted)
+}
+
+// truncationRule is a TruncateRule with its pattern compiled
+type truncationRule struct {
+	pattern *ignore.Pattern
+	limit   LineLimit
+}
+
+// truncation picks the line limit of each file
+type truncation struct {
+	defaults LineLimit
+	rules    []truncationRule
+}
+
+// newTruncation compiles the truncation rules, returning nil if no file is
+// ever truncated
+func newTruncation(defaults LineLimit, rules []TruncateRule) *truncation {
+	t := &truncation{defaults: defaults}
+	for _, rule := range rules {
+		if p := ignore.ParsePattern(rule.Pattern, ""); p != nil {
+			t.rules = append(t.rules, truncationRule{pattern: p, limit: rule.Limit})
+		}
+	}
+	if defaults.IsZero() && len(t.rules) == 0 {
+		return nil
+	}
+	return t
+}
+
+// limitFor returns the line limit of a file: that of the last rule matching
+// it, or the default
+func (t *truncation) limitFor(relativePath string) LineLimit {
+	if t == nil {
+		return LineLimit{}
+	}
+	limit := t.defaults
+	rel := filepath.ToSlash(relativePath)
+	for _, r := range t.rules {
+		if r.pattern.Match(rel, false) || matchesParentDir(r.pattern, rel) {
+			limit = r.limit
+		}
+	}
+	return limit
+}
+
+// truncatedFile is a text file read under a line limit
+type truncatedFile struct {
+	content    []byte // The kept lines, with the elision marker
+	lines      int    // Lines in the whole file
+	sha256     string // Hex-encoded SHA-256 of the whole file
+	language   string
+	truncation Truncation
+}
+
+// meta describes the file as stored, along with the lines left out of it
+func (t *truncatedFile) meta(info fs.FileInfo) FileMeta {
+	return FileMeta{
+		Size:       info.Size(),
+		Mode:       info.Mode(),
+		ModTime:    info.ModTime(),
+		Lines:      t.lines,
+		Language:   t.language,
+		SHA256:     t.sha256,
+		Truncation: t.truncation,
+	}
+}
+
+// readTruncated reads the file at path keeping only the lines limit allows.
+// The file is streamed, so only the kept lines are held in memory, but it is
+// still hashed and its lines counted as a whole. A line limit does not apply
+// to binary files, for which it returns nil without reading further.
+func readTruncated(path, relativePath string, limit LineLimit) (*truncatedFile, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	hash := sha256.New()
+	r := bufio.NewReaderSize(io.TeeReader(f, hash), 64*1024)
+
+	// Sniff the start of the file like detectBinary does for whole files
+	sample, err := r.Peek(binarySniffLen + utf8.UTFMax)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if isBinary, _ := detectBinary(sample); isBinary {
+		return nil, nil
+	}
+	file := &truncatedFile{language: language.Detect(relativePath, sample)}
+
+	var head bytes.Buffer
+	var ring [][]byte // The last lines read past the head, grown up to limit.Tail
+	tailed := 0       // Lines passed through the ring
+	inLine := false   // The last chunk read did not end its line
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(chunk) > 0 {
+			inHead := file.lines < limit.Head
+			if inHead {
+				head.Write(chunk)
+			} else if limit.Tail > 0 {
+				slot := tailed % limit.Tail
+				if slot == len(ring) {
+					ring = append(ring, nil)
+				} else if !inLine {
+					ring[slot] = ring[slot][:0]
+				}
+				ring[slot] = append(ring[slot], chunk...)
+			}
+			inLine = chunk[len(chunk)-1] != '\n'
+			if !inLine {
+				file.lines++
+				if !inHead && limit.Tail > 0 {
+					tailed++
+				}
+			}
+		}
+		if err == bufio.ErrBufferFull {
+			continue // A long line, read in parts
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if inLine {
+		// A final line without a newline
+		if file.lines >= limit.Head && limit.Tail > 0 {
+			tailed++
+		}
+		file.lines++
+	}
+
+	kept := min(tailed, limit.Tail)
+	file.truncation = Truncation{
+		Head:    min(file.lines, limit.Head),
+		Omitted: file.lines - min(file.lines, limit.Head) - kept,
+	}
+	if file.truncation.Omitted > 0 {
+		head.WriteString(elisionMarker(file.truncation.Omitted))
+	}
+	for k := tailed - kept; k < tailed; k++ {
+		head.Write(ring[k%limit.Tail])
+	}
+	file.content = head.Bytes()
+	if file.content == nil {
+		file.content = []byte{} // Empty files are still delivered
+	}
+	file.sha256 = hex.EncodeToString(hash.Sum(nil))
+	return file, nil
+}
@@ -0,0 +1,140 @@
+package walker
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+// writeFile writes content to name in dir, creating its parent
+// directories, and returns its path
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadTruncated(t *testing.T) {
+	const five = "1\n2\n3\n4\n5\n"
+	long := strings.Repeat("x", 100*1024) // Longer than the read buffer
+
+	tests := []struct {
+		name       string
+		content    string
+		limit      LineLimit
+		want       string
+		lines      int
+		truncation Truncation
+	}{
+		{"head only", five, LineLimit{Head: 2}, "1\n2\n[... 3 lines omitted ...]\n", 5, Truncation{Head: 2, Omitted: 3}},
+		{"tail only", five, LineLimit{Tail: 2}, "[... 3 lines omitted ...]\n4\n5\n", 5, Truncation{Omitted: 3}},
+		{"head and tail", five, LineLimit{Head: 2, Tail: 2}, "1\n2\n[... 1 line omitted ...]\n4\n5\n", 5, Truncation{Head: 2, Omitted: 1}},
+		{"head and tail overlap", five, LineLimit{Head: 3, Tail: 3}, five, 5, Truncation{Head: 3}},
+		{"head and tail meet", five, LineLimit{Head: 2, Tail: 3}, five, 5, Truncation{Head: 2}},
+		{"head longer than file", five, LineLimit{Head: 10}, five, 5, Truncation{Head: 5}},
+		{"tail longer than file", five, LineLimit{Tail: 10}, five, 5, Truncation{}},
+		{"tail far longer than file", five, LineLimit{Tail: 100_000_000}, five, 5, Truncation{}},
+		{"empty file", "", LineLimit{Head: 2, Tail: 2}, "", 0, Truncation{}},
+		{"no trailing newline, tail", "1\n2\n3\n4\n5", LineLimit{Tail: 2}, "[... 3 lines omitted ...]\n4\n5", 5, Truncation{Omitted: 3}},
+		{"no trailing newline, head and tail", "1\n2\n3\n4\n5", LineLimit{Head: 2, Tail: 1}, "1\n2\n[... 2 lines omitted ...]\n5", 5, Truncation{Head: 2, Omitted: 2}},
+		{"no trailing newline, whole", "1\n2\n3", LineLimit{Head: 3}, "1\n2\n3", 3, Truncation{Head: 3}},
+		{"single line without newline", "only", LineLimit{Tail: 1}, "only", 1, Truncation{}},
+		{"long lines", "a\n" + long + "\n" + long + "\nb\n", LineLimit{Head: 1, Tail: 2}, "a\n[... 1 line omitted ...]\n" + long + "\nb\n", 4, Truncation{Head: 1, Omitted: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeFile(t, t.TempDir(), "file.txt", tt.content)
+			file, err := readTruncated(path, "file.txt", tt.limit)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if file == nil {
+				t.Fatal("text file read as binary")
+			}
+			if string(file.content) != tt.want {
+				t.Errorf("content = %q, want %q", file.content, tt.want)
+			}
+			if file.content == nil {
+				t.Error("content is nil, so the file would not be delivered")
+			}
+			if file.lines != tt.lines {
+				t.Errorf("lines = %d, want %d", file.lines, tt.lines)
+			}
+			if file.truncation != tt.truncation {
+				t.Errorf("truncation = %+v, want %+v", file.truncation, tt.truncation)
+			}
+			if sum := sha256.Sum256([]byte(tt.content)); file.sha256 != hex.EncodeToString(sum[:]) {
+				t.Errorf("sha256 = %s, want that of the whole file", file.sha256)
+			}
+		})
+	}
+}
+
+func TestReadTruncatedBinary(t *testing.T) {
+	path := writeFile(t, t.TempDir(), "file.bin", "\x00\x01\x02\n\x03\n")
+	file, err := readTruncated(path, "file.bin", LineLimit{Head: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file != nil {
+		t.Errorf("binary file truncated to %q", file.content)
+	}
+}
+
+// TestLineLimitOverridesMaxFileSize checks that files larger than the size
+// limit are truncated rather than skipped when a line limit applies to them,
+// while binary files, which line limits leave whole, are still skipped
+func TestLineLimitOverridesMaxFileSize(t *testing.T) {
+	root := t.TempDir()
+	var big strings.Builder
+	for i := 0; i < 1000; i++ {
+		big.WriteString("log line\n")
+	}
+	writeFile(t, root, "big.log", big.String())
+	writeFile(t, root, "big.txt", big.String())
+	writeFile(t, root, "big.bin", "\x00"+big.String())
+	writeFile(t, root, "small.txt", "small\n")
+
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules := []TruncateRule{{Pattern: "*.log", Limit: LineLimit{Tail: 2}}, {Pattern: "*.bin", Limit: LineLimit{Tail: 2}}}
+	delivered := make(map[string]string)
+	skipped, err := WalkFiles(root, matcher, func(file File, err error) error {
+		if err == nil {
+			delivered[file.RelativePath] = string(file.Content)
+		}
+		return nil
+	}, WithMaxFileSize(100), WithTruncation(LineLimit{}, rules))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, want := delivered["big.log"], "[... 998 lines omitted ...]\nlog line\nlog line\n"; got != want {
+		t.Errorf("big.log = %q, want %q", got, want)
+	}
+	if got := delivered["small.txt"]; got != "small\n" {
+		t.Errorf("small.txt = %q", got)
+	}
+	bySize := make(map[string]bool)
+	for _, item := range skipped {
+		bySize[item.Path] = item.Reason == ReasonSkippedSizeLimit
+	}
+	for _, path := range []string{"big.txt", "big.bin"} {
+		if _, ok := delivered[path]; ok || !bySize[path] {
+			t.Errorf("%s was not skipped for its size", path)
+		}
+	}
+}
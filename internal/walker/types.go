@@ -2,12 +2,99 @@
 package walker
 
 import (
+	"errors"
+	"io"
+	"io/fs"
 	"sync"
+	"time"
 )
 
 // WalkFunc is the callback function type used by Walk
 type WalkFunc func(relativePath string, content []byte, err error) error
 
+// FileFunc is the callback function type used by WalkFiles. On error, only
+// file.RelativePath is set.
+type FileFunc func(file File, err error) error
+
+// StreamFunc is the callback function type used by WalkStreams. On error,
+// only file.RelativePath is set.
+type StreamFunc func(file StreamFile, err error) error
+
+// Transform rewrites the content of a file before it is delivered. If it
+// removes or adds lines, it also returns the line in content of each line of
+// the result; nil means lines are kept one to one.
+type Transform func(relativePath string, content []byte) ([]byte, []int, error)
+
+// File is a file delivered by WalkFiles
+type File struct {
+	RelativePath string
+	Content      []byte
+	Meta         FileMeta
+}
+
+// StreamFile is a file delivered by WalkStreams. Content yields Size bytes,
+// unless the file changes while it is read, and is only valid until the
+// callback returns. Content may also implement io.Seeker, for callbacks that
+// need to read it more than once.
+type StreamFile struct {
+	RelativePath string
+	Size         int64
+	Content      io.Reader
+	Meta         FileMeta
+}
+
+// FileMeta describes a delivered file. Size, Lines and SHA256 refer to the
+// file as stored, before a binary policy or a line limit replaces its content.
+// Files streamed by WalkStreams only have Lines and SHA256 with WithStreamScan.
+type FileMeta struct {
+	Size       int64
+	Mode       fs.FileMode
+	ModTime    time.Time
+	Lines      int        // Number of lines; a final line without a newline counts
+	Language   string     // Detected language identifier ("" if unknown)
+	SHA256     string     // Hex-encoded SHA-256 of the content
+	Truncation Truncation // Lines left out by a line limit (zero if none)
+	// SourceLines holds the line in the file of each content line, when a
+	// transform removed lines (nil otherwise)
+	SourceLines []int
+	Transformed bool // Content was rewritten by a transform
+	Binary      bool // Content looked binary, so the binary policy replaced it
+}
+
+// SourceLine returns the line in the file of content line n
+func (m FileMeta) SourceLine(n int) int {
+	if n > 0 && n <= len(m.SourceLines) {
+		return m.SourceLines[n-1]
+	}
+	return n
+}
+
+// SkipFileError is returned by a WalkFunc to record that it declined a file.
+// The walk continues and the file is tracked as skipped with Reason.
+type SkipFileError struct {
+	Reason SkippedReason
+}
+
+// Error implements the error interface
+func (e *SkipFileError) Error() string {
+	return string(e.Reason)
+}
+
+// SkipFile returns an error that makes the walker track the current file as
+// skipped for reason instead of logging a callback failure
+func SkipFile(reason SkippedReason) error {
+	return &SkipFileError{Reason: reason}
+}
+
+// asSkipFile reports whether err asks to skip the file, and why
+func asSkipFile(err error) (SkippedReason, bool) {
+	var skip *SkipFileError
+	if errors.As(err, &skip) {
+		return skip.Reason, true
+	}
+	return "", false
+}
+
 // SkippedReason clarifies why a file/directory was not processed.
 type SkippedReason string
 
@@ -15,14 +102,22 @@ const (
 	ReasonIgnoredHidden     SkippedReason = "Ignored (Hidden Rule)"
 	ReasonIgnoredRule       SkippedReason = "Ignored (Gitignore/Custom Rule)"
 	ReasonFilteredExtension SkippedReason = "Filtered (Extension Mismatch)"
+	ReasonFilteredInclude   SkippedReason = "Filtered (No Include Pattern Match)"
 	ReasonSkippedSizeLimit  SkippedReason = "Skipped (Size Limit Exceeded)"
 	ReasonSkippedNotRegular SkippedReason = "Skipped (Not a Regular File)"
+	ReasonSkippedBinary     SkippedReason = "Skipped (Binary File)"
+	ReasonSkippedSymlink    SkippedReason = "Skipped (Symlink Not Followed)"
+	ReasonSymlinkLoop       SkippedReason = "Skipped (Symlink Loop)"
+	ReasonSymlinkEscapes    SkippedReason = "Skipped (Symlink Escapes Root)"
 	ReasonSkippedPermError  SkippedReason = "Skipped (Permission Error)"
 	ReasonSkippedWalkError  SkippedReason = "Skipped (Walk Error)"
 	ReasonSkippedReadError  SkippedReason = "Skipped (Read Error)"
 	ReasonSkippedInfoError  SkippedReason = "Skipped (File Info Error)"
 	ReasonSkippedPathError  SkippedReason = "Skipped (Path Calculation Error)"
 	ReasonSkippedDirIgnored SkippedReason = "Skipped (Parent Directory Ignored)"
+	ReasonSkippedDepthLimit SkippedReason = "Skipped (Max Depth Reached)"
+	ReasonSkippedFileLimit  SkippedReason = "Skipped (Per-Directory File Limit)"
+	ReasonSkippedTokenLimit SkippedReason = "Skipped (Token Budget Exceeded)"
 )
 
 // SkippedItem holds information about a skipped path.
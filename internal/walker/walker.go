@@ -2,7 +2,9 @@
 package walker
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io/fs"
 	"os"
@@ -15,9 +17,53 @@ import (
 	"github.com/bethropolis/dir-dumper/internal/ignore"
 )
 
+// reorderWindowPerWorker is the number of read-but-undelivered files allowed
+// per worker in concurrent mode
+const reorderWindowPerWorker = 4
+
 // Walk traverses the directory tree starting from rootDir.
 // It returns a list of skipped items and any critical error that occurred.
 func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts ...Option) ([]SkippedItem, error) {
+	return WalkFiles(rootDir, matcher, func(file File, err error) error {
+		return walkFn(file.RelativePath, file.Content, err)
+	}, opts...)
+}
+
+// WalkFiles is like Walk, but passes each file's metadata to walkFn along
+// with its content
+func WalkFiles(rootDir string, matcher *ignore.IgnoreMatcher, walkFn FileFunc, opts ...Option) ([]SkippedItem, error) {
+	return walk(rootDir, matcher, func(result fileResult) error {
+		if result.err != nil {
+			return walkFn(File{RelativePath: result.relativePath}, result.err)
+		}
+		return walkFn(File{RelativePath: result.relativePath, Content: result.content, Meta: result.meta}, nil)
+	}, false, opts...)
+}
+
+// WalkStreams is like WalkFiles, but passes each file's content to walkFn as
+// a reader. Text and base64-encoded binary files are streamed from disk as
+// walkFn reads them, so memory use does not grow with file size. Only their
+// start is read ahead, to detect binary content, and their Meta.Lines and
+// Meta.SHA256 are left zero unless WithStreamScan asks for a full read ahead
+// to fill them in. Files whose content is rewritten
+// (truncated, transformed or replaced by a placeholder) are read from memory.
+func WalkStreams(rootDir string, matcher *ignore.IgnoreMatcher, walkFn StreamFunc, opts ...Option) ([]SkippedItem, error) {
+	return walk(rootDir, matcher, func(result fileResult) error {
+		if result.err != nil {
+			return walkFn(StreamFile{RelativePath: result.relativePath}, result.err)
+		}
+		file := StreamFile{RelativePath: result.relativePath, Size: result.size, Content: result.stream, Meta: result.meta}
+		if file.Content == nil {
+			file.Size, file.Content = int64(len(result.content)), bytes.NewReader(result.content)
+		}
+		return walkFn(file, nil)
+	}, true, opts...)
+}
+
+// walk traverses the directory tree for WalkFiles and WalkStreams, passing
+// every file read to deliver. With stream set, text content is left on disk
+// to be read by the callback.
+func walk(rootDir string, matcher *ignore.IgnoreMatcher, deliver deliverFunc, stream bool, opts ...Option) ([]SkippedItem, error) {
 	startTime := time.Now()
 
 	// Apply options
@@ -25,6 +71,7 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 	for _, opt := range opts {
 		opt(&options)
 	}
+	options.stream = stream
 
 	// Get absolute path for the root directory
 	absRootDir, err := filepath.Abs(rootDir)
@@ -77,8 +124,12 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 		}()
 	}
 
-	options.Logger.Debug("walker.Walk started. Root: %s, Concurrent: %v, Workers: %d",
-		absRootDir, options.Concurrent, options.MaxWorkers)
+	options.Logger.Debug("walker.Walk started. Root: %s, Concurrent: %v, Workers: %d, FollowSymlinks: %v",
+		absRootDir, options.Concurrent, options.MaxWorkers, options.FollowSymlinks)
+
+	// Count of files accepted per directory, for the per-directory file limit.
+	// Only accessed from the goroutine running the directory walk.
+	filesPerDir := make(map[string]int)
 
 	// Define the core logic for a single entry (used by both sequential and concurrent modes)
 	processEntry := func(path string, d fs.DirEntry, err error) (error, bool) {
@@ -116,10 +167,19 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 		// Handle walk errors
 		if err != nil {
 			reason := ReasonSkippedWalkError
-			if os.IsPermission(err) {
+			switch {
+			case errors.Is(err, errSymlinkLoop):
+				reason = ReasonSymlinkLoop
+			case errors.Is(err, errSymlinkEscapesRoot):
+				reason = ReasonSymlinkEscapes
+			case os.IsPermission(err):
 				reason = ReasonSkippedPermError
 			}
-			options.Logger.Error("Walker Error: Walk error for %q: %v", relativePath, err)
+			if reason == ReasonSymlinkLoop || reason == ReasonSymlinkEscapes {
+				options.Logger.Debug("Walker: Not following symlink %q: %v", relativePath, err)
+			} else {
+				options.Logger.Error("Walker Error: Walk error for %q: %v", relativePath, err)
+			}
 			tracker.Track(relativePath, reason, isDir)
 			if isDir {
 				stats.skippedDirs.Add(1)
@@ -152,13 +212,28 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 
 		// Only process files, not directories
 		if isDir {
+			// Do not descend into directories whose files would exceed the depth limit
+			if options.MaxDepth > 0 && pathDepth(relativePath) >= options.MaxDepth {
+				options.Logger.Debug("Walker: Not descending into %q (max depth %d reached)", relativePath, options.MaxDepth)
+				tracker.Track(relativePath, ReasonSkippedDepthLimit, true)
+				stats.skippedDirs.Add(1)
+				return filepath.SkipDir, false
+			}
+
+			// Prune directories that cannot contain an included file
+			if !options.Include.mayContain(relativePath) {
+				options.Logger.Debug("Walker: Pruning directory %q (no include pattern can match below)", relativePath)
+				tracker.Track(relativePath, ReasonFilteredInclude, true)
+				stats.skippedDirs.Add(1)
+				return filepath.SkipDir, false
+			}
 			options.Logger.Debug("Walker: Descending into directory %q", relativePath)
 			return nil, false
 		}
 
 		// Check extension filtering if enabled
 		if options.ExtensionMap != nil && len(options.ExtensionMap) > 0 {
-			ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(filepath.ToSlash(relativePath)), "."))
+			ext := fileExtension(relativePath)
 			_, allowed := options.ExtensionMap[ext]
 			options.Logger.Debug("Walker: Extension check for %q: ext='%s', allowed=%v",
 				relativePath, ext, allowed)
@@ -169,29 +244,71 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 			}
 		}
 
+		// Check include patterns if enabled
+		if !options.Include.allowsFile(relativePath) {
+			options.Logger.Debug("Walker: File %q matches no include pattern", relativePath)
+			tracker.Track(relativePath, ReasonFilteredInclude, false)
+			stats.skippedFiles.Add(1)
+			return nil, false
+		}
+
+		// Check the per-directory file limit
+		if options.MaxFilesDir > 0 {
+			dir := filepath.Dir(relativePath)
+			if filesPerDir[dir] >= options.MaxFilesDir {
+				options.Logger.Debug("Walker: File %q exceeds the per-directory limit of %d", relativePath, options.MaxFilesDir)
+				tracker.Track(relativePath, ReasonSkippedFileLimit, false)
+				stats.skippedFiles.Add(1)
+				return nil, false
+			}
+			filesPerDir[dir]++
+		}
+
 		options.Logger.Debug("Walker: File %q PASSED all checks, will be processed", relativePath)
 		stats.processedFiles.Add(1)
 		return nil, true
 	}
 
+	// Follow symlinks with a custom walker; symlinks emitted as entries are never followed
+	walkTree := filepath.WalkDir
+	if options.Paths != nil {
+		// Visit a fixed list of files (e.g. tracked by git) instead of the tree
+		walkTree = func(root string, fn fs.WalkDirFunc) error {
+			return walkPaths(root, options.Paths, fn)
+		}
+	} else if options.FollowSymlinks && !options.EmitSymlinks {
+		walkTree = walkFollowingSymlinks
+	}
+
 	// Choose between concurrent and sequential processing
 	if options.Concurrent {
+		// Files are read in parallel but delivered to walkFn in walk order,
+		// so output is identical to sequential mode. The window bounds how many
+		// files may be read but not yet delivered, which bounds buffered memory.
 		var wg sync.WaitGroup
-		filesChan := make(chan struct{ path, relativePath string }, options.MaxWorkers*2)
+		jobs := make(chan fileJob, options.MaxWorkers*2)
+		results := make(chan fileResult, options.MaxWorkers*2)
+		window := make(chan struct{}, options.MaxWorkers*reorderWindowPerWorker)
+
+		// The budget bounds the bytes read ahead. Files reserve their size in
+		// walk order, so the next file to deliver always holds its share.
+		budget := newByteBudget(options.MaxInFlight)
+		stopWaking := context.AfterFunc(options.Context, budget.wake)
+		defer stopWaking()
 
 		// Start worker goroutines
 		options.Logger.Debug("Starting %d workers for concurrent processing.", options.MaxWorkers)
 		for i := 0; i < options.MaxWorkers; i++ {
 			wg.Add(1)
-			go fileProcessorWorker(i+1, filesChan, &wg, options, walkFn, tracker)
+			go fileReaderWorker(i+1, jobs, results, &wg, options, tracker)
 		}
 
 		// Use a goroutine to walk the directory tree and queue files
 		done := make(chan error, 1)
-		walkFinished := make(chan struct{})
 
 		go func() {
-			walkErr := filepath.WalkDir(absRootDir, func(path string, d fs.DirEntry, err error) error {
+			seq := 0
+			walkErr := walkTree(absRootDir, func(path string, d fs.DirEntry, err error) error {
 				processDecisionErr, shouldProcess := processEntry(path, d, err)
 				if processDecisionErr != nil {
 					return processDecisionErr
@@ -208,48 +325,48 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 
 					// Triple check - make sure this isn't the root dir or "."
 					if path != absRootDir && relativePath != "." {
+						// Wait for a free slot in the reorder window
+						select {
+						case <-options.Context.Done():
+							return options.Context.Err()
+						case window <- struct{}{}:
+						}
+
+						// Wait for the bytes this file will hold
+						reserved, err := budget.acquire(options.Context, admissionSize(d, options))
+						if err != nil {
+							return err
+						}
+
 						// Send to channel with context cancellation support
 						select {
 						case <-options.Context.Done():
 							return options.Context.Err()
-						case filesChan <- struct{ path, relativePath string }{path, relativePath}:
-							options.Logger.Debug("Walker Queueing: File [%s]", relativePath)
+						case jobs <- fileJob{seq: seq, path: path, relativePath: relativePath, reserved: reserved}:
+							options.Logger.Debug("Walker Queueing: File [%s] (#%d)", relativePath, seq)
+							seq++
 						}
 					}
 				}
 				return nil
 			})
 
+			// Close the jobs channel to signal workers to finish
+			close(jobs)
 			done <- walkErr
-			close(walkFinished)
 		}()
 
-		// Wait for either context cancellation or walk completion
-		select {
-		case <-options.Context.Done():
-			options.Logger.Debug("Walker: Context cancelled, waiting for walkDir to finish...")
-			<-walkFinished // Wait for walkDir to return after it detects cancellation
-		case <-walkFinished:
-			options.Logger.Debug("Walker: Directory traversal completed")
-		}
-
-		// Now close the channel to signal workers to finish
-		close(filesChan)
-
-		// Wait for all workers to finish processing
-		options.Logger.Debug("Walker: Waiting for workers to complete...")
-		wg.Wait()
+		// Close results once all workers are done
+		go func() {
+			wg.Wait()
+			close(results)
+		}()
 
-		// Get the walk error, if any
-		var walkErr error
-		select {
-		case walkErr = <-done:
-			// Got the error (or nil)
-		default:
-			// Should never happen but just in case
-			walkErr = fmt.Errorf("walker: internal error - missing walk result")
-		}
+		// Deliver results in walk order until all workers have finished
+		deliverInOrder(results, window, budget, options, deliver, tracker)
+		options.Logger.Debug("Walker: Directory traversal and delivery completed")
 
+		walkErr := <-done
 		if walkErr != nil && walkErr != context.Canceled && walkErr != context.DeadlineExceeded {
 			options.Logger.Error("Walker: Error during directory traversal: %v", walkErr)
 		}
@@ -257,14 +374,11 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 		duration := time.Since(startTime)
 		options.Logger.Debug("Walker: Total walk and processing time: %s", duration)
 
-		if walkErr == context.Canceled || walkErr == context.DeadlineExceeded {
-			return tracker.Items(), walkErr
-		}
 		return tracker.Items(), walkErr
 	} else {
 		// Sequential processing
 		options.Logger.Debug("Walker: Starting sequential walk.")
-		walkErr := filepath.WalkDir(absRootDir, func(path string, d fs.DirEntry, err error) error {
+		walkErr := walkTree(absRootDir, func(path string, d fs.DirEntry, err error) error {
 			processDecisionErr, shouldProcess := processEntry(path, d, err)
 			if processDecisionErr != nil {
 				return processDecisionErr
@@ -282,7 +396,7 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 				// Triple check - make sure this isn't the root dir or "."
 				if path != absRootDir && relativePath != "." {
 					options.Logger.Debug("Walker Processing Sequentially: File [%s]", relativePath)
-					processFile(path, relativePath, options, walkFn, tracker)
+					processFile(path, relativePath, options, deliver, tracker)
 					stats.processedFiles.Add(1)
 				}
 			}
@@ -295,3 +409,30 @@ func Walk(rootDir string, matcher *ignore.IgnoreMatcher, walkFn WalkFunc, opts .
 		return tracker.Items(), walkErr
 	}
 }
+
+// admissionSize returns the bytes a file is expected to hold in memory once
+// read: its size, or 0 if it is not read whole because it exceeds the size
+// limit or is not a regular file
+func admissionSize(d fs.DirEntry, options WalkOptions) int64 {
+	if options.MaxInFlight <= 0 || d == nil {
+		return 0
+	}
+	info, err := d.Info()
+	if err != nil || !info.Mode().IsRegular() {
+		return 0
+	}
+	if options.MaxFileSize > 0 && info.Size() > options.MaxFileSize {
+		return 0
+	}
+	return info.Size()
+}
+
+// pathDepth returns the number of components in a relative path
+func pathDepth(relativePath string) int {
+	return strings.Count(filepath.ToSlash(relativePath), "/") + 1
+}
+
+// fileExtension returns the lowercase extension of a path, without the dot
+func fileExtension(relativePath string) string {
+	return strings.ToLower(strings.TrimPrefix(filepath.Ext(filepath.ToSlash(relativePath)), "."))
+}
@@ -0,0 +1,195 @@
+package walker
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/bethropolis/dir-dumper/internal/ignore"
+)
+
+// walkResults walks root and returns the slash-separated paths delivered,
+// sorted, and the reason each skipped path was skipped for
+func walkResults(t *testing.T, root string, opts ...Option) ([]string, map[string]SkippedReason) {
+	t.Helper()
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var delivered []string
+	skipped, err := WalkFiles(root, matcher, func(file File, err error) error {
+		if err == nil {
+			delivered = append(delivered, filepath.ToSlash(file.RelativePath))
+		}
+		return nil
+	}, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(delivered)
+	reasons := make(map[string]SkippedReason)
+	for _, item := range skipped {
+		reasons[filepath.ToSlash(item.Path)] = item.Reason
+	}
+	return delivered, reasons
+}
+
+// TestDeliverInOrder feeds the reorder buffer results out of order and
+// checks that they are delivered in sequence, freeing a window slot each
+func TestDeliverInOrder(t *testing.T) {
+	arrival := []int{3, 1, 0, 5, 2, 4, 7, 6}
+	results := make(chan fileResult, len(arrival))
+	window := make(chan struct{}, len(arrival))
+	for _, seq := range arrival {
+		results <- fileResult{seq: seq, relativePath: fmt.Sprint(seq), content: []byte{}, deliver: true}
+		window <- struct{}{}
+	}
+	close(results)
+
+	var delivered []string
+	deliverInOrder(results, window, nil, defaultOptions(), func(result fileResult) error {
+		delivered = append(delivered, result.relativePath)
+		return nil
+	}, NewSkippedTracker(0))
+
+	want := []string{"0", "1", "2", "3", "4", "5", "6", "7"}
+	if !reflect.DeepEqual(delivered, want) {
+		t.Errorf("delivered %v, want %v", delivered, want)
+	}
+	if len(window) != 0 {
+		t.Errorf("%d window slots still held", len(window))
+	}
+}
+
+// TestDeliverInOrderGap checks that results after a missing one are held
+// back rather than delivered out of order
+func TestDeliverInOrderGap(t *testing.T) {
+	results := make(chan fileResult, 2)
+	window := make(chan struct{}, 2)
+	for _, seq := range []int{1, 2} {
+		results <- fileResult{seq: seq, content: []byte{}, deliver: true}
+		window <- struct{}{}
+	}
+	close(results)
+
+	delivered := 0
+	deliverInOrder(results, window, nil, defaultOptions(), func(fileResult) error {
+		delivered++
+		return nil
+	}, NewSkippedTracker(0))
+	if delivered != 0 {
+		t.Errorf("%d results delivered before the first one arrived", delivered)
+	}
+}
+
+// TestConcurrentOrder checks that concurrent walks deliver files in the same
+// order as sequential ones, however the reads finish
+func TestConcurrentOrder(t *testing.T) {
+	root := t.TempDir()
+	for i := 0; i < 60; i++ {
+		// Mixed sizes, so reads finish out of order
+		size := (i * 7919) % 50000
+		writeFile(t, root, filepath.Join(fmt.Sprintf("dir%d", i%4), fmt.Sprintf("sub%d", i%3), fmt.Sprintf("f%02d.txt", i)), strings.Repeat("x", size)+"\n")
+	}
+	matcher, err := ignore.New(root, ignore.WithDumperIgnore(false))
+	if err != nil {
+		t.Fatal(err)
+	}
+	walkOrder := func(opts ...Option) []string {
+		t.Helper()
+		var order []string
+		_, err := WalkFiles(root, matcher, func(file File, err error) error {
+			if err != nil {
+				t.Errorf("%s: %v", file.RelativePath, err)
+			}
+			order = append(order, file.RelativePath)
+			return nil
+		}, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return order
+	}
+
+	want := walkOrder()
+	if len(want) != 60 {
+		t.Fatalf("sequential walk delivered %d files, want 60", len(want))
+	}
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"one worker", []Option{WithConcurrency(true), WithMaxWorkers(1)}},
+		{"many workers", []Option{WithConcurrency(true), WithMaxWorkers(16)}},
+		{"in-flight limit", []Option{WithConcurrency(true), WithMaxWorkers(8), WithMaxInFlightBytes(64 * 1024)}},
+	} {
+		for run := 0; run < 3; run++ {
+			if got := walkOrder(tc.opts...); !reflect.DeepEqual(got, want) {
+				t.Fatalf("%s: concurrent order differs from sequential:\n got %q\nwant %q", tc.name, got, want)
+			}
+		}
+	}
+}
+
+func TestMaxDepth(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"a.txt", "d1/b.txt", "d1/d2/c.txt", "d1/d2/d3/d.txt"} {
+		writeFile(t, root, name, "content\n")
+	}
+
+	tests := []struct {
+		depth    int
+		want     []string
+		limitDir string // Directory not descended into
+	}{
+		{0, []string{"a.txt", "d1/b.txt", "d1/d2/c.txt", "d1/d2/d3/d.txt"}, ""},
+		{1, []string{"a.txt"}, "d1"},
+		{2, []string{"a.txt", "d1/b.txt"}, "d1/d2"},
+		{3, []string{"a.txt", "d1/b.txt", "d1/d2/c.txt"}, "d1/d2/d3"},
+		{4, []string{"a.txt", "d1/b.txt", "d1/d2/c.txt", "d1/d2/d3/d.txt"}, ""},
+	}
+	for _, tt := range tests {
+		for _, concurrent := range []bool{false, true} {
+			delivered, reasons := walkResults(t, root, WithMaxDepth(tt.depth), WithConcurrency(concurrent))
+			if !reflect.DeepEqual(delivered, tt.want) {
+				t.Errorf("depth %d, concurrent=%v: delivered %q, want %q", tt.depth, concurrent, delivered, tt.want)
+			}
+			if tt.limitDir != "" && reasons[tt.limitDir] != ReasonSkippedDepthLimit {
+				t.Errorf("depth %d: %s skipped as %q, want %q", tt.depth, tt.limitDir, reasons[tt.limitDir], ReasonSkippedDepthLimit)
+			}
+			for path, reason := range reasons {
+				if reason == ReasonSkippedDepthLimit && path != tt.limitDir {
+					t.Errorf("depth %d: %s also reported past the depth limit", tt.depth, path)
+				}
+			}
+		}
+	}
+}
+
+// TestMaxFilesPerDir checks that each directory keeps its first files in
+// walk order, and that filtered files do not count against the limit
+func TestMaxFilesPerDir(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"0.md", "a.txt", "b.txt", "c.txt", "sub/a.txt", "sub/b.txt", "sub/c.txt", "sub/deep/a.txt"} {
+		writeFile(t, root, name, "content\n")
+	}
+
+	for _, concurrent := range []bool{false, true} {
+		delivered, reasons := walkResults(t, root, WithMaxFilesPerDir(2), WithExtensions([]string{"txt"}), WithConcurrency(concurrent))
+		want := []string{"a.txt", "b.txt", "sub/a.txt", "sub/b.txt", "sub/deep/a.txt"}
+		if !reflect.DeepEqual(delivered, want) {
+			t.Errorf("concurrent=%v: delivered %q, want %q", concurrent, delivered, want)
+		}
+		wantReasons := map[string]SkippedReason{
+			"0.md":      ReasonFilteredExtension,
+			"c.txt":     ReasonSkippedFileLimit,
+			"sub/c.txt": ReasonSkippedFileLimit,
+		}
+		if !reflect.DeepEqual(reasons, wantReasons) {
+			t.Errorf("concurrent=%v: skipped %v, want %v", concurrent, reasons, wantReasons)
+		}
+	}
+}